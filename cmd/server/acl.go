@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/authlog"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// netACL decides which addresses may connect, from config.AllowCIDRs and
+// config.DenyCIDRs. A denied network always loses; when an allowlist is
+// set, only addresses on it get in.
+type netACL struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+func newNetACL(allow, deny []string) (*netACL, error) {
+	a := &netACL{}
+	var err error
+	if a.allow, err = parseNets(allow); err != nil {
+		return nil, fmt.Errorf("ALLOW_CIDRS: %w", err)
+	}
+	if a.deny, err = parseNets(deny); err != nil {
+		return nil, fmt.Errorf("DENY_CIDRS: %w", err)
+	}
+	return a, nil
+}
+
+// parseNets reads CIDR blocks; a bare address stands for itself alone.
+func parseNets(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an address or CIDR block", s)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// enabled reports whether any rule is set.
+func (a *netACL) enabled() bool {
+	return len(a.allow) > 0 || len(a.deny) > 0
+}
+
+// permits reports whether addr ("host:port") may connect.
+func (a *netACL) permits(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range a.deny {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	for _, n := range a.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// aclOption drops connections from networks the ACL doesn't permit as
+// soon as they are accepted, before the SSH handshake, so a denied client
+// costs no key exchange and never reaches authentication.
+func aclOption(acl *netACL) ssh.Option {
+	return func(srv *ssh.Server) error {
+		srv.ConnCallback = func(ctx ssh.Context, conn net.Conn) net.Conn {
+			addr := conn.RemoteAddr().String()
+			if acl.enabled() && !acl.permits(addr) {
+				log.Info("Rejected connection by network ACL", "addr", addr)
+				authlog.Log(authlog.Reject, addr, "", "", "network not allowed")
+				return nil
+			}
+			return conn
+		}
+		return nil
+	}
+}
+
+// aclMiddleware is a second line behind aclOption: it turns away a
+// session from a network the ACL doesn't permit before anything else
+// runs for it.
+func aclMiddleware(acl *netACL) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			if acl.enabled() && !acl.permits(s.RemoteAddr().String()) {
+				log.Info("Rejected connection by network ACL", "addr", s.RemoteAddr())
+				logAuth(s, authlog.Reject, "network not allowed")
+				wish.Fatalln(s, "This server doesn't accept connections from your network.")
+				return
+			}
+			next(s)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/authlog"
+	"github.com/aminshahid573/termplay/internal/config"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// authorizedKeys is the allowlist in config.AuthorizedKeysFile, read
+// again whenever the file changes, so friends can be added without a
+// restart.
+type authorizedKeys struct {
+	path string
+
+	mu    sync.Mutex
+	mtime time.Time
+	keys  [][]byte // wire form of each listed key
+}
+
+// load reads the file if it changed since it was last read.
+func (a *authorizedKeys) load() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(a.mtime) {
+		return nil
+	}
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return err
+	}
+	var keys [][]byte
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := gossh.ParseAuthorizedKey(data)
+		if err != nil {
+			// ParseAuthorizedKey skips lines that aren't keys itself, and
+			// only errors once no key is left in the rest of the file
+			break
+		}
+		keys = append(keys, key.Marshal())
+		data = rest
+	}
+	a.mtime, a.keys = info.ModTime(), keys
+	log.Info("Loaded authorized keys", "path", a.path, "keys", len(keys))
+	return nil
+}
+
+// allows reports whether key is listed. If the file can't be read, the
+// keys read last still count.
+func (a *authorizedKeys) allows(key ssh.PublicKey) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(); err != nil {
+		log.Warn("Failed to read authorized keys", "path", a.path, "err", err)
+	}
+	wire := key.Marshal()
+	for _, k := range a.keys {
+		if bytes.Equal(k, wire) {
+			return true
+		}
+	}
+	return false
+}
+
+// authOptions installs the server's auth handlers. Any key is accepted,
+// so players are known by it, and a client without one is let in by
+// keyboard-interactive auth, without being asked anything, to play as a
+// guest. With config.AuthorizedKeysFile set, only listed keys are
+// accepted: unlisted ones are refused during authentication, so the
+// client goes on to offer its other keys, and a client out of keys gets
+// in only for authorizedKeysMiddleware to tell them why they can't play.
+func authOptions() ([]ssh.Option, *authorizedKeys, error) {
+	var ak *authorizedKeys
+	if config.AuthorizedKeysFile != "" {
+		ak = &authorizedKeys{path: config.AuthorizedKeysFile}
+		if err := ak.load(); err != nil {
+			return nil, nil, fmt.Errorf("AUTHORIZED_KEYS: %w", err)
+		}
+	}
+	return []ssh.Option{
+		wish.WithPublicKeyAuth(func(_ ssh.Context, key ssh.PublicKey) bool {
+			return ak == nil || ak.allows(key)
+		}),
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool {
+			return true
+		}),
+	}, ak, nil
+}
+
+// authorizedKeysMiddleware turns away sessions that didn't authenticate
+// with a listed key, telling them how to get in.
+func authorizedKeysMiddleware(ak *authorizedKeys) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			if ak == nil || s.PublicKey() != nil && ak.allows(s.PublicKey()) {
+				next(s)
+				return
+			}
+			log.Info("Rejected connection without an authorized key", "addr", s.RemoteAddr())
+			logAuth(s, authlog.Reject, "key not authorized")
+			wish.Fatalln(s, "This is a private termplay server: only SSH keys its operator has listed may play.\n"+
+				"Send them your public key (usually ~/.ssh/id_ed25519.pub) and ask to be added,\n"+
+				"or, if they have added it already, connect with it: ssh -i ~/.ssh/<key> ...")
+		}
+	}
+}
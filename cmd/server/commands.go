@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
+)
+
+const usage = `usage:
+  termplay                      start the SSH server
+  termplay local                play in this terminal instead of over SSH
+  termplay export --out FILE    snapshot rooms, profiles and stats to FILE
+  termplay import [--yes] FILE  restore a snapshot, replacing current data
+  termplay migrate              upgrade every stored record to the current schema
+  termplay motd [TEXT|--file FILE|--clear]
+                                show, set or clear the message of the day
+  termplay maintenance [on [--in DURATION] [--message TEXT]|off]
+                                show or switch maintenance mode
+  termplay compat [--min N [--block]|--clear]
+                                show or set the oldest protocol servers
+                                sharing the database must speak
+  termplay sync [--sync D] [--active D] [--idle D] | --clear
+                                show or override how often games poll
+                                the database
+  termplay roomlog [--json] CODE
+                                print the event log of a room
+  termplay version              print the version and protocol of this build
+`
+
+// runCommand handles the maintenance subcommands. It reports false when
+// args name no subcommand and the server should start as usual.
+func runCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("export", flag.ExitOnError)
+		out := fs.String("out", "", "file to write the snapshot to")
+		fs.Parse(args[1:])
+		if *out == "" {
+			return true, fmt.Errorf("export: --out is required\n\n%s", usage)
+		}
+		return true, exportTo(*out)
+
+	case "import":
+		fs := flag.NewFlagSet("import", flag.ExitOnError)
+		yes := fs.Bool("yes", false, "skip the confirmation prompt")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return true, fmt.Errorf("import: expected one file\n\n%s", usage)
+		}
+		return true, importFrom(fs.Arg(0), *yes)
+
+	case "migrate":
+		if err := db.Init(); err != nil {
+			return true, err
+		}
+		rooms, profiles, err := db.MigrateAll()
+		fmt.Printf("Upgraded %d rooms and %d profiles (room schema v%d, profile schema v%d)\n",
+			rooms, profiles, db.RoomSchemaVersion, db.ProfileSchemaVersion)
+		return true, err
+
+	case "motd":
+		fs := flag.NewFlagSet("motd", flag.ExitOnError)
+		file := fs.String("file", "", "read the message from FILE")
+		clear := fs.Bool("clear", false, "remove the message")
+		fs.Parse(args[1:])
+		return true, setMOTD(strings.Join(fs.Args(), " "), *file, *clear)
+
+	case "maintenance":
+		if len(args) < 2 {
+			return true, showMaintenance()
+		}
+		fs := flag.NewFlagSet("maintenance", flag.ExitOnError)
+		in := fs.Duration("in", config.MaintenanceCountdown, "time players get to finish their games")
+		message := fs.String("message", "", "shown to players along with the countdown")
+		fs.Parse(args[2:])
+		switch args[1] {
+		case "on":
+			return true, setMaintenance(&db.Maintenance{Start: time.Now().Add(*in).Unix(), Message: *message, By: "cli"})
+		case "off":
+			return true, setMaintenance(nil)
+		}
+		return true, fmt.Errorf("maintenance: expected on or off\n\n%s", usage)
+
+	case "compat":
+		fs := flag.NewFlagSet("compat", flag.ExitOnError)
+		minimum := fs.Int("min", 0, "oldest protocol allowed to write")
+		block := fs.Bool("block", false, "refuse writes from older servers instead of warning")
+		clear := fs.Bool("clear", false, "remove the requirement")
+		fs.Parse(args[1:])
+		switch {
+		case *clear:
+			return true, setCompat(nil)
+		case *minimum > 0:
+			return true, setCompat(&db.Compat{MinProtocol: *minimum, Block: *block, By: "cli", At: time.Now().Unix()})
+		}
+		return true, showCompat()
+
+	case "sync":
+		fs := flag.NewFlagSet("sync", flag.ExitOnError)
+		syncEvery := fs.Duration("sync", 0, "poll interval on the player's move")
+		active := fs.Duration("active", 0, "poll interval while waiting on the opponent")
+		idle := fs.Duration("idle", 0, "poll interval in the lobby and between games")
+		clear := fs.Bool("clear", false, "go back to the configured intervals")
+		fs.Parse(args[1:])
+		switch {
+		case *clear:
+			return true, setTuning(nil)
+		case *syncEvery > 0 || *active > 0 || *idle > 0:
+			return true, setTuning(&db.Tuning{
+				Sync:   syncEvery.Milliseconds(),
+				Active: active.Milliseconds(),
+				Idle:   idle.Milliseconds(),
+				By:     "cli",
+				At:     time.Now().Unix(),
+			})
+		}
+		return true, showTuning()
+
+	case "roomlog":
+		fs := flag.NewFlagSet("roomlog", flag.ExitOnError)
+		asJSON := fs.Bool("json", false, "print one JSON object per event")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return true, fmt.Errorf("roomlog: expected a room code\n\n%s", usage)
+		}
+		return true, printRoomLog(strings.ToUpper(fs.Arg(0)), *asJSON)
+
+	case "local":
+		return true, runLocal()
+
+	case "version":
+		fmt.Printf("termplay %s, protocol %d\n", metrics.BuildVersion(), db.Protocol)
+		return true, nil
+
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return true, nil
+	}
+	return true, fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+}
+
+func exportTo(path string) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	d, err := db.Export()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Exported to %s (%d bytes)\n", path, len(data))
+	return nil
+}
+
+// setMOTD stores the message of the day from text or file, clears it, or
+// with none of those prints the current one.
+func setMOTD(text, file string, clear bool) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	switch {
+	case clear:
+		if err := db.SetMOTD(""); err != nil {
+			return err
+		}
+		fmt.Println("Message of the day cleared")
+		return nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	case text == "":
+		cur, err := db.GetMOTD()
+		if err != nil {
+			return err
+		}
+		if cur == "" {
+			fmt.Println("No message of the day set")
+		} else {
+			fmt.Println(cur)
+		}
+		return nil
+	}
+	text = strings.TrimSpace(text)
+	if err := db.SetMOTD(text); err != nil {
+		return err
+	}
+	fmt.Printf("Message of the day set (%d lines)\n", strings.Count(text, "\n")+1)
+	return nil
+}
+
+func showMaintenance() error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	mt, err := db.GetMaintenance()
+	if err != nil {
+		return err
+	}
+	if mt == nil {
+		fmt.Println("Maintenance mode is off")
+		return nil
+	}
+	fmt.Printf("Maintenance mode is on, going down at %s (set by %s)\n", time.Unix(mt.Start, 0).Format(time.RFC3339), mt.By)
+	return nil
+}
+
+func setMaintenance(mt *db.Maintenance) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	if err := db.SetMaintenance("cli", mt); err != nil {
+		return err
+	}
+	if mt == nil {
+		fmt.Println("Maintenance mode off")
+	} else {
+		fmt.Printf("Maintenance mode on, going down in %s\n", mt.Remaining().Round(time.Second))
+	}
+	return nil
+}
+
+func showCompat() error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	c, err := db.GetCompat()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("This build: %s, protocol %d\n", metrics.BuildVersion(), db.Protocol)
+	if c == nil {
+		fmt.Println("No minimum protocol set")
+		return nil
+	}
+	mode := "older servers are warned"
+	if c.Block {
+		mode = "older servers can't write"
+	}
+	fmt.Printf("Minimum protocol %d, %s (set by %s)\n", c.MinProtocol, mode, c.By)
+	return nil
+}
+
+func setCompat(c *db.Compat) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	if c != nil && c.MinProtocol > db.Protocol {
+		// Setting it from a build that falls short would lock out this very build
+		return fmt.Errorf("compat: this build speaks protocol %d, upgrade it before requiring %d", db.Protocol, c.MinProtocol)
+	}
+	if err := db.SetCompat("cli", c); err != nil {
+		return err
+	}
+	if c == nil {
+		fmt.Println("Minimum protocol cleared")
+	} else {
+		fmt.Printf("Minimum protocol set to %d\n", c.MinProtocol)
+	}
+	return nil
+}
+
+func showTuning() error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	t, err := db.GetTuning()
+	if err != nil {
+		return err
+	}
+	iv := t.Intervals()
+	fmt.Printf("Polling every %s on your move, %s waiting, %s idle\n", iv.Sync, iv.Active, iv.Idle)
+	if t == nil {
+		fmt.Println("No overrides, as configured")
+	} else {
+		fmt.Printf("Overridden by %s at %s\n", t.By, time.Unix(t.At, 0).Format(time.RFC3339))
+	}
+	return nil
+}
+
+func setTuning(t *db.Tuning) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	if err := db.SetTuning("cli", t); err != nil {
+		return err
+	}
+	if t == nil {
+		fmt.Println("Poll overrides cleared")
+	} else {
+		iv := t.Intervals()
+		fmt.Printf("Polling every %s on your move, %s waiting, %s idle\n", iv.Sync, iv.Active, iv.Idle)
+	}
+	return nil
+}
+
+func printRoomLog(code string, asJSON bool) error {
+	if err := db.Init(); err != nil {
+		return err
+	}
+	events, err := db.GetRoomLog(code)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Printf("Nothing logged for room %s in the last %d days\n", code, config.RoomLogDays)
+		return nil
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for _, e := range events {
+		if asJSON {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("%s  seq %-4d %-8s %-20s %s  [%s]\n",
+			time.UnixMilli(e.At).UTC().Format("2006-01-02 15:04:05.000"), e.Seq, e.Kind, e.Player, e.Detail, e.Server)
+	}
+	return nil
+}
+
+func importFrom(path string, yes bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var d db.Dump
+	if err := json.Unmarshal(data, &d); err != nil {
+		return fmt.Errorf("%s is not a termplay export: %v", path, err)
+	}
+
+	if !yes {
+		fmt.Printf("This replaces rooms, profiles and stats with the contents of %s. Continue? [y/N] ", path)
+		var answer string
+		fmt.Scanln(&answer)
+		if answer != "y" && answer != "Y" {
+			return fmt.Errorf("import cancelled")
+		}
+	}
+
+	if err := db.Init(); err != nil {
+		return err
+	}
+	if err := db.Import(&d); err != nil {
+		return err
+	}
+	fmt.Println("Import complete")
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	"github.com/charmbracelet/keygen"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// hostKeyOptions serves every host key in config.HostKeys, generating
+// the ones that don't exist yet. Clients pick the key type they know, so
+// an ed25519 key plus an RSA one covers both modern and old clients.
+func hostKeyOptions() []ssh.Option {
+	var opts []ssh.Option
+	for _, path := range config.HostKeys {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			kt := hostKeyType(path)
+			log.Info("Generating host key", "path", path, "type", kt)
+			if _, err := keygen.New(path, keygen.WithKeyType(kt), keygen.WithWrite()); err != nil {
+				return []ssh.Option{func(*ssh.Server) error { return fmt.Errorf("host key %s: %w", path, err) }}
+			}
+		}
+		opts = append(opts, ssh.HostKeyFile(path))
+	}
+	return opts
+}
+
+// hostKeyType picks the type of a host key to generate from its file
+// name, the way OpenSSH names them (ssh_host_rsa_key and so on).
+// Anything else gets ed25519.
+func hostKeyType(path string) keygen.KeyType {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, "rsa"):
+		return keygen.RSA
+	case strings.Contains(name, "ecdsa"):
+		return keygen.ECDSA
+	}
+	return keygen.Ed25519
+}
+
+// algorithmsOption narrows the key exchanges, ciphers and MACs offered
+// to clients to config.SSHKeyExchanges, SSHCiphers and SSHMACs. Empty
+// lists keep the library's defaults. Names the library doesn't know are
+// an error, so a typo can't quietly leave a list at its default.
+func algorithmsOption() ssh.Option {
+	return func(s *ssh.Server) error {
+		supported, insecure := gossh.SupportedAlgorithms(), gossh.InsecureAlgorithms()
+		lists := []struct {
+			env  string
+			want []string
+			have [][]string
+		}{
+			{"SSH_KEX", config.SSHKeyExchanges, [][]string{supported.KeyExchanges, insecure.KeyExchanges}},
+			{"SSH_CIPHERS", config.SSHCiphers, [][]string{supported.Ciphers, insecure.Ciphers}},
+			{"SSH_MACS", config.SSHMACs, [][]string{supported.MACs, insecure.MACs}},
+		}
+		for _, l := range lists {
+			for _, name := range l.want {
+				if !slices.Contains(l.have[0], name) && !slices.Contains(l.have[1], name) {
+					return fmt.Errorf("%s: unknown algorithm %q", l.env, name)
+				}
+			}
+		}
+
+		s.ServerConfigCallback = func(ssh.Context) *gossh.ServerConfig {
+			c := &gossh.ServerConfig{}
+			c.KeyExchanges = config.SSHKeyExchanges
+			c.Ciphers = config.SSHCiphers
+			c.MACs = config.SSHMACs
+			return c
+		}
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aminshahid573/termplay/internal/authlog"
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/ui"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// interactiveOnly is what clients that came for a shell or a file
+// transfer are told before they are let go.
+func interactiveOnly() string {
+	return fmt.Sprintf("This server only hosts games: there is no shell, commands or file transfer.\n"+
+		"Connect interactively instead: ssh -p %[1]d <host>\n"+
+		"or with options: ssh -t -p %[1]d <host> %[2]s", config.Port, ui.ArgsUsage)
+}
+
+// interactiveMiddleware turns away `ssh host ls` and anything else that
+// runs a command or has no terminal, with a message saying how to connect
+// instead of a bare "requires an active PTY". The arguments ui.ParseArgs
+// accepts are let through.
+func interactiveMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		reason := ""
+		if _, err := ui.ParseArgs(s.Command()); err != nil {
+			reason = "exec"
+		} else if _, _, ok := s.Pty(); !ok {
+			reason = "no pty"
+		}
+		if reason != "" {
+			log.Info("Rejected non-interactive session", "user", s.User(), "addr", s.RemoteAddr(), "reason", reason)
+			logAuth(s, authlog.Reject, reason)
+			wish.Fatalln(s, interactiveOnly())
+			return
+		}
+		next(s)
+	}
+}
+
+// rejectSubsystems answers SFTP (which `scp` also uses nowadays) with the
+// same message, where the client would otherwise only see the subsystem
+// request fail.
+func rejectSubsystems() ssh.Option {
+	return func(srv *ssh.Server) error {
+		srv.SubsystemHandlers = map[string]ssh.SubsystemHandler{
+			"sftp": func(s ssh.Session) {
+				logAuth(s, authlog.Reject, "sftp")
+				wish.Fatalln(s, interactiveOnly())
+			},
+		}
+		return nil
+	}
+}
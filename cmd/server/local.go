@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	stdlog "log"
+	"os"
+
+	"github.com/aminshahid573/termplay/internal/bus"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// runLocal plays in this terminal instead of serving SSH, as the player
+// "local", against the configured database. Unlike over SSH, Ctrl+Z
+// suspends the game here. Logs go to termplay-local.log.
+func runLocal() error {
+	// Logs on the terminal would scribble over the game
+	var logTo io.Writer = io.Discard
+	if f, err := os.OpenFile("termplay-local.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil {
+		defer f.Close()
+		logTo = f
+	}
+	log.SetOutput(logTo)
+	stdlog.SetOutput(logTo)
+
+	if err := db.Init(); err != nil {
+		return err
+	}
+	if err := bus.Init(); err != nil {
+		return err
+	}
+
+	cleanup := &ui.CleanupState{}
+	_, err := tea.NewProgram(ui.InitialModel(nil, cleanup), tea.WithAltScreen()).Run()
+	leaveRooms(cleanup)
+	return err
+}
@@ -3,21 +3,28 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	stdlog "log"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/aminshahid573/termplay/internal/authlog"
+	"github.com/aminshahid573/termplay/internal/bus"
 	"github.com/aminshahid573/termplay/internal/config"
 	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/hooks"
+	"github.com/aminshahid573/termplay/internal/logfile"
+	"github.com/aminshahid573/termplay/internal/metrics"
 	"github.com/aminshahid573/termplay/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
-	"github.com/charmbracelet/wish/activeterm"
 	bm "github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
 )
@@ -25,24 +32,87 @@ import (
 var cleanupWg sync.WaitGroup
 
 func main() {
+	if handled, err := runCommand(os.Args[1:]); handled {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.LogFile != "" {
+		f, err := logfile.Open(config.LogFile, config.LogMaxSize, config.LogRotateEvery, config.LogMaxAge)
+		if err != nil {
+			log.Fatal("Failed to open log file", "err", err)
+		}
+		defer f.Close()
+		w := io.MultiWriter(os.Stderr, f)
+		log.SetOutput(w)
+		stdlog.SetOutput(w)
+	}
+
+	if config.AuthLogFile != "" {
+		if err := authlog.Open(config.AuthLogFile); err != nil {
+			log.Fatal("Failed to open auth log", "err", err)
+		}
+	}
+
+	acl, err := newNetACL(config.AllowCIDRs, config.DenyCIDRs)
+	if err != nil {
+		log.Fatal("Bad network ACL", "err", err)
+	}
+
 	// 1. Init DB
 	if err := db.Init(); err != nil {
 		log.Fatal("Failed to init Firebase", "err", err)
 	}
 
-	// Cleanup old rooms on startup
-	go db.CleanZombies()
+	if err := bus.Init(); err != nil {
+		log.Fatal("Failed to init message bus", "err", err)
+	}
+
+	// Clean up idle rooms on startup, and every JANITOR_INTERVAL after
+	go db.RunJanitor()
+
+	// Roll archived games into daily/weekly summaries every night
+	go db.RunStatsAggregator()
+
+	// Pick up maintenance mode, however it was switched on
+	go db.RunMaintenanceWatch()
+
+	// Warn, or stop writing, if the database needs a newer protocol
+	go db.RunCompatWatch()
+
+	// Pick up poll cadence overrides set by admins
+	go db.RunTuningWatch()
+
+	if config.MetricsAddr != "" {
+		go serveMetrics(config.MetricsAddr)
+	}
 
 	// 2. Setup SSH
-	s, err := wish.NewServer(
-		wish.WithAddress(fmt.Sprintf("%s:%d", config.Host, config.Port)),
-		wish.WithHostKeyPath("ssh_host_key"),
+	opts := []ssh.Option{wish.WithAddress(fmt.Sprintf("%s:%d", config.Host, config.Port))}
+	opts = append(opts, hostKeyOptions()...)
+	keyOpts, authorized, err := authOptions()
+	if err != nil {
+		log.Fatal("Bad authorized keys", "err", err)
+	}
+	opts = append(opts, keyOpts...)
+	opts = append(opts, algorithmsOption(), rejectSubsystems(), aclOption(acl),
 		wish.WithMiddleware(
 			bm.Middleware(teaHandler),
+			throughputMiddleware,
+			keyboardMiddleware,
+			banMiddleware,
 			logging.Middleware(),
-			activeterm.Middleware(),
+			interactiveMiddleware,
+			keepAliveMiddleware,
+			authorizedKeysMiddleware(authorized),
+			aclMiddleware(acl),
+			authLogMiddleware,
 		),
 	)
+	s, err := wish.NewServer(opts...)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -72,25 +142,176 @@ func main() {
 	log.Info("Shutdown complete")
 }
 
+// banMiddleware turns away players banned from the admin console before a
+// program is started for them.
+func banMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		if p, err := db.GetProfile(ui.SessionID(s)); err == nil && p.Banned {
+			log.Info("Rejected banned player", "id", p.ID)
+			logAuth(s, authlog.Reject, "banned")
+			wish.Fatalln(s, "This account has been banned.")
+			return
+		}
+		next(s)
+	}
+}
+
+// authLogMiddleware records every connection in the auth log.
+func authLogMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		logAuth(s, authlog.Connect, "")
+		next(s)
+	}
+}
+
+// logAuth writes an auth log event about s.
+func logAuth(s ssh.Session, kind, reason string) {
+	key := ""
+	if s.PublicKey() != nil {
+		key = ui.KeyID(s)
+	}
+	authlog.Log(kind, s.RemoteAddr().String(), s.User(), key, reason)
+}
+
+// keepAliveMiddleware pings the client every config.KeepAliveInterval,
+// the way OpenSSH's ClientAliveInterval does, so long quiet games aren't
+// dropped by NAT timeouts and dead connections are noticed. The pings
+// also measure the player's round-trip time, see ui.SessionLatency.
+func keepAliveMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		rtt := ui.SessionLatency(s)
+		if config.KeepAliveInterval > 0 {
+			go keepAlive(s, rtt)
+		}
+		next(s)
+	}
+}
+
+func keepAlive(s ssh.Session, rtt *metrics.Latency) {
+	var missed atomic.Int32
+	t := time.NewTicker(config.KeepAliveInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.Context().Done():
+			return
+		case <-t.C:
+		}
+		if config.KeepAliveMax > 0 && int(missed.Load()) >= config.KeepAliveMax {
+			log.Info("Dropping unresponsive client", "user", s.User(), "addr", s.RemoteAddr())
+			s.Close()
+			return
+		}
+		missed.Add(1)
+		go func() {
+			// Clients answer "failure" to the unknown request; any answer will do
+			start := time.Now()
+			if _, err := s.SendRequest("keepalive@openssh.com", true, nil); err == nil {
+				missed.Store(0)
+				rtt.Observe(time.Since(start))
+			}
+		}()
+	}
+}
+
+// writeBlocked is how long a write to the client has to take to count as
+// blocked on a full window, rather than just copied into a buffer.
+const writeBlocked = 20 * time.Millisecond
+
+// throughputMiddleware counts and times the program's writes to the
+// client, so slow links can be told apart, see ui.SessionThroughput.
+func throughputMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		next(meteredSession{s, ui.SessionThroughput(s)})
+	}
+}
+
+type meteredSession struct {
+	ssh.Session
+	meter *metrics.Throughput
+}
+
+func (s meteredSession) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := s.Session.Write(p)
+	s.meter.AddSent(n)
+	if d := time.Since(start); d >= writeBlocked {
+		s.meter.Observe(n, d)
+	}
+	return n, err
+}
+
+// keyboardMiddleware translates the enhanced key reports the program asks
+// the client's terminal for, see ui.NewKeyReader, and switches them off
+// again once the program is done.
+func keyboardMiddleware(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		next(keyboardSession{s, ui.NewKeyReader(s)})
+		io.WriteString(s, ui.EnhancedKeysOff)
+	}
+}
+
+type keyboardSession struct {
+	ssh.Session
+	in io.Reader
+}
+
+func (s keyboardSession) Read(p []byte) (int, error) {
+	return s.in.Read(p)
+}
+
 func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	cleanup := &ui.CleanupState{}
+	metrics.Sessions.Add(1)
+	unregister := ui.RegisterSession(s)
 
 	cleanupWg.Add(1)
 	// Start cleanup routine
 	go func() {
 		defer cleanupWg.Done()
 		<-s.Context().Done()
+		metrics.Sessions.Add(-1)
+		unregister()
 
-		cleanup.Mu.Lock()
-		defer cleanup.Mu.Unlock()
-
-		if cleanup.RoomCode != "" {
-			log.Info("Cleaning up room", "code", cleanup.RoomCode, "id", cleanup.SessionID)
-			if err := db.LeaveRoom(cleanup.RoomCode, cleanup.SessionID, cleanup.IsHost); err != nil {
-				log.Error("Cleanup Error", "err", err)
-			}
-		}
+		leaveRooms(cleanup)
 	}()
 
-	return ui.InitialModel(s, cleanup), []tea.ProgramOption{tea.WithAltScreen()}
+	m := ui.InitialModel(s, cleanup)
+	hooks.FirePlayerConnected(hooks.PlayerConnected{SessionID: m.SessionID, Addr: s.RemoteAddr().String(),
+		User: s.User(), Guest: !m.HasKey, At: time.Now()})
+	return m, []tea.ProgramOption{tea.WithAltScreen()}
+}
+
+// leaveRooms removes a player who has gone from every room they were in.
+func leaveRooms(cleanup *ui.CleanupState) {
+	cleanup.Mu.Lock()
+	defer cleanup.Mu.Unlock()
+
+	if cleanup.StopEvents != nil {
+		cleanup.StopEvents()
+	}
+	if cleanup.StopLobbyChat != nil {
+		cleanup.StopLobbyChat()
+	}
+	if cleanup.StopRoomAlert != nil {
+		cleanup.StopRoomAlert()
+	}
+	if cleanup.RoomCode != "" {
+		log.Info("Cleaning up room", "code", cleanup.RoomCode, "id", cleanup.SessionID)
+		if err := db.Disconnect(cleanup.RoomCode, cleanup.SessionID, cleanup.IsHost); err != nil {
+			log.Error("Cleanup Error", "err", err)
+		}
+	}
+	for _, t := range cleanup.Tabs {
+		if t.StopEvents != nil {
+			t.StopEvents()
+		}
+		if t.RoomCode == "" {
+			continue
+		}
+		log.Info("Cleaning up room", "code", t.RoomCode, "id", cleanup.SessionID)
+		if err := db.Disconnect(t.RoomCode, cleanup.SessionID, t.IsHost); err != nil {
+			log.Error("Cleanup Error", "err", err)
+		}
+	}
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
+
+	"github.com/charmbracelet/log"
+)
+
+// serveMetrics serves Prometheus metrics at /metrics on addr: the rooms
+// in the store by game type and status, shared by every server, and this
+// server's own sessions, database calls and finished games.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	log.Info("Serving metrics", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Error("Metrics server", "err", err)
+	}
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	status, err := db.GetServerStatus()
+	if err != nil {
+		// The last counts are still served; the scrape shows the gap
+		log.Warn("Metrics: counting rooms failed", "err", err)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	var rooms []metrics.Sample
+	for k, n := range status.ByState {
+		rooms = append(rooms, metrics.Sample{Labels: []string{"game", k.GameType, "status", k.Status}, Value: float64(n)})
+	}
+	metrics.WriteGauge(w, "termplay_rooms", "Rooms in the store, by game type and status.", rooms...)
+	metrics.WriteGauge(w, "termplay_games_today", "Games archived since UTC midnight.", metrics.Sample{Value: float64(status.GamesToday)})
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+	metrics.WriteGauge(w, "termplay_store_up", "Whether the last count of rooms in the store succeeded.", metrics.Sample{Value: up})
+	metrics.WriteProcess(w)
+}
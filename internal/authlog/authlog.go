@@ -0,0 +1,60 @@
+// Package authlog writes connection, rejection and abuse events as single
+// lines in a fixed format, so operators can point fail2ban or CrowdSec at
+// them and block addresses at the SSH port:
+//
+//	2006-01-02T15:04:05Z termplay[812]: reject ip=203.0.113.9 port=52144 user="bob" key=SHA256_abc reason="banned"
+//
+// Fields always come in this order. user and reason are quoted; ip, port
+// and key never contain spaces, and key is "none" for keyless logins.
+// Lines go to stderr, or only to the file set with AUTH_LOG_FILE.
+package authlog
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event kinds.
+const (
+	Connect = "connect" // a session opened
+	Reject  = "reject"  // turned away before a program started
+	Abuse   = "abuse"   // ban-worthy behavior inside a session
+)
+
+var (
+	mu  sync.Mutex
+	out io.Writer = os.Stderr
+)
+
+// Open sends events to the file at path, appending, instead of stderr.
+func Open(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	out = f
+	mu.Unlock()
+	return nil
+}
+
+// Log writes one event. addr is the remote "host:port" of the session.
+func Log(kind, addr, user, key, reason string) {
+	ip, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		ip, port = addr, "0"
+	}
+	if key == "" {
+		key = "none"
+	}
+	line := fmt.Sprintf("%s termplay[%d]: %s ip=%s port=%s user=%q key=%s reason=%q\n",
+		time.Now().UTC().Format(time.RFC3339), os.Getpid(), kind, ip, port, user, key, reason)
+
+	mu.Lock()
+	defer mu.Unlock()
+	io.WriteString(out, line)
+}
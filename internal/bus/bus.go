@@ -0,0 +1,97 @@
+// Package bus fans room events out to every session watching a room, so
+// updates reach players the moment they are written instead of on the next
+// poll. The local backend works inside a single server process; the pubsub
+// backend bridges several server instances through Google Pub/Sub.
+package bus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aminshahid573/termplay/internal/config"
+)
+
+// Bus publishes a payload once and delivers it to every subscriber of the
+// same topic. Topics are room codes, or names derived from them such as
+// the room's chat topic.
+type Bus interface {
+	Publish(topic string, data []byte) error
+	Subscribe(topic string) (<-chan []byte, func())
+}
+
+// Default is the bus used by the db layer and the UI.
+var Default Bus = NewLocal()
+
+// Fanout reports whether Default carries room events between server
+// instances, so a session subscribed to its room only needs to poll as a
+// fallback. The local backend can't see writes made by another instance.
+func Fanout() bool {
+	_, ok := Default.(*pubSub)
+	return ok
+}
+
+// Init selects the backend configured by BUS_BACKEND.
+func Init() error {
+	switch config.BusBackend {
+	case "", "local":
+		Default = NewLocal()
+	case "pubsub":
+		b, err := newPubSub(config.PubSubTopic, config.PubSubSubscription)
+		if err != nil {
+			return fmt.Errorf("error initializing pubsub bus: %v", err)
+		}
+		Default = b
+	default:
+		return fmt.Errorf("unsupported BUS_BACKEND %q (want local or pubsub)", config.BusBackend)
+	}
+	return nil
+}
+
+// Local is an in-process fan-out. Slow subscribers never block publishers:
+// if a subscriber's buffer is full the event is dropped and that session
+// falls back to its regular poll.
+type Local struct {
+	mu   sync.Mutex
+	subs map[string]map[chan []byte]struct{}
+}
+
+func NewLocal() *Local {
+	return &Local{subs: make(map[string]map[chan []byte]struct{})}
+}
+
+func (l *Local) Publish(topic string, data []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.subs[topic] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+	return nil
+}
+
+func (l *Local) Subscribe(topic string) (<-chan []byte, func()) {
+	ch := make(chan []byte, 8)
+
+	l.mu.Lock()
+	if l.subs[topic] == nil {
+		l.subs[topic] = make(map[chan []byte]struct{})
+	}
+	l.subs[topic][ch] = struct{}{}
+	l.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.subs[topic], ch)
+			if len(l.subs[topic]) == 0 {
+				delete(l.subs, topic)
+			}
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
@@ -0,0 +1,109 @@
+package bus
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// pubSub publishes every event to a shared Google Pub/Sub topic and pulls
+// events written by other server instances from this instance's own
+// subscription. Delivery to local sessions always goes through a Local bus,
+// so events published here reach local subscribers without a round-trip.
+type pubSub struct {
+	*Local
+	svc          *pubsub.Service
+	topic        string
+	subscription string
+	origin       string
+}
+
+func newPubSub(topic, subscription string) (*pubSub, error) {
+	if topic == "" || subscription == "" {
+		return nil, fmt.Errorf("PUBSUB_TOPIC and PUBSUB_SUBSCRIPTION are required")
+	}
+
+	var opts []option.ClientOption
+	if config.CredPath != "" {
+		if _, err := os.Stat(config.CredPath); err == nil {
+			opts = append(opts, option.WithCredentialsFile(config.CredPath))
+		}
+	}
+	svc, err := pubsub.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	b := &pubSub{
+		Local:        NewLocal(),
+		svc:          svc,
+		topic:        topic,
+		subscription: subscription,
+		origin:       fmt.Sprintf("%s-%d", host, rand.Int63()),
+	}
+	go b.pullLoop()
+	return b, nil
+}
+
+// Publish delivers to local subscribers at once and sends the event to
+// the other instances in the background, so a slow Pub/Sub never holds up
+// the write that published it. A failed send is only logged: the other
+// instances' polls pick the change up.
+func (b *pubSub) Publish(topic string, data []byte) error {
+	b.Local.Publish(topic, data)
+
+	req := &pubsub.PublishRequest{Messages: []*pubsub.PubsubMessage{{
+		Data:       base64.StdEncoding.EncodeToString(data),
+		Attributes: map[string]string{"room": topic, "origin": b.origin},
+	}}}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := b.svc.Projects.Topics.Publish(b.topic, req).Context(ctx).Do(); err != nil {
+			log.Printf("Bus: publish %s failed: %v", topic, err)
+		}
+	}()
+	return nil
+}
+
+// pullLoop forwards events from other instances to local subscribers.
+func (b *pubSub) pullLoop() {
+	for {
+		resp, err := b.svc.Projects.Subscriptions.Pull(b.subscription, &pubsub.PullRequest{MaxMessages: 100}).Do()
+		if err != nil {
+			log.Printf("Bus: pull failed: %v", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		var acks []string
+		for _, rm := range resp.ReceivedMessages {
+			acks = append(acks, rm.AckId)
+			if rm.Message == nil || rm.Message.Attributes["origin"] == b.origin {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(rm.Message.Data)
+			if err != nil {
+				continue
+			}
+			b.Local.Publish(rm.Message.Attributes["room"], data)
+		}
+
+		if len(acks) > 0 {
+			ack := &pubsub.AcknowledgeRequest{AckIds: acks}
+			if _, err := b.svc.Projects.Subscriptions.Acknowledge(b.subscription, ack).Do(); err != nil {
+				log.Printf("Bus: ack failed: %v", err)
+			}
+		}
+	}
+}
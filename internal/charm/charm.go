@@ -0,0 +1,178 @@
+// Package charm lets players sign in with a Charm account, as an identity
+// next to their SSH keys. A game server never holds a player's Charm key,
+// so it can't ask a Charm server who they are; instead the player runs
+//
+//	charm jwt termplay
+//
+// on a machine set up with their Charm account, and pastes the token in
+// Settings. The token is signed by the Charm server at config.CharmURL,
+// whose public keys are published at /v1/public/jwks, so it proves the
+// Charm ID without the game server taking the player's word for it.
+//
+// Only tokens made out to termplay alone are taken: one made out to
+// "charm" as well would open the player's Charm account, files and KV
+// included, to whoever holds it.
+package charm
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+)
+
+// Audience is what the player's token must be made out to.
+const Audience = "termplay"
+
+// Enabled reports whether the server accepts Charm sign-ins.
+func Enabled() bool {
+	return config.CharmURL != ""
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Verify checks token and returns the Charm ID it was made for.
+func Verify(token string) (string, error) {
+	if !Enabled() {
+		return "", fmt.Errorf("this server doesn't accept Charm sign-ins")
+	}
+	token = strings.TrimSpace(token)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("that isn't a Charm token: run charm jwt %s and paste what it prints", Audience)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "EdDSA" {
+		return "", fmt.Errorf("that isn't a Charm token: run charm jwt %s and paste what it prints", Audience)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("that Charm token is damaged, copy it again")
+	}
+	key, err := publicKey(header.Kid)
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return "", fmt.Errorf("that Charm token wasn't issued by %s", config.CharmURL)
+	}
+
+	var claims struct {
+		Sub string   `json:"sub"`
+		Iss string   `json:"iss"`
+		Exp int64    `json:"exp"`
+		Aud audience `json:"aud"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Sub == "" {
+		return "", fmt.Errorf("that Charm token is damaged, copy it again")
+	}
+	switch {
+	case strings.TrimSuffix(claims.Iss, "/") != config.CharmURL:
+		return "", fmt.Errorf("that Charm token wasn't issued by %s", config.CharmURL)
+	case time.Now().Unix() > claims.Exp:
+		return "", fmt.Errorf("that Charm token has expired, run charm jwt %s again", Audience)
+	case !claims.Aud.has(Audience):
+		return "", fmt.Errorf("that Charm token is for another app: run charm jwt %s", Audience)
+	case claims.Aud.has("charm"):
+		return "", fmt.Errorf("that token opens your whole Charm account, don't share it: run charm jwt %s", Audience)
+	}
+	return claims.Sub, nil
+}
+
+// audience is the "aud" claim, which may be one string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+func (a audience) has(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// The Charm server's signing keys, by key ID, fetched again when a token
+// names one not seen yet.
+var (
+	keysMu sync.Mutex
+	keys   map[string]ed25519.PublicKey
+)
+
+func publicKey(kid string) (ed25519.PublicKey, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	fetched, err := fetchKeys()
+	if err != nil {
+		return nil, fmt.Errorf("could not reach the Charm server: %v", err)
+	}
+	keys = fetched
+	k, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("that Charm token wasn't issued by %s", config.CharmURL)
+	}
+	return k, nil
+}
+
+func fetchKeys() (map[string]ed25519.PublicKey, error) {
+	resp, err := client.Get(config.CharmURL + "/v1/public/jwks")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	found := make(map[string]ed25519.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		found[k.Kid] = ed25519.PublicKey(x)
+	}
+	return found, nil
+}
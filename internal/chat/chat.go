@@ -0,0 +1,80 @@
+// Package chat holds the rules applied to in-room chat before messages
+// are stored or shown: length limits, per-player rate limiting and
+// collapsing of repeated lines.
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+)
+
+// MaxLen is the longest message accepted, in runes.
+const MaxLen = 200
+
+// Clean trims a message and enforces MaxLen. It returns "" for messages
+// that should not be sent at all.
+func Clean(text string) string {
+	text = strings.TrimSpace(text)
+	if r := []rune(text); len(r) > MaxLen {
+		text = string(r[:MaxLen])
+	}
+	return text
+}
+
+var (
+	mu   sync.Mutex
+	sent = make(map[string][]time.Time) // player ID -> recent send times
+)
+
+// Allow records an attempt by pid to send a message and reports whether it
+// is within the rate limit. The limit is shared by all of a player's
+// sessions on this server.
+func Allow(pid string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-config.ChatWindow)
+	recent := sent[pid][:0]
+	for _, t := range sent[pid] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= config.ChatBurst {
+		sent[pid] = recent
+		wait := recent[0].Sub(cutoff).Round(time.Second)
+		return fmt.Errorf("slow down, you can chat again in %s", wait)
+	}
+	sent[pid] = append(recent, now)
+	return nil
+}
+
+// Line is a chat message as displayed, with identical consecutive
+// messages from the same sender folded into one.
+type Line struct {
+	db.ChatMessage
+	Count int
+}
+
+// Collapse folds runs of the same text from the same sender, skipping any
+// sender in muted.
+func Collapse(msgs []db.ChatMessage, muted map[string]bool) []Line {
+	var lines []Line
+	for _, msg := range msgs {
+		if muted[msg.From] {
+			continue
+		}
+		if n := len(lines); n > 0 && lines[n-1].From == msg.From && strings.EqualFold(lines[n-1].Text, msg.Text) {
+			lines[n-1].Count++
+			continue
+		}
+		lines = append(lines, Line{ChatMessage: msg, Count: 1})
+	}
+	return lines
+}
@@ -19,6 +19,16 @@ type Pos struct {
 	Row, Col int
 }
 
+// String gives the square in algebraic notation, e.g. "e4".
+func (p Pos) String() string {
+	return string(rune('a'+p.Col)) + string(rune('8'-p.Row))
+}
+
+// OnBoard reports whether p is one of the 64 squares.
+func (p Pos) OnBoard() bool {
+	return inBounds(p.Row, p.Col)
+}
+
 // Move represents a full move details
 type Move struct {
 	From, To      Pos
@@ -539,3 +549,19 @@ func IsInsufficientMaterial(board [8][8]Piece) bool {
 
 	return false
 }
+
+// pieceValues are the usual point values of the pieces; the king has none.
+var pieceValues = map[string]int{"P": 1, "N": 3, "B": 3, "R": 5, "Q": 9}
+
+// Material adds up the point values of one side's pieces.
+func Material(board [8][8]Piece, isWhite bool) int {
+	total := 0
+	for r := 0; r < 8; r++ {
+		for c := 0; c < 8; c++ {
+			if p := board[r][c]; !p.IsEmpty() && p.IsWhite == isWhite {
+				total += pieceValues[p.Type]
+			}
+		}
+	}
+	return total
+}
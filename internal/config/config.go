@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +15,183 @@ var (
 	SyncInterval = 500 * time.Millisecond
 	Host         = "localhost"
 	Port         = 2324
+
+	// Keep-alive pings sent to every SSH client so NAT and firewalls
+	// don't drop quiet sessions (0 disables). A client that leaves
+	// KeepAliveMax pings in a row unanswered is disconnected (0 never).
+	KeepAliveInterval = 30 * time.Second
+	KeepAliveMax      = 3
+
+	// Poll cadence: fast while waiting on the opponent's move, slow while
+	// nothing is expected to change (lobby, finished game).
+	PollActiveInterval = 200 * time.Millisecond
+	PollIdleInterval   = 3 * time.Second
+
+	// Store latency budget: a store call still running after StoreSlowAfter
+	// shows a "still working" spinner, and one still running after
+	// StoreTimeout is given up on with an error. 0 disables either.
+	StoreSlowAfter = 750 * time.Millisecond
+	StoreTimeout   = 20 * time.Second
+
+	// Idle time on a non-game screen before the screensaver kicks in (0 disables).
+	ScreensaverAfter = 5 * time.Minute
+
+	// How long a host waits alone in a tic-tac-toe lobby before being
+	// offered a bot opponent.
+	BotOfferAfter = 20 * time.Second
+
+	// Turn reminders: the YOUR TURN chip flashes once a player has sat on
+	// their move this long without pressing a key, and their opponent is
+	// told they seem away after AwayNoticeAfter. 0 disables either.
+	TurnNudgeAfter  = 30 * time.Second
+	AwayNoticeAfter = 60 * time.Second
+
+	// RejoinWindow is how long the seat of a player whose connection drops
+	// mid-game is held for them to come back, before their opponent can
+	// claim the game. 0 forfeits at once.
+	RejoinWindow = 2 * time.Minute
+
+	// RankedMoveTime is the clock on every move in a ranked room; a player
+	// who lets it run out loses the game. 0 turns the clock off.
+	RankedMoveTime = 60 * time.Second
+
+	// CorrespondenceMoveTime is the clock on every move in a
+	// correspondence room, where players come and go between moves.
+	CorrespondenceMoveTime = 24 * time.Hour
+
+	// LagGraceMax caps the extra time a player on a slow link gets on
+	// the ranked clock: their measured round-trip, up to this much.
+	LagGraceMax = 2 * time.Second
+
+	// Stalling in ranked games: a player who loses on time, or leaves a
+	// game they are losing, StallLimit times within StallWindow is
+	// charged StallPenalty extra losses on the leaderboard and kept out
+	// of ranked rooms for StallLockout. A StallLimit of 0 turns this off.
+	StallLimit   = 3
+	StallWindow  = 24 * time.Hour
+	StallPenalty = 3
+	StallLockout = time.Hour
+
+	// Chat flood protection: at most ChatBurst messages per ChatWindow
+	// from one player.
+	ChatBurst  = 5
+	ChatWindow = 10 * time.Second
+
+	// Whether the main menu offers the server-wide lobby chat.
+	LobbyChat = true
+
+	// How long a built leaderboard is served before it is rebuilt from the
+	// daily stats summaries.
+	LeaderboardRefresh = 10 * time.Minute
+
+	// Room capacity: spectators per room, and everyone in it (players
+	// plus spectators). 0 means no limit.
+	MaxSpectators = 20
+	MaxMembers    = 22
+
+	// Rooms one session may be in at once, each in its own tab.
+	MaxTabs = 4
+
+	// Per-session limits; a session past one is closed. Pending store
+	// calls are database calls the session is waiting on, each in a
+	// goroutine of its own.
+	// Cells is the terminal's width times height, and a frame is one
+	// rendered screen in bytes. 0 means no limit.
+	SessionMaxPending = 64
+	SessionMaxCells   = 100_000
+	SessionMaxFrame   = 4 << 20
+
+	// A session switches to low-bandwidth mode on its own once its
+	// round-trip reaches LowBandwidthRTT, or its link takes output slower
+	// than LowBandwidthRate bytes a second. 0 turns either check off.
+	LowBandwidthRTT  = 500 * time.Millisecond
+	LowBandwidthRate = 16 << 10
+
+	// Players seen before this date earn the early adopter badge. Zero
+	// means the badge is no longer handed out.
+	EarlyAdopterUntil time.Time
+
+	// How long players get to finish their games after maintenance mode
+	// is switched on, before the server goes down.
+	MaintenanceCountdown = 5 * time.Minute
+
+	// Rooms nobody has written to for RoomIdleTTL are removed by the
+	// janitor, which looks every JanitorInterval.
+	RoomIdleTTL     = time.Hour
+	JanitorInterval = time.Minute
+
+	// A public lobby still waiting for an opponent after RelistAfter is
+	// moved back to the top of the public list, and kept alive, while
+	// its host is connected. 0 turns this off.
+	RelistAfter = 5 * time.Minute
+
+	// Days each room's event log is kept for admins to look into
+	// reports. 0 turns the log off.
+	RoomLogDays = 7
+
+	// File holding the message of the day shown at login. A message set
+	// with `termplay motd` takes precedence.
+	MOTDFile = ""
+
+	// Network ACL, checked before a session starts: addresses or CIDR
+	// blocks that may connect (empty means anyone) and ones that may not.
+	// A denied network wins over an allowed one.
+	AllowCIDRs []string
+	DenyCIDRs  []string
+
+	// authorized_keys file listing the only SSH keys that may play, for
+	// private servers. Empty lets anyone in.
+	AuthorizedKeysFile = ""
+
+	// Host key files, all served so each client can pick a type it knows.
+	// Missing files are generated: RSA if the name contains "rsa", ECDSA
+	// if it contains "ecdsa", ed25519 otherwise.
+	HostKeys = []string{"ssh_host_key", "ssh_host_rsa_key"}
+
+	// Key exchanges, ciphers and MACs offered to clients, in order of
+	// preference. Empty means the SSH library's defaults.
+	SSHKeyExchanges []string
+	SSHCiphers      []string
+	SSHMACs         []string
+
+	// Server logs go to stderr and, if set, to this file too. The file is
+	// rotated once it would pass LogMaxSize bytes or is LogRotateEvery
+	// old, and rotated files are removed after LogMaxAge. Zero turns each
+	// limit off.
+	LogFile        = ""
+	LogMaxSize     = int64(100 << 20)
+	LogRotateEvery = 24 * time.Hour
+	LogMaxAge      = 14 * 24 * time.Hour
+
+	// Where correspondence turn alerts go: the ntfy server for "ntfy:"
+	// topics, and the SMTP relay (host:port) and sender for email. No
+	// relay means no email alerts.
+	NtfyServer   = "https://ntfy.sh"
+	SMTPAddr     = ""
+	SMTPFrom     = ""
+	SMTPUser     = ""
+	SMTPPassword = ""
+
+	// File the auth log (connections, rejections, abuse) is appended to,
+	// for fail2ban or CrowdSec. Empty means stderr.
+	AuthLogFile = ""
+
+	// Address (host:port) to serve Prometheus metrics on, at /metrics.
+	// Empty means no metrics endpoint.
+	MetricsAddr = ""
+
+	// Session IDs (sanitized SSH key fingerprints) allowed into the
+	// admin console.
+	AdminKeys = map[string]bool{}
+
+	// Optional message bus for pushing room updates to sessions.
+	BusBackend         = "local"
+	PubSubTopic        = ""
+	PubSubSubscription = ""
+
+	// HTTP URL of the Charm server players may sign in with (see package
+	// charm), as its tokens name it. Empty turns Charm sign-in off.
+	CharmURL = ""
 )
 
 func init() {
@@ -38,4 +216,282 @@ func init() {
 			Port = p
 		}
 	}
+	if v := os.Getenv("KEEPALIVE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			KeepAliveInterval = d
+		}
+	}
+	if v := os.Getenv("KEEPALIVE_MAX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			KeepAliveMax = n
+		}
+	}
+
+	if v := os.Getenv("SYNC_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			SyncInterval = d
+		}
+	}
+	if v := os.Getenv("POLL_ACTIVE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			PollActiveInterval = d
+		}
+	}
+	if v := os.Getenv("POLL_IDLE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			PollIdleInterval = d
+		}
+	}
+
+	if v := os.Getenv("STORE_SLOW_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			StoreSlowAfter = d
+		}
+	}
+	if v := os.Getenv("STORE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			StoreTimeout = d
+		}
+	}
+
+	if v := os.Getenv("SCREENSAVER_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			ScreensaverAfter = d
+		}
+	}
+
+	if v := os.Getenv("BOT_OFFER_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			BotOfferAfter = d
+		}
+	}
+	if v := os.Getenv("TURN_NUDGE_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			TurnNudgeAfter = d
+		}
+	}
+	if v := os.Getenv("AWAY_NOTICE_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			AwayNoticeAfter = d
+		}
+	}
+	if v := os.Getenv("REJOIN_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			RejoinWindow = d
+		}
+	}
+	if v := os.Getenv("RANKED_MOVE_TIME"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			RankedMoveTime = d
+		}
+	}
+	if v := os.Getenv("CORRESPONDENCE_MOVE_TIME"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			CorrespondenceMoveTime = d
+		}
+	}
+	if v := os.Getenv("LAG_GRACE_MAX"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			LagGraceMax = d
+		}
+	}
+	if v := os.Getenv("STALL_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			StallLimit = n
+		}
+	}
+	if v := os.Getenv("STALL_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			StallWindow = d
+		}
+	}
+	if v := os.Getenv("STALL_PENALTY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			StallPenalty = n
+		}
+	}
+	if v := os.Getenv("STALL_LOCKOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			StallLockout = d
+		}
+	}
+
+	if v := os.Getenv("CHAT_BURST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			ChatBurst = n
+		}
+	}
+	if v := os.Getenv("CHAT_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			ChatWindow = d
+		}
+	}
+	if v := os.Getenv("LOBBY_CHAT"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			LobbyChat = b
+		}
+	}
+
+	if v := os.Getenv("LEADERBOARD_REFRESH"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			LeaderboardRefresh = d
+		}
+	}
+
+	if v := os.Getenv("MAX_SPECTATORS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			MaxSpectators = n
+		}
+	}
+	if v := os.Getenv("MAX_MEMBERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			MaxMembers = n
+		}
+	}
+	if v := os.Getenv("MAX_TABS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			MaxTabs = n
+		}
+	}
+	if v := os.Getenv("SESSION_MAX_PENDING"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			SessionMaxPending = n
+		}
+	}
+	if v := os.Getenv("SESSION_MAX_CELLS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			SessionMaxCells = n
+		}
+	}
+	if v := os.Getenv("SESSION_MAX_FRAME"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			SessionMaxFrame = n
+		}
+	}
+	if v := os.Getenv("LOW_BANDWIDTH_RTT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			LowBandwidthRTT = d
+		}
+	}
+	if v := os.Getenv("LOW_BANDWIDTH_RATE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			LowBandwidthRate = n
+		}
+	}
+
+	if v := os.Getenv("EARLY_ADOPTER_UNTIL"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			EarlyAdopterUntil = t
+		}
+	}
+
+	if v := os.Getenv("MAINTENANCE_COUNTDOWN"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			MaintenanceCountdown = d
+		}
+	}
+	if v := os.Getenv("ROOM_LOG_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			RoomLogDays = n
+		}
+	}
+
+	if v := os.Getenv("ROOM_IDLE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			RoomIdleTTL = d
+		}
+	}
+	if v := os.Getenv("JANITOR_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			JanitorInterval = d
+		}
+	}
+	if v := os.Getenv("RELIST_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			RelistAfter = d
+		}
+	}
+
+	if v := os.Getenv("MOTD_FILE"); v != "" {
+		MOTDFile = v
+	}
+	if v := os.Getenv("LOG_FILE"); v != "" {
+		LogFile = v
+	}
+	if v := os.Getenv("LOG_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			LogMaxSize = int64(n) << 20
+		}
+	}
+	if v := os.Getenv("LOG_ROTATE_EVERY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			LogRotateEvery = d
+		}
+	}
+	if v := os.Getenv("LOG_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			LogMaxAge = d
+		}
+	}
+	if v := os.Getenv("AUTH_LOG_FILE"); v != "" {
+		AuthLogFile = v
+	}
+	MetricsAddr = os.Getenv("METRICS_ADDR")
+	if v := os.Getenv("NTFY_SERVER"); v != "" {
+		NtfyServer = strings.TrimSuffix(v, "/")
+	}
+	SMTPAddr = os.Getenv("SMTP_ADDR")
+	SMTPFrom = os.Getenv("SMTP_FROM")
+	SMTPUser = os.Getenv("SMTP_USER")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	CharmURL = strings.TrimSuffix(os.Getenv("CHARM_URL"), "/")
+
+	for _, k := range strings.Split(os.Getenv("ADMIN_KEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			AdminKeys[k] = true
+		}
+	}
+	for _, c := range strings.Split(os.Getenv("ALLOW_CIDRS"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			AllowCIDRs = append(AllowCIDRs, c)
+		}
+	}
+	for _, c := range strings.Split(os.Getenv("DENY_CIDRS"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			DenyCIDRs = append(DenyCIDRs, c)
+		}
+	}
+
+	if v := os.Getenv("AUTHORIZED_KEYS"); v != "" {
+		AuthorizedKeysFile = v
+	}
+	if v := os.Getenv("HOST_KEYS"); v != "" {
+		HostKeys = nil
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				HostKeys = append(HostKeys, k)
+			}
+		}
+	}
+	for _, a := range strings.Split(os.Getenv("SSH_KEX"), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			SSHKeyExchanges = append(SSHKeyExchanges, a)
+		}
+	}
+	for _, a := range strings.Split(os.Getenv("SSH_CIPHERS"), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			SSHCiphers = append(SSHCiphers, a)
+		}
+	}
+	for _, a := range strings.Split(os.Getenv("SSH_MACS"), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			SSHMACs = append(SSHMACs, a)
+		}
+	}
+
+	if v := os.Getenv("BUS_BACKEND"); v != "" {
+		BusBackend = v
+	}
+	PubSubTopic = os.Getenv("PUBSUB_TOPIC")
+	PubSubSubscription = os.Getenv("PUBSUB_SUBSCRIPTION")
 }
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ErrLinkCode is returned for a link code that is unknown or has expired.
+var ErrLinkCode = fmt.Errorf("that link code is wrong or has expired")
+
+// LinkCodeTTL is how long a link code can be used.
+const LinkCodeTTL = 10 * time.Minute
+
+// linkCode is a pending link at /linkcodes/<code>, made on a machine the
+// player already uses and redeemed from the new one.
+type linkCode struct {
+	Account string `json:"account"`
+	Expires int64  `json:"expires"`
+}
+
+// linkAlphabet leaves out characters easily misread, like room codes do.
+const linkAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// AccountOf returns the profile ID a key was linked to, or "" if the key
+// is not linked and plays as itself. Links live at /keys/<key ID>.
+func AccountOf(keyID string) (string, error) {
+	var account string
+	err := withRef("keys/"+keyID, func(ref *db.Ref) error { return ref.Get(context.Background(), &account) })
+	return account, err
+}
+
+// NewLinkCode makes a one-time code that links another key to account.
+func NewLinkCode(account string) (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = linkAlphabet[int(b[i])%len(linkAlphabet)]
+	}
+	code := string(b)
+	lc := linkCode{Account: account, Expires: time.Now().Add(LinkCodeTTL).Unix()}
+	if err := writeRef("linkcodes/"+code, func(ref *db.Ref) error { return ref.Set(context.Background(), lc) }); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// LinkKey redeems a link code for keyID, so the key plays as the account
+// that made the code from then on. It returns that account. The code
+// works once; whatever keyID played as before stays behind.
+func LinkKey(code, keyID string) (string, error) {
+	ctx := context.Background()
+	var lc linkCode
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		lc = linkCode{}
+		if err := tn.Unmarshal(&lc); err != nil {
+			return nil, err
+		}
+		return nil, nil // used up, whether it was valid or not
+	}
+	if err := writeRef("linkcodes/"+code, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return "", err
+	}
+	if lc.Account == "" || time.Now().Unix() > lc.Expires {
+		return "", ErrLinkCode
+	}
+	if lc.Account == keyID {
+		return "", fmt.Errorf("that code was made with this key, enter it on your other machine")
+	}
+
+	return lc.Account, linkKeyTo(keyID, lc.Account)
+}
+
+// linkKeyTo makes keyID play as account.
+func linkKeyTo(keyID, account string) error {
+	ctx := context.Background()
+	if err := writeRef("keys/"+keyID, func(ref *db.Ref) error { return ref.Set(ctx, account) }); err != nil {
+		return err
+	}
+	return writeRef("profiles/"+account, func(ref *db.Ref) error {
+		return ref.Update(ctx, map[string]interface{}{
+			"keys/" + keyID: true,
+			"updatedAt":     time.Now().Unix(),
+		})
+	})
+}
+
+// LinkCharm signs keyID in with the Charm account charmID, verified by
+// package charm. The first key to sign in with a Charm account makes the
+// profile it plays as, account, that Charm account's profile; any key
+// signing in later is linked to it, as with a link code. It returns the
+// profile keyID plays as from then on. Links live at /charm/<Charm ID>.
+func LinkCharm(charmID, keyID, account string) (string, error) {
+	if charmID == "" || strings.ContainsAny(charmID, ".$#[]/") {
+		return "", fmt.Errorf("that Charm ID can't be used")
+	}
+	ctx := context.Background()
+	var owner string
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		owner = ""
+		if err := tn.Unmarshal(&owner); err != nil {
+			return nil, err
+		}
+		if owner == "" {
+			owner = account
+		}
+		return owner, nil
+	}
+	if err := writeRef("charm/"+charmID, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return "", err
+	}
+
+	switch owner {
+	case account:
+		// Already plays as the Charm account's profile
+		err := writeRef("profiles/"+account, func(ref *db.Ref) error {
+			return ref.Update(ctx, map[string]interface{}{
+				"charm":     charmID,
+				"updatedAt": time.Now().Unix(),
+			})
+		})
+		return account, err
+	case keyID:
+		// The key's own profile is the Charm one; it was linked away
+		return keyID, UnlinkKey(keyID)
+	}
+	return owner, linkKeyTo(keyID, owner)
+}
+
+// UnlinkKey makes keyID play as itself again.
+func UnlinkKey(keyID string) error {
+	ctx := context.Background()
+	account, err := AccountOf(keyID)
+	if err != nil || account == "" {
+		return err
+	}
+	if err := writeRef("keys/"+keyID, func(ref *db.Ref) error { return ref.Delete(ctx) }); err != nil {
+		return err
+	}
+	return writeRef("profiles/"+account+"/keys/"+keyID, func(ref *db.Ref) error { return ref.Delete(ctx) })
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/hooks"
+	"github.com/aminshahid573/termplay/internal/metrics"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ArchivedGame is the compact record of one game kept under /archive once
+// it is over, so history never has to be read out of the live rooms.
+type ArchivedGame struct {
+	Room        string   `json:"room"`
+	GameType    string   `json:"gameType"`
+	PlayerX     string   `json:"playerX"`
+	PlayerXName string   `json:"playerXName"`
+	PlayerO     string   `json:"playerO"`
+	PlayerOName string   `json:"playerOName"`
+	Winner      string   `json:"winner"` // X/O or White/Black, "Draw", or "" if abandoned
+	Result      string   `json:"result"` // "finished" or "abandoned"
+	Moves       []string `json:"moves"`
+	StartedAt   int64    `json:"startedAt"`
+	EndedAt     int64    `json:"endedAt"`
+	Casual      bool     `json:"casual,omitempty"` // played in a casual room, left out of the stats
+}
+
+// archiveGame stores the game currently in r. Failures are logged rather
+// than returned: losing a history record must never block play.
+func archiveGame(r Room, result string) {
+	g := ArchivedGame{
+		Room:        r.Code,
+		GameType:    r.GameType,
+		PlayerX:     r.PlayerX,
+		PlayerXName: r.PlayerXName,
+		PlayerO:     r.PlayerO,
+		PlayerOName: r.PlayerOName,
+		Result:      result,
+		Casual:      !r.Ranked,
+		Moves:       r.Moves,
+		StartedAt:   r.StartedAt,
+		EndedAt:     time.Now().Unix(),
+	}
+	if result == "finished" {
+		g.Winner = r.Winner
+		if g.Winner == "" {
+			g.Winner = "Draw"
+		}
+	}
+	if err := writeRef("archive", func(ref *db.Ref) error {
+		_, err := ref.Push(context.Background(), g)
+		return err
+	}); err != nil {
+		log.Printf("Archive: room %s: %v", r.Code, err)
+	}
+	var ran time.Duration
+	if g.StartedAt > 0 {
+		ran = time.Duration(g.EndedAt-g.StartedAt) * time.Second
+	}
+	metrics.ObserveGame(g.GameType, result, ran)
+	if result == "finished" {
+		recordHeadToHead(g)
+	}
+	hooks.FireGameFinished(hooks.GameFinished{
+		Room:        g.Room,
+		GameType:    g.GameType,
+		PlayerX:     g.PlayerX,
+		PlayerXName: g.PlayerXName,
+		PlayerO:     g.PlayerO,
+		PlayerOName: g.PlayerOName,
+		Winner:      g.Winner,
+		Result:      g.Result,
+		Moves:       len(g.Moves),
+		Ranked:      !g.Casual,
+		StartedAt:   time.Unix(g.StartedAt, 0),
+		EndedAt:     time.Unix(g.EndedAt, 0),
+	})
+}
+
+// archiveIfAbandoned records a game that was cut short, if one was under
+// way.
+func archiveIfAbandoned(r Room) {
+	if r.Status == "playing" && len(r.Moves) > 0 {
+		archiveGame(r, "abandoned")
+	}
+}
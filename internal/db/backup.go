@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// dumpVersion is bumped whenever Dump changes shape.
+const dumpVersion = 1
+
+// Dump is a snapshot of the stored data, kept as raw JSON so fields this
+// build does not know about survive a round-trip.
+type Dump struct {
+	Version    int             `json:"version"`
+	ExportedAt int64           `json:"exportedAt"`
+	Rooms      json.RawMessage `json:"rooms,omitempty"`
+	Profiles   json.RawMessage `json:"profiles,omitempty"`
+	Stats      json.RawMessage `json:"stats,omitempty"`
+	Keys       json.RawMessage `json:"keys,omitempty"` // keys linked to profiles
+	HeadToHead json.RawMessage `json:"headtohead,omitempty"`
+	Charm      json.RawMessage `json:"charm,omitempty"`     // Charm accounts' profiles
+	Names      json.RawMessage `json:"names,omitempty"`     // reserved names' owners
+	Penalties  json.RawMessage `json:"penalties,omitempty"` // stall penalties, mirrored from profiles
+}
+
+// sections maps each Dump section to its path in the database.
+func (d *Dump) sections() map[string]*json.RawMessage {
+	return map[string]*json.RawMessage{
+		"rooms":      &d.Rooms,
+		"profiles":   &d.Profiles,
+		"stats":      &d.Stats,
+		"keys":       &d.Keys,
+		"headtohead": &d.HeadToHead,
+		"charm":      &d.Charm,
+		"names":      &d.Names,
+		"penalties":  &d.Penalties,
+	}
+}
+
+// Export snapshots rooms, profiles, linked keys and Charm accounts,
+// reserved names, head-to-head records, stats and stall penalties.
+func Export() (*Dump, error) {
+	d := &Dump{Version: dumpVersion, ExportedAt: time.Now().Unix()}
+	for path, dst := range d.sections() {
+		if err := withRef(path, func(ref *db.Ref) error { return ref.Get(context.Background(), dst) }); err != nil {
+			return nil, fmt.Errorf("export %s: %v", path, err)
+		}
+	}
+	return d, nil
+}
+
+// Import replaces each section present in the dump. Sections missing
+// from the dump are left untouched.
+func Import(d *Dump) error {
+	if d.Version != dumpVersion {
+		return fmt.Errorf("unsupported dump version %d (want %d)", d.Version, dumpVersion)
+	}
+	for path, src := range d.sections() {
+		if len(*src) == 0 || string(*src) == "null" {
+			continue
+		}
+		data := *src
+		if err := writeRef(path, func(ref *db.Ref) error { return ref.Set(context.Background(), data) }); err != nil {
+			return fmt.Errorf("import %s: %v", path, err)
+		}
+	}
+	InvalidateLeaderboard()
+	return nil
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// Badges a player can earn. They are stored on the profile and copied
+// into a room when the player takes a seat (see Seat).
+const (
+	BadgeChampion = "champion" // most wins in a season (calendar month)
+	BadgeCentury  = "100wins"
+	BadgeEarly    = "early" // played before config.EarlyAdopterUntil
+)
+
+// BadgeOrder is the order badges are shown in, most prestigious first.
+var BadgeOrder = []string{BadgeChampion, BadgeCentury, BadgeEarly}
+
+// AwardBadge adds a badge to a player's profile. Awarding a badge twice
+// is harmless.
+func AwardBadge(id, badge string) error {
+	return updateProfile(id, map[string]interface{}{"badges/" + badge: true})
+}
+
+// awardMilestones gives the 100 wins badge to everyone on the leaderboard
+// who has reached it.
+func awardMilestones() {
+	snap, err := leaderboard()
+	if err != nil {
+		log.Printf("Badges: leaderboard unavailable: %v", err)
+		return
+	}
+	for _, e := range snap.Entries {
+		if e.Wins < 100 {
+			// Entries are sorted by wins
+			break
+		}
+		if err := AwardBadge(e.ID, BadgeCentury); err != nil {
+			log.Printf("Badges: awarding %s to %s failed: %v", BadgeCentury, e.ID, err)
+		}
+	}
+}
+
+// awardSeasonChampion crowns the player with the most wins in the month
+// containing day.
+func awardSeasonChampion(day time.Time) {
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	season := newSummary(start.Format("2006-01"))
+	for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+		s, err := GetDailySummary(d)
+		if err != nil {
+			log.Printf("Badges: season %s incomplete: %v", season.Period, err)
+			return
+		}
+		season.merge(*s)
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(season.Players))
+	for id, t := range season.Players {
+		entries = append(entries, LeaderboardEntry{ID: id, Name: t.Name, Wins: t.Wins, Losses: t.Losses})
+	}
+	if len(entries) == 0 {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].less(entries[j]) })
+	if top := entries[0]; top.Wins > 0 {
+		log.Printf("Badges: %s champion is %s (%d wins)", season.Period, top.Name, top.Wins)
+		if err := AwardBadge(top.ID, BadgeChampion); err != nil {
+			log.Printf("Badges: awarding %s failed: %v", BadgeChampion, err)
+		}
+	}
+}
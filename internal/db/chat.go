@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aminshahid573/termplay/internal/bus"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ChatMessage is one line of chat, stored under chats/{code} (or
+// chats/lobby for the lobby chat).
+type ChatMessage struct {
+	From string `json:"from"` // session ID of the sender
+	Name string `json:"name"`
+	Text string `json:"text"`
+	At   int64  `json:"at"`
+}
+
+// LobbyChannel is the chat "room" for the server-wide lobby chat. Room
+// codes are upper case, so it can never clash with a real room.
+const LobbyChannel = "lobby"
+
+// lobbyChatKeep is how many lobby messages survive the nightly trim.
+const lobbyChatKeep = 500
+
+// ChatTopic is the bus topic new chat messages for a room are pushed on.
+func ChatTopic(code string) string {
+	return "chat:" + code
+}
+
+// SendChat appends a message to the room's chat and pushes it to every
+// session in the room.
+func SendChat(code string, msg ChatMessage) error {
+	if err := writeRef("chats/"+code, func(ref *db.Ref) error {
+		_, err := ref.Push(context.Background(), msg)
+		return err
+	}); err != nil {
+		return err
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	if err := bus.Default.Publish(ChatTopic(code), data); err != nil {
+		log.Printf("Bus: publish chat %s failed: %v", code, err)
+	}
+	return nil
+}
+
+// GetChat returns up to limit of the room's most recent messages, oldest
+// first.
+func GetChat(code string, limit int) ([]ChatMessage, error) {
+	var nodes []db.QueryNode
+	err := withRef("chats/"+code, func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().LimitToLast(limit).GetOrdered(context.Background())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]ChatMessage, 0, len(nodes))
+	for _, n := range nodes {
+		var msg ChatMessage
+		if err := n.Unmarshal(&msg); err == nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// deleteChat drops a room's chat history along with the room.
+func deleteChat(code string) {
+	writeRef("chats/"+code, func(ref *db.Ref) error { return ref.Delete(context.Background()) })
+}
+
+// trimLobbyChat drops all but the newest keep lobby messages. Room chats
+// are deleted with their room; the lobby never closes, so it is trimmed
+// nightly instead.
+func trimLobbyChat(keep int) {
+	ctx := context.Background()
+	var nodes []db.QueryNode
+	err := withRef("chats/"+LobbyChannel, func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().GetOrdered(ctx)
+		return err
+	})
+	if err != nil {
+		log.Printf("Chat: reading lobby chat failed: %v", err)
+		return
+	}
+	if len(nodes) <= keep {
+		return
+	}
+	old := make(map[string]interface{}, len(nodes)-keep)
+	for _, n := range nodes[:len(nodes)-keep] {
+		old[n.Key()] = nil
+	}
+	if err := writeRef("chats/"+LobbyChannel, func(ref *db.Ref) error { return ref.Update(ctx, old) }); err != nil {
+		log.Printf("Chat: trimming lobby chat failed: %v", err)
+		return
+	}
+	log.Printf("Chat: trimmed %d old lobby messages", len(old))
+}
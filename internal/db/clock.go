@@ -0,0 +1,55 @@
+package db
+
+import "time"
+
+// A room keeps the time its game has run, from StartedAt to EndedAt, and
+// each player's share of it spent on their own moves, so spectators and
+// players coming back see the same clocks as everyone else.
+
+// playerOn is the ID of the player seated on side ("X" or "O").
+func (r Room) playerOn(side string) string {
+	if side == "X" {
+		return r.PlayerX
+	}
+	return r.PlayerO
+}
+
+// spendThinking charges pid, who had the move, with the time from TurnAt
+// until now, a Unix time.
+func (r *Room) spendThinking(pid string, now int64) {
+	if pid == "" || r.TurnAt == 0 || now <= r.TurnAt {
+		return
+	}
+	if r.Thinking == nil {
+		r.Thinking = make(map[string]int64)
+	}
+	r.Thinking[pid] += now - r.TurnAt
+}
+
+// Elapsed is how long r's game has run, or ran if it is over, as of now.
+// It is zero when that isn't known: before the game starts, or for games
+// finished before rooms kept the time.
+func (r Room) Elapsed(now time.Time) time.Duration {
+	if r.StartedAt == 0 {
+		return 0
+	}
+	end := now.Unix()
+	switch {
+	case r.Status == "playing":
+	case r.EndedAt != 0:
+		end = r.EndedAt
+	default:
+		return 0
+	}
+	return time.Duration(max(end-r.StartedAt, 0)) * time.Second
+}
+
+// ThinkingTime is how long pid has spent on their moves this game, as of
+// now, the move they are on included.
+func (r Room) ThinkingTime(pid string, now time.Time) time.Duration {
+	secs := r.Thinking[pid]
+	if r.Status == "playing" && r.TurnAt != 0 && pid != "" && r.playerOn(r.sideToMove()) == pid {
+		secs += max(now.Unix()-r.TurnAt, 0)
+	}
+	return time.Duration(secs) * time.Second
+}
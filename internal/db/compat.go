@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Protocol is the version of the stored data layout this build reads and
+// writes. Bump it with any change older servers sharing the store would
+// misread, such as a room or profile schema bump. Release versions can't
+// serve here: self-hosted forks number their builds however they like.
+const Protocol = 1
+
+// ErrOutdated is returned for writes to shared data while this server is
+// blocked for speaking an older protocol than the store requires.
+var ErrOutdated = fmt.Errorf("this server is out of date and can't save games, ask its operator to upgrade")
+
+// Compat is the oldest protocol the store accepts, set with `termplay
+// compat`. Older servers are warned, or with Block, kept from writing.
+type Compat struct {
+	MinProtocol int    `json:"minProtocol"`
+	Block       bool   `json:"block"`
+	By          string `json:"by"`
+	At          int64  `json:"at"`
+}
+
+// compatPoll is how often RunCompatWatch re-reads the requirement.
+const compatPoll = time.Minute
+
+var compat atomic.Pointer[Compat]
+
+// Outdated returns the store's requirement when this server falls short
+// of it, or nil. It never touches the database.
+func Outdated() *Compat {
+	if c := compat.Load(); c != nil && Protocol < c.MinProtocol {
+		return c
+	}
+	return nil
+}
+
+// checkCompat refuses writes from a server the store has blocked.
+func checkCompat() error {
+	if c := Outdated(); c != nil && c.Block {
+		return ErrOutdated
+	}
+	return nil
+}
+
+// GetCompat reads the protocol requirement, nil when none is set.
+func GetCompat() (*Compat, error) {
+	var c *Compat
+	if err := withRef("compat", func(ref *db.Ref) error { return ref.Get(context.Background(), &c) }); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetCompat sets the protocol requirement, or removes it when c is nil.
+// The change goes on the moderation audit trail.
+func SetCompat(adminID string, c *Compat) error {
+	ctx := context.Background()
+	err := withRef("compat", func(ref *db.Ref) error {
+		if c == nil {
+			return ref.Delete(ctx)
+		}
+		return ref.Set(ctx, c)
+	})
+	if err != nil {
+		return err
+	}
+	compat.Store(c)
+
+	action := "min protocol cleared"
+	if c != nil {
+		action = fmt.Sprintf("min protocol %d", c.MinProtocol)
+		if c.Block {
+			action += " (block)"
+		}
+	}
+	entry := AuditEntry{Admin: adminID, Action: action, At: time.Now().Unix()}
+	return withRef("moderation/audit", func(ref *db.Ref) error {
+		_, err := ref.Push(ctx, entry)
+		return err
+	})
+}
+
+// RunCompatWatch keeps Outdated up to date and logs when this server
+// falls behind the store, or catches up. It never returns.
+func RunCompatWatch() {
+	for {
+		c, err := GetCompat()
+		if err != nil {
+			log.Printf("Compat: reading requirement failed: %v", err)
+		} else {
+			was := Outdated()
+			compat.Store(c)
+			switch now := Outdated(); {
+			case now != nil && (was == nil || *was != *now):
+				mode := "warning players"
+				if now.Block {
+					mode = "refusing writes"
+				}
+				log.Printf("Compat: this server speaks protocol %d, the store requires %d; %s until it is upgraded", Protocol, now.MinProtocol, mode)
+			case now == nil && was != nil:
+				log.Printf("Compat: protocol %d accepted again", Protocol)
+			}
+		}
+		time.Sleep(compatPoll)
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/notify"
+)
+
+// Correspondence rooms are for slow games: every move is on
+// config.CorrespondenceMoveTime, and players keep their seats when they
+// disconnect or leave, to come back between moves. A player who lets the
+// clock run out loses once their opponent claims the win.
+var ErrCorrespondenceBot = fmt.Errorf("the bot doesn't play correspondence games")
+
+// correspondenceKeep is how long an unfinished correspondence room is
+// kept without a write: a week past its move clock, so a player who
+// returns late can still claim their win.
+func correspondenceKeep() time.Duration {
+	return config.CorrespondenceMoveTime + 7*24*time.Hour
+}
+
+// KeepsSeat reports whether pid keeps their seat in r on leaving: in
+// a correspondence game that isn't over yet. Guests don't, as they can't
+// come back as themselves.
+func (r Room) KeepsSeat(pid string) bool {
+	return r.Correspondence && r.Status != "finished" && r.sideOf(pid) != "" && !IsGuest(pid)
+}
+
+// AwaitsMove reports whether it is pid's move in r.
+func (r Room) AwaitsMove(pid string) bool {
+	side := r.sideOf(pid)
+	return r.Status == "playing" && side != "" && side == r.sideToMove()
+}
+
+// StepAway is LeaveRoom, except that a player keeps their seat in an
+// unfinished correspondence game, to pick it up again later. It is what
+// leaving a room or disconnecting does.
+func StepAway(code, pid string, isHost bool) error {
+	r, err := GetRoom(code)
+	if err == nil && r.KeepsSeat(pid) {
+		LogRoomEvent(code, RoomEvent{Kind: "away", Player: pid, Seq: r.Seq})
+		return nil
+	}
+	if err == nil && r.walksAway(pid) {
+		go recordStall(pid, code, "left a lost game")
+	}
+	return LeaveRoom(code, pid, isHost)
+}
+
+// CorrespondenceGames returns the unfinished correspondence games pid
+// sits in: those where it is their move first, then the rest, each by
+// how long they have been waiting.
+func CorrespondenceGames(pid string) ([]Room, error) {
+	raw, err := getRawRooms()
+	if err != nil {
+		return nil, err
+	}
+	var games []Room
+	for code, rr := range raw {
+		if r := sanitizeRoom(code, rr); r.KeepsSeat(pid) {
+			games = append(games, r)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		a, b := games[i].AwaitsMove(pid), games[j].AwaitsMove(pid)
+		if a != b {
+			return a
+		}
+		return games[i].TurnAt < games[j].TurnAt
+	})
+	return games, nil
+}
+
+// notifyTurn alerts the player to move in correspondence room code, at
+// the endpoint in their settings. Nobody is alerted to their own move:
+// actor is whoever just acted. It returns at once; a failed delivery is
+// only logged.
+func notifyTurn(code string, r Room, actor string) {
+	if !r.Correspondence || r.Status != "playing" {
+		return
+	}
+	pid, opp := r.PlayerX, r.PlayerOName
+	if r.sideToMove() == "O" {
+		pid, opp = r.PlayerO, r.PlayerXName
+	}
+	if pid == "" || pid == actor || pid == BotID || IsGuest(pid) {
+		return
+	}
+	go func() {
+		p, err := GetProfile(pid)
+		if err != nil || p.Settings.TurnNotify == "" {
+			return
+		}
+		t := notify.Turn{Room: code, GameType: r.GameType, Opponent: opp, Deadline: r.MoveDeadline()}
+		if err := notify.Send(p.Settings.TurnNotify, t); err != nil {
+			log.Printf("Turn alert: room %s, player %s: %v", code, pid, err)
+		}
+	}()
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// A player whose connection drops mid-game doesn't forfeit at once: their
+// seat is held for config.RejoinWindow, for them to come back with the
+// same key (JoinRoom takes them back to it). Meanwhile their opponent
+// sees the countdown, and once it runs out claims the game with
+// ClaimForfeit.
+
+// RejoinDeadline is when the seat of pid, dropped from r, stops being
+// held, or the zero time if it isn't.
+func (r Room) RejoinDeadline(pid string) time.Time {
+	at, ok := r.Dropped[pid]
+	if !ok || r.Status != "playing" {
+		return time.Time{}
+	}
+	return time.Unix(at, 0).Add(config.RejoinWindow)
+}
+
+// Disconnect is what a dropped connection does to pid's seat in room
+// code: held for a while in a game under way, see above, and StepAway
+// otherwise. Guests can't come back as themselves, so they forfeit as
+// if they had left.
+func Disconnect(code, pid string, isHost bool) error {
+	r, err := GetRoom(code)
+	if err != nil || config.RejoinWindow <= 0 || IsGuest(pid) || r.KeepsSeat(pid) {
+		return StepAway(code, pid, isHost)
+	}
+	var final rawRoom
+	held := false
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		held = raw.live() && (raw.PlayerX == pid || raw.PlayerO == pid)
+		if !held {
+			return raw, nil
+		}
+		if raw.Dropped == nil {
+			raw.Dropped = make(map[string]int64)
+		}
+		raw.Dropped[pid] = time.Now().Unix()
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		return err
+	}
+	if !held {
+		return StepAway(code, pid, isHost)
+	}
+	log.Printf("Room %s: %s dropped, seat held for %s", code, pid, config.RejoinWindow)
+	LogRoomEvent(code, RoomEvent{Kind: "drop", Player: pid, Seq: final.Seq})
+	publishRoom(code, sanitizeRoom(code, final))
+	return nil
+}
+
+// ClaimForfeit ends the game in pid's favor once their opponent's seat has
+// been held past the rejoin window. r is the room state the claim was
+// made against, as for UpdateMove.
+func ClaimForfeit(code, pid string, r Room) error {
+	var final rawRoom
+	var gone string
+	var stalled bool
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.Seq != r.Seq {
+			return nil, ErrStaleMove
+		}
+		cur := sanitizeRoom(code, raw)
+		side := cur.sideOf(pid)
+		if side == "" || cur.Status != "playing" {
+			return nil, fmt.Errorf("only a player can claim a forfeit")
+		}
+		gone = cur.PlayerO
+		if side == "O" {
+			gone = cur.PlayerX
+		}
+		deadline := cur.RejoinDeadline(gone)
+		if deadline.IsZero() || time.Now().Before(deadline) {
+			// They came back first, or the claim was early by this
+			// server's clock
+			return nil, ErrStaleMove
+		}
+
+		stalled = cur.walksAway(gone)
+		if side == "X" {
+			// As when a guest leaves: the name stays for the result screen
+			raw.forfeit("O")
+			raw.PlayerO = ""
+		} else {
+			raw.forfeit("X")
+		}
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: "forfeit claim: " + err.Error(), Seq: r.Seq})
+		return err
+	}
+	log.Printf("Room %s: %s forfeited, not back in time", code, gone)
+	LogRoomEvent(code, RoomEvent{Kind: "forfeit", Player: gone, Detail: "not back in time", Seq: final.Seq})
+	room := sanitizeRoom(code, final)
+	publishRoom(code, room)
+	// Archived with both seats, so the loser is on record
+	if room.PlayerO == "" {
+		room.PlayerO = gone
+	}
+	archiveGame(room, "finished")
+	if stalled {
+		go recordStall(gone, code, "left a lost game")
+	}
+	return nil
+}
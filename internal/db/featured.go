@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// featured is the single admin-picked game pinned to the top of the
+// public list.
+type featured struct {
+	Code string `json:"code"`
+	By   string `json:"by"`
+	At   int64  `json:"at"`
+}
+
+// GetFeatured returns the code of the featured room, or "" if none is set.
+func GetFeatured() (string, error) {
+	var f featured
+	if err := withRef("featured", func(ref *db.Ref) error { return ref.Get(context.Background(), &f) }); err != nil {
+		return "", err
+	}
+	return f.Code, nil
+}
+
+// SetFeatured pins code as the featured game, or clears the slot when
+// code is "". The change goes on the moderation audit trail.
+func SetFeatured(adminID, code string) error {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	err := writeRef("featured", func(ref *db.Ref) error {
+		if code == "" {
+			return ref.Delete(ctx)
+		}
+		return ref.Set(ctx, featured{Code: code, By: adminID, At: now})
+	})
+	if err != nil {
+		return err
+	}
+
+	action := "feature"
+	if code == "" {
+		action = "unfeature"
+	}
+	entry := AuditEntry{Admin: adminID, Action: action, Report: Report{Room: code}, At: now}
+	return withRef("moderation/audit", func(ref *db.Ref) error {
+		_, err := ref.Push(ctx, entry)
+		return err
+	})
+}
+
+// Spectate adds pid to a room's spectators. Unlike JoinRoom it never
+// takes an open seat, so someone who only wanted to watch doesn't end up
+// playing.
+func Spectate(code, pid, name string) error {
+	var final rawRoom
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX == "" {
+			return nil, fmt.Errorf("room not found")
+		}
+		if raw.Banned[pid] {
+			return nil, fmt.Errorf("you were removed from this room by the host")
+		}
+		if raw.Spectators == nil {
+			raw.Spectators = make(map[string]string)
+		}
+		if _, back := raw.Spectators[pid]; !back && !sanitizeRoom(code, raw).HasRoom(true) {
+			return nil, ErrRoomFull
+		}
+		raw.Spectators[pid] = name
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		return err
+	}
+	publishRoom(code, sanitizeRoom(code, final))
+	return nil
+}
+
+// PickLiveGame chooses a public game in progress for pid to watch. A live
+// featured game always wins; otherwise it is the game whose players have
+// the most leaderboard wins between them when best is set, or a random
+// one. It returns nil when nothing is being played.
+func PickLiveGame(pid string, best bool) (*Room, error) {
+	rooms, err := GetPublicRooms()
+	if err != nil {
+		return nil, err
+	}
+	feat, _ := GetFeatured()
+
+	var live []Room
+	for _, r := range rooms {
+		if r.Status != "playing" || r.PlayerO == "" || r.PlayerX == pid || r.PlayerO == pid || !r.HasRoom(true) {
+			continue
+		}
+		if r.Code == feat {
+			return &r, nil
+		}
+		live = append(live, r)
+	}
+	if len(live) == 0 {
+		return nil, nil
+	}
+
+	if best {
+		if snap, err := leaderboard(); err == nil {
+			wins := make(map[string]int, len(snap.Entries))
+			for _, e := range snap.Entries {
+				wins[e.ID] = e.Wins
+			}
+			top, topWins := 0, -1
+			for i, r := range live {
+				if w := wins[r.PlayerX] + wins[r.PlayerO]; w > topWins {
+					top, topWins = i, w
+				}
+			}
+			return &live[top], nil
+		}
+	}
+	return &live[rand.Intn(len(live))], nil
+}
@@ -2,25 +2,33 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/aminshahid573/termplay/internal/bus"
 	"github.com/aminshahid573/termplay/internal/chess"
 	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/hooks"
+	"github.com/aminshahid573/termplay/internal/metrics"
 	"github.com/aminshahid573/termplay/internal/tictactoe"
 	"log"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"time"
 
 	"firebase.google.com/go/v4"
 	db "firebase.google.com/go/v4/db"
+	"firebase.google.com/go/v4/errorutils"
 	"google.golang.org/api/option"
 )
 
 // Room is the clean, strict structure used by the Game UI
 type Room struct {
 	Code        string            `json:"code"`
-	Board       [9]string         `json:"board"`
+	Board       tictactoe.Board   `json:"board"`
 	Turn        string            `json:"turn"`
 	PlayerX     string            `json:"playerX"`
 	PlayerO     string            `json:"playerO"`
@@ -32,16 +40,48 @@ type Room struct {
 	Status      string            `json:"status"`
 	WinsX       int               `json:"winsX"`
 	WinsO       int               `json:"winsO"`
+	Games       int               `json:"games"` // games finished in this pairing, see WinsX/WinsO
 	Spectators  map[string]string `json:"spectators"`
 	UpdatedAt   int64             `json:"updatedAt"`
 	GameType    string            `json:"gameType"`
 	ChessState  chess.GameState   `json:"chessState"`
+	Banned      map[string]bool   `json:"banned"` // players kicked by the host
+	BotLevel    string            `json:"botLevel"`
+	Handicap    string            `json:"handicap"`  // see handicap.go
+	PieRule     bool              `json:"pieRule"`   // see Rules
+	Ranked      bool              `json:"ranked"`    // see ranked.go
+	TurnAt      int64             `json:"turnAt"`    // when the side to move got the move
+	Flagged     string            `json:"flagged"`   // side that lost this game on time
+	Lag         map[string]int64  `json:"lag"`       // player ID -> round-trip in ms, see ranked.go
+	Moves       []string          `json:"moves"`     // this game's moves, for the archive
+	StartedAt   int64             `json:"startedAt"` // when this game began
+	Seq         int64             `json:"seq"`       // bumped on every write, see integrity.go
+	Sum         uint32            `json:"sum"`       // Checksum at the time of writing
+	Seats       map[string]Seat   `json:"seats"`     // player ID -> badges etc., see Seat
+
+	Correspondence bool `json:"correspondence"` // see correspondence.go
+
+	ListedAt int64 `json:"listedAt"` // when last put at the top of the public list, see janitor.go
+
+	// Games won in a row in the series, positive for X and negative for
+	// O, see streak.go
+	Streak int `json:"streak"`
+
+	Forfeit string           `json:"forfeit"` // side that lost this game by leaving, see forfeit.go
+	Dropped map[string]int64 `json:"dropped"` // player ID -> when their connection dropped, see drop.go
+
+	Thinking map[string]int64 `json:"thinking"` // player ID -> seconds spent on their moves this game, see clock.go
+	EndedAt  int64            `json:"endedAt"`  // when this game finished
+
+	CreatedAt int64 `json:"createdAt"` // when the host opened the room
+
+	SchemaVersion int `json:"schemaVersion"` // see migrate.go
 }
 
 // rawRoom is a helper struct to safely read dirty data (mixed types) from Firebase
 type rawRoom struct {
 	Code        string            `json:"code"`
-	Board       []interface{}     `json:"board"` // Loose type to prevent crashes
+	Board       tictactoe.Board   `json:"board"` // Decodes defensively, see tictactoe.Board
 	Turn        string            `json:"turn"`
 	PlayerX     string            `json:"playerX"`
 	PlayerO     string            `json:"playerO"`
@@ -53,19 +93,65 @@ type rawRoom struct {
 	Status      string            `json:"status"`
 	WinsX       int               `json:"winsX"`
 	WinsO       int               `json:"winsO"`
+	Games       int               `json:"games"` // games finished in this pairing, see WinsX/WinsO
 	Spectators  map[string]string `json:"spectators"`
 	UpdatedAt   int64             `json:"updatedAt"`
 	GameType    string            `json:"gameType"`
 	ChessState  chess.GameState   `json:"chessState"`
+	Banned      map[string]bool   `json:"banned"`
+	BotLevel    string            `json:"botLevel"`
+	Handicap    string            `json:"handicap"`  // see handicap.go
+	PieRule     bool              `json:"pieRule"`   // see Rules
+	Ranked      bool              `json:"ranked"`    // see ranked.go
+	TurnAt      int64             `json:"turnAt"`    // when the side to move got the move
+	Flagged     string            `json:"flagged"`   // side that lost this game on time
+	Lag         map[string]int64  `json:"lag"`       // player ID -> round-trip in ms, see ranked.go
+	Moves       []string          `json:"moves"`     // this game's moves, for the archive
+	StartedAt   int64             `json:"startedAt"` // when this game began
+	Seq         int64             `json:"seq"`       // bumped on every write, see integrity.go
+	Sum         uint32            `json:"sum"`       // Checksum at the time of writing
+	Seats       map[string]Seat   `json:"seats"`     // player ID -> badges etc., see Seat
+
+	Correspondence bool `json:"correspondence"` // see correspondence.go
+
+	ListedAt int64 `json:"listedAt"` // when last put at the top of the public list, see janitor.go
+
+	// Games won in a row in the series, positive for X and negative for
+	// O, see streak.go
+	Streak int `json:"streak"`
+
+	Forfeit string           `json:"forfeit"` // side that lost this game by leaving, see forfeit.go
+	Dropped map[string]int64 `json:"dropped"` // player ID -> when their connection dropped, see drop.go
+
+	Thinking map[string]int64 `json:"thinking"` // player ID -> seconds spent on their moves this game, see clock.go
+	EndedAt  int64            `json:"endedAt"`  // when this game finished
+
+	CreatedAt int64 `json:"createdAt"` // when the host opened the room
+
+	SchemaVersion int `json:"schemaVersion"` // see migrate.go
 }
 
-var client *db.Client
+var (
+	client   *db.Client
+	clientMu sync.RWMutex
+)
 
 func Init() error {
 	if config.DBURL == "" {
 		return fmt.Errorf("FIREBASE_DB_URL environment variable is required")
 	}
 
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	clientMu.Lock()
+	client = c
+	clientMu.Unlock()
+	return nil
+}
+
+func newClient() (*db.Client, error) {
 	var opts []option.ClientOption
 	if config.CredPath != "" {
 		if _, err := os.Stat(config.CredPath); err == nil {
@@ -76,15 +162,104 @@ func Init() error {
 	cfg := &firebase.Config{DatabaseURL: config.DBURL}
 	app, err := firebase.NewApp(context.Background(), cfg, opts...)
 	if err != nil {
-		return fmt.Errorf("error initializing app: %v", err)
+		return nil, fmt.Errorf("error initializing app: %v", err)
 	}
-	client, err = app.Database(context.Background())
+	c, err := app.Database(context.Background())
 	if err != nil {
-		return fmt.Errorf("error initializing db client: %v", err)
+		return nil, fmt.Errorf("error initializing db client: %v", err)
+	}
+	return c, nil
+}
+
+// withRef runs op against a ref on the current client. Long-running servers
+// eventually see their credentials expire; when op fails with an auth error
+// the client is re-created and op is retried once on a fresh ref.
+// Every attempt is timed into metrics.ObserveDB.
+func withRef(path string, op func(ref *db.Ref) error) error {
+	op = timed(op)
+	clientMu.RLock()
+	c := client
+	clientMu.RUnlock()
+
+	err := op(c.NewRef(path))
+	if !isAuthError(err) {
+		return err
 	}
+	if rerr := reinitClient(c); rerr != nil {
+		log.Printf("DB: re-init after auth error failed: %v", rerr)
+		return err
+	}
+
+	clientMu.RLock()
+	c = client
+	clientMu.RUnlock()
+	return op(c.NewRef(path))
+}
+
+// writeRef is withRef for writes to shared data. It refuses them while
+// the store has blocked this server's protocol (see compat.go), so an
+// outdated fork can't leave records newer servers misread.
+func writeRef(path string, op func(ref *db.Ref) error) error {
+	if err := checkCompat(); err != nil {
+		return err
+	}
+	return withRef(path, op)
+}
+
+func timed(op func(ref *db.Ref) error) func(ref *db.Ref) error {
+	return func(ref *db.Ref) error {
+		start := time.Now()
+		defer func() { metrics.ObserveDB(time.Since(start)) }()
+		return op(ref)
+	}
+}
+
+// isAuthError reports whether err means the client's token has gone bad,
+// so a new client may succeed. A write the security rules refuse
+// (permission denied) is not one: a new client would be refused too.
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errorutils.IsUnauthenticated(err) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "oauth2:") || strings.Contains(msg, "invalid_grant")
+}
+
+// reinitClient swaps in a new client unless another caller already replaced
+// the stale one.
+func reinitClient(stale *db.Client) error {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	if client != stale {
+		return nil
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	client = c
+	n := metrics.DBReinits.Add(1)
+	log.Printf("DB: auth error, re-initialized client (re-inits: %d)", n)
 	return nil
 }
 
+// publishRoom pushes the latest room state to every session subscribed to
+// the room, and to players watching for open rooms if it has a free seat.
+// A zero Room (no PlayerX) tells subscribers the room is gone.
+func publishRoom(code string, r Room) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := bus.Default.Publish(code, data); err != nil {
+		log.Printf("Bus: publish %s failed: %v", code, err)
+	}
+	publishOpenRoom(r)
+}
+
 // Helper to convert raw data to clean Room
 func sanitizeRoom(code string, raw rawRoom) Room {
 	clean := Room{
@@ -100,9 +275,34 @@ func sanitizeRoom(code string, raw rawRoom) Room {
 		Status:      raw.Status,
 		WinsX:       raw.WinsX,
 		WinsO:       raw.WinsO,
+		Games:       raw.Games,
 		Spectators:  raw.Spectators,
 		GameType:    raw.GameType,
 		ChessState:  raw.ChessState,
+		Board:       raw.Board,
+		Banned:      raw.Banned,
+		BotLevel:    raw.BotLevel,
+		Handicap:    raw.Handicap,
+		PieRule:     raw.PieRule,
+		Ranked:      raw.Ranked,
+		TurnAt:      raw.TurnAt,
+		Flagged:     raw.Flagged,
+		Lag:         raw.Lag,
+		Moves:       raw.Moves,
+		StartedAt:   raw.StartedAt,
+		Seq:         raw.Seq,
+		Sum:         raw.Sum,
+		Seats:       raw.Seats,
+
+		Correspondence: raw.Correspondence,
+		ListedAt:       raw.ListedAt,
+		Streak:         raw.Streak,
+		Forfeit:        raw.Forfeit,
+		Dropped:        raw.Dropped,
+		Thinking:       raw.Thinking,
+		EndedAt:        raw.EndedAt,
+		CreatedAt:      raw.CreatedAt,
+		SchemaVersion:  raw.SchemaVersion,
 	}
 
 	if clean.GameType == "" {
@@ -117,38 +317,22 @@ func sanitizeRoom(code string, raw rawRoom) Room {
 	if clean.Code == "" {
 		clean.Code = code
 	}
-
-	// Safely convert Board
-	clean.Board = [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "} // Default empty
-	for i, val := range raw.Board {
-		if i >= 9 {
-			break
-		}
-		// Type assertion to handle strings vs numbers
-		switch v := val.(type) {
-		case string:
-			clean.Board[i] = v
-		case float64: // JSON numbers come as float64
-			clean.Board[i] = fmt.Sprintf("%.0f", v) // Convert 0 -> "0"
-		case int:
-			clean.Board[i] = fmt.Sprintf("%d", v)
-		default:
-			clean.Board[i] = " "
-		}
-	}
 	return clean
 }
 
-func CreateRoom(code, pid, name string, public bool, gameType string) error {
-	ref := client.NewRef("rooms/" + code)
+// ErrCodeTaken is returned by CreateRoom when a room already has the code.
+var ErrCodeTaken = fmt.Errorf("room code taken")
 
-	// Check collision
-	var raw rawRoom
-	if err := ref.Get(context.Background(), &raw); err == nil {
-		if raw.PlayerX != "" {
-			return fmt.Errorf("room code taken")
+func CreateRoom(code, pid, name string, public bool, gameType, botLevel string, rules Rules) error {
+	if CurrentMaintenance() != nil {
+		return ErrMaintenance
+	}
+	if rules.Ranked {
+		if err := rankedLockout(pid); err != nil {
+			return err
 		}
 	}
+	path := "rooms/" + code
 
 	r := Room{
 		Code:        code,
@@ -159,25 +343,73 @@ func CreateRoom(code, pid, name string, public bool, gameType string) error {
 		Spectators:  make(map[string]string),
 		UpdatedAt:   time.Now().Unix(),
 		GameType:    gameType,
+		BotLevel:    botLevel,
+		Ranked:      rules.Ranked,
+
+		Correspondence: rules.Correspondence,
+		ListedAt:       time.Now().Unix(),
+		CreatedAt:      time.Now().Unix(),
+		SchemaVersion:  RoomSchemaVersion,
+	}
+	if rules.Ranked && rules.Handicap != HandicapNone {
+		return ErrRanked
+	}
+	if rules.Correspondence && botLevel != "" {
+		return ErrCorrespondenceBot
 	}
 
 	if gameType == "chess" {
 		r.ChessState = chess.NewGame()
 		r.Turn = "White"
 	} else {
-		r.Board = [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "}
+		r.Board = tictactoe.Board{}
 		r.Turn = "X"
+		r.Handicap = rules.Handicap
+		r.PieRule = rules.PieRule
 	}
+	r.Seats = withSeat(nil, pid, seatFor(pid, name))
+
+	r.stamp()
 
 	log.Printf("Creating Room: %s (%s)", code, gameType)
-	return ref.Set(context.Background(), r)
+	// Only if the code is free, checked in the same transaction so two
+	// hosts drawing the same code can't overwrite each other's room
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX != "" {
+			return nil, ErrCodeTaken
+		}
+		return r, nil
+	}
+	if err := writeRef(path, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		return err
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "create", Player: pid, Seq: r.Seq,
+		Detail: fmt.Sprintf("%s, public %v, ranked %v, handicap %q, swap rule %v", gameType, public, r.Ranked, r.Handicap, r.PieRule)})
+	hooks.FireRoomCreated(hooks.RoomCreated{Code: code, GameType: gameType, Host: pid, HostName: name,
+		Public: public, Ranked: r.Ranked, At: time.Now()})
+	publishOpenRoom(r)
+	return nil
 }
 
 func GetRoom(code string) (*Room, error) {
-	ref := client.NewRef("rooms/" + code)
-	// Fetch as Raw first to avoid crashing on bad data
+	// Fetch as a generic record first so old shapes can be migrated and
+	// bad data can't crash the decode
+	var rec map[string]interface{}
+	if err := withRef("rooms/"+code, func(ref *db.Ref) error { return ref.Get(context.Background(), &rec) }); err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("room does not exist")
+	}
+	if migrate(rec, roomMigrations) {
+		upgradeLater("rooms/"+code, roomMigrations)
+	}
 	var raw rawRoom
-	if err := ref.Get(context.Background(), &raw); err != nil {
+	if err := decodeRecord(rec, &raw); err != nil {
 		return nil, err
 	}
 	if raw.PlayerX == "" {
@@ -188,13 +420,87 @@ func GetRoom(code string) (*Room, error) {
 	return &clean, nil
 }
 
+// ErrRoomFull is returned when joining would exceed config.MaxMembers or
+// config.MaxSpectators.
+var ErrRoomFull = fmt.Errorf("room at capacity")
+
+// Members counts everyone in the room: seated players and spectators.
+func (r Room) Members() int {
+	n := len(r.Spectators)
+	if r.PlayerX != "" {
+		n++
+	}
+	if r.PlayerO != "" {
+		n++
+	}
+	return n
+}
+
+// HasRoom reports whether one more person fits, as a player or, when
+// spectator is set, as a spectator.
+func (r Room) HasRoom(spectator bool) bool {
+	if config.MaxMembers > 0 && r.Members() >= config.MaxMembers {
+		return false
+	}
+	return !spectator || config.MaxSpectators <= 0 || len(r.Spectators) < config.MaxSpectators
+}
+
+// Seat is what a room shows about a player besides their name, copied
+// from their profile when they sit down so lists and headers don't need
+// a profile read per name.
+type Seat struct {
+	Badges   []string `json:"badges"`   // in BadgeOrder
+	Mark     string   `json:"mark"`     // custom glyph, "" for the plain side letter
+	Color    string   `json:"color"`    // color name for the glyph, "" for the side's own
+	Reserved bool     `json:"reserved"` // seated under the name they reserved
+}
+
+// seatFor reads pid's profile for the seat they take as name. Errors just
+// mean a plain seat; this is decoration and must never block joining.
+func seatFor(pid, name string) Seat {
+	if pid == "" || pid == BotID {
+		return Seat{}
+	}
+	p, err := GetProfile(pid)
+	if err != nil {
+		return Seat{}
+	}
+	s := Seat{
+		Mark:     p.Settings.Mark,
+		Color:    p.Settings.MarkColor,
+		Reserved: p.ReservedName != "" && SameName(p.ReservedName, name),
+	}
+	for _, b := range BadgeOrder {
+		if p.Badges[b] {
+			s.Badges = append(s.Badges, b)
+		}
+	}
+	return s
+}
+
+// withSeat records pid's seat in a room's seat map, returning the
+// (possibly new) map.
+func withSeat(seats map[string]Seat, pid string, s Seat) map[string]Seat {
+	if seats == nil {
+		seats = make(map[string]Seat)
+	}
+	seats[pid] = s
+	return seats
+}
+
 func JoinRoom(code, pid, name string) error {
 	ctx := context.Background()
+	seat := seatFor(pid, name)
+	lockout := rankedLockout(pid)
 
 	// Transaction needs strict type mapping, so if the room is corrupted,
 	// this might still fail unless we handle it inside.
 	// For simplicity, we assume GetRoom checks passed.
+	var event RoomEvent
+	var started bool
 	fn := func(tn db.TransactionNode) (interface{}, error) {
+		event = RoomEvent{Kind: "join", Player: pid}
+		started = false
 		var raw rawRoom
 		if err := tn.Unmarshal(&raw); err != nil {
 			return nil, err
@@ -203,10 +509,43 @@ func JoinRoom(code, pid, name string) error {
 			return nil, fmt.Errorf("room not found")
 		}
 
+		if raw.Banned[pid] {
+			return nil, fmt.Errorf("you were removed from this room by the host")
+		}
+
 		// Check if Host is rejoining
 		if raw.PlayerX == pid {
+			delete(raw.Dropped, pid)
 			raw.PlayerXName = name
+			raw.Seats = withSeat(raw.Seats, pid, seat)
 			raw.UpdatedAt = time.Now().Unix()
+			raw.stamp(code)
+			event.Detail, event.Seq = "host back", raw.Seq
+			return raw, nil
+		}
+		// The guest kept their seat, as in a correspondence game, so
+		// the game goes on where it was, clock and all
+		if raw.PlayerO == pid && raw.Status == "playing" {
+			delete(raw.Dropped, pid)
+			raw.PlayerOName = name
+			raw.Seats = withSeat(raw.Seats, pid, seat)
+			raw.UpdatedAt = time.Now().Unix()
+			raw.stamp(code)
+			event.Detail, event.Seq = "back", raw.Seq
+			return raw, nil
+		}
+
+		if raw.PlayerO == BotID {
+			// Take over the bot's seat mid-game
+			if CurrentMaintenance() != nil {
+				return nil, ErrMaintenance
+			}
+			raw.PlayerO = pid
+			raw.PlayerOName = name
+			raw.Seats = withSeat(raw.Seats, pid, seat)
+			raw.UpdatedAt = time.Now().Unix()
+			raw.stamp(code)
+			event.Detail, event.Seq = "took the bot's seat as "+name, raw.Seq
 			return raw, nil
 		}
 
@@ -215,26 +554,67 @@ func JoinRoom(code, pid, name string) error {
 			if raw.Spectators == nil {
 				raw.Spectators = make(map[string]string)
 			}
+			if _, back := raw.Spectators[pid]; !back && !sanitizeRoom(code, raw).HasRoom(true) {
+				return nil, ErrRoomFull
+			}
 			raw.Spectators[pid] = name
+			raw.stamp(code)
+			event = RoomEvent{Kind: "spectate", Player: pid, Detail: name, Seq: raw.Seq}
 			return raw, nil
 		}
 
+		if raw.PlayerO != pid && !sanitizeRoom(code, raw).HasRoom(false) {
+			return nil, ErrRoomFull
+		}
+		if raw.PlayerO != pid && CurrentMaintenance() != nil {
+			// Players already seated may rejoin to finish; nobody new
+			return nil, ErrMaintenance
+		}
+		if raw.Ranked && lockout != nil {
+			return nil, lockout
+		}
+
+		if raw.Forfeit != "" {
+			// The last guest forfeited; a new one gets a new game
+			raw.clearBoard()
+		}
+
 		// Update fields
 		raw.PlayerO = pid
 		raw.PlayerOName = name
+		raw.Seats = withSeat(raw.Seats, pid, seat)
 		raw.Status = "playing"
+		raw.TurnAt = time.Now().Unix()
+		if len(raw.Moves) == 0 {
+			raw.StartedAt = time.Now().Unix()
+			raw.Thinking, raw.EndedAt = nil, 0
+			raw.Turn = sanitizeRoom(code, raw).startingTurn(raw.Turn)
+		}
+		raw.stamp(code)
+		event.Detail, event.Seq = "as O, "+name, raw.Seq
+		started = true
 		return raw, nil
 	}
-	return client.NewRef("rooms/"+code).Transaction(ctx, fn)
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: "join: " + err.Error()})
+		return err
+	}
+	LogRoomEvent(code, event)
+	if r, err := GetRoom(code); err == nil {
+		publishRoom(code, *r)
+		if started {
+			notifyTurn(code, *r, pid)
+		}
+	}
+	return nil
 }
 
 func LeaveRoom(code, pid string, isHost bool) error {
 	ctx := context.Background()
-	ref := client.NewRef("rooms/" + code)
+	path := "rooms/" + code
 
 	if isHost {
-		// Host leaves -> Delete room
-		return ref.Delete(ctx)
+		return leaveAsHost(code, pid)
 	}
 
 	// Not host. Check if PlayerO or Spectator
@@ -245,81 +625,419 @@ func LeaveRoom(code, pid string, isHost bool) error {
 	// If Spectator: delete spectators/pid
 
 	// Let's use transaction to be safe and atomic
+	var final rawRoom
+	action := ""
 	fn := func(tn db.TransactionNode) (interface{}, error) {
 		var raw rawRoom
 		if err := tn.Unmarshal(&raw); err != nil {
 			return nil, err
 		}
+		action = "leave"
 
-		if raw.PlayerO == pid {
+		switch {
+		case raw.PlayerO == pid && raw.Forfeit == "X":
+			// The host already left; the room goes with its last player
+			action = "delete"
+			final = raw
+			return raw, nil
+		case raw.PlayerO == pid && raw.live():
+			// The name stays for the host's result screen
+			action = "forfeit"
+			raw.forfeit("O")
+			raw.PlayerO = ""
+		case raw.PlayerO == pid:
 			raw.PlayerO = ""
 			raw.PlayerOName = ""
 			raw.Status = "waiting"
-		} else {
+		default:
 			if raw.Spectators != nil {
 				delete(raw.Spectators, pid)
 			}
 		}
+		raw.stamp(code)
+		final = raw
 		return raw, nil
 	}
-	return ref.Transaction(ctx, fn)
+	if err := writeRef(path, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+	switch action {
+	case "forfeit":
+		log.Printf("Room %s: O forfeited by leaving", code)
+		LogRoomEvent(code, RoomEvent{Kind: "forfeit", Player: pid, Detail: "O left mid-game", Seq: final.Seq})
+		r := sanitizeRoom(code, final)
+		publishRoom(code, r)
+		// Archived with the seat as it was, so the loser is on record
+		r.PlayerO = pid
+		archiveGame(r, "finished")
+	case "delete":
+		return deleteRoom(code, pid, final, "last player left after the host forfeited")
+	default:
+		LogRoomEvent(code, RoomEvent{Kind: "leave", Player: pid, Seq: final.Seq})
+		publishRoom(code, sanitizeRoom(code, final))
+	}
+	return nil
 }
 
-func UpdateMove(code, pid string, idx int, r Room) error {
-	// Game Logic
-	r.Board[idx] = r.Turn
-	winner, line := tictactoe.CheckWinner(r.Board)
-
-	if winner != "" {
-		r.Winner = winner
-		r.WinningLine = line
-		r.Status = "finished"
-		if winner == "X" {
-			r.WinsX++
+// BotID is the player ID stored in a seat filled by the computer.
+// The host's client makes the bot's moves.
+const BotID = "bot"
+
+// AddBot seats a bot as Player O in a waiting tic-tac-toe room.
+func AddBot(code, hostID string) error {
+	if CurrentMaintenance() != nil {
+		return ErrMaintenance
+	}
+	ctx := context.Background()
+	var final rawRoom
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX != hostID {
+			return nil, fmt.Errorf("only the host can add a bot")
+		}
+		if raw.GameType == "chess" {
+			return nil, fmt.Errorf("bots only play tic-tac-toe")
+		}
+		if raw.PlayerO != "" {
+			return nil, fmt.Errorf("room already has an opponent")
+		}
+		if raw.Ranked {
+			return nil, ErrRanked
+		}
+		if raw.Correspondence {
+			return nil, ErrCorrespondenceBot
+		}
+
+		raw.PlayerO = BotID
+		raw.PlayerOName = "Bot"
+		raw.Status = "playing"
+		raw.Turn = sanitizeRoom(code, raw).startingTurn(raw.Turn)
+		raw.UpdatedAt = time.Now().Unix()
+		raw.StartedAt = raw.UpdatedAt
+		raw.TurnAt = raw.UpdatedAt
+		raw.Thinking, raw.EndedAt = nil, 0
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "bot", Player: hostID, Detail: final.BotLevel, Seq: final.Seq})
+	publishRoom(code, sanitizeRoom(code, final))
+	return nil
+}
+
+// KickPlayer removes Player O at the host's request and bars them from
+// rejoining. The board and series score are reset for the next opponent.
+func KickPlayer(code, hostID string) error {
+	ctx := context.Background()
+	var final rawRoom
+	var kicked string
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX != hostID {
+			return nil, fmt.Errorf("only the host can remove players")
+		}
+		if raw.PlayerO == "" {
+			return nil, fmt.Errorf("no opponent to remove")
+		}
+
+		if raw.Banned == nil {
+			raw.Banned = make(map[string]bool)
+		}
+		raw.Banned[raw.PlayerO] = true
+		kicked = raw.PlayerO
+		raw.PlayerO = ""
+		raw.PlayerOName = ""
+		raw.Status = "waiting"
+		raw.Winner = ""
+		raw.WinningLine = nil
+		raw.Flagged = ""
+		raw.Forfeit = ""
+		raw.WinsX = 0
+		raw.WinsO = 0
+		raw.Games = 0
+		raw.Streak = 0
+		raw.Moves = nil
+		if raw.GameType == "chess" {
+			raw.ChessState = chess.NewGame()
+			raw.Turn = "White"
 		} else {
-			r.WinsO++
+			raw.Board = tictactoe.Board{}
+			raw.Turn = "X"
 		}
-	} else if tictactoe.CheckDraw(r.Board) {
-		r.Status = "finished"
-	} else {
-		if r.Turn == "X" {
-			r.Turn = "O"
+		raw.UpdatedAt = time.Now().Unix()
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+	log.Printf("Room %s: host removed opponent", code)
+	LogRoomEvent(code, RoomEvent{Kind: "kick", Player: hostID, Detail: kicked, Seq: final.Seq})
+	publishRoom(code, sanitizeRoom(code, final))
+	return nil
+}
+
+// leaveAsHost hands the room to Player O if one is present, keeping the
+// series score, and only deletes the room when the host was alone.
+func leaveAsHost(code, pid string) error {
+	ctx := context.Background()
+	path := "rooms/" + code
+
+	var final rawRoom
+	var abandoned Room
+	action := ""
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		final = raw
+		switch {
+		case raw.PlayerX != pid, raw.Forfeit == "X":
+			// Someone else hosts this room now, or the host already
+			// forfeited it; leave it alone
+			action = "none"
+			return raw, nil
+		case raw.PlayerO == "", raw.PlayerO == BotID:
+			action = "delete"
+			return raw, nil
+		}
+		if raw.live() {
+			// Both seats stay put, so the opponent gets the result screen
+			// rather than a room pulled from under them
+			action = "forfeit"
+			raw.forfeit("X")
+			raw.stamp(code)
+			final = raw
+			return raw, nil
+		}
+
+		action = "migrate"
+		abandoned = sanitizeRoom(code, raw)
+		raw.PlayerX, raw.PlayerXName = raw.PlayerO, raw.PlayerOName
+		raw.PlayerO, raw.PlayerOName = "", ""
+		raw.WinsX, raw.WinsO = raw.WinsO, raw.WinsX
+		raw.Streak = -raw.Streak
+		raw.Status = "waiting"
+		raw.Winner = ""
+		raw.WinningLine = nil
+		raw.Flagged = ""
+		raw.Moves = nil
+		if raw.GameType == "chess" {
+			raw.ChessState = chess.NewGame()
+			raw.Turn = "White"
 		} else {
-			r.Turn = "X"
+			raw.Board = tictactoe.Board{}
+			raw.Turn = "X"
 		}
+		raw.UpdatedAt = time.Now().Unix()
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef(path, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+
+	switch action {
+	case "migrate":
+		archiveIfAbandoned(abandoned)
+		log.Printf("Room %s: host left, promoted %s", code, final.PlayerXName)
+		LogRoomEvent(code, RoomEvent{Kind: "leave", Player: pid, Detail: "host left, promoted " + final.PlayerX, Seq: final.Seq})
+		publishRoom(code, sanitizeRoom(code, final))
+	case "forfeit":
+		log.Printf("Room %s: X forfeited by leaving", code)
+		LogRoomEvent(code, RoomEvent{Kind: "forfeit", Player: pid, Detail: "host left mid-game", Seq: final.Seq})
+		publishRoom(code, sanitizeRoom(code, final))
+		archiveGame(sanitizeRoom(code, final), "finished")
+	case "delete":
+		archiveIfAbandoned(sanitizeRoom(code, final))
+		return deleteRoom(code, pid, final, "host left")
+	}
+	return nil
+}
+
+// deleteRoom removes room code, left by pid for the reason given, as
+// final had it.
+func deleteRoom(code, pid string, final rawRoom, why string) error {
+	LogRoomEvent(code, RoomEvent{Kind: "delete", Player: pid, Detail: why, Seq: final.Seq})
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Delete(context.Background()) }); err != nil {
+		return err
 	}
+	deleteChat(code)
+	publishRoom(code, Room{Code: code})
+	return nil
+}
+
+// UpdateMove plays idx for pid, who must have the move, in r, the room
+// state the player was looking at. If the stored room has moved on since
+// (a newer Seq), the move is refused with ErrStaleMove rather than
+// overwriting it.
+func UpdateMove(code, pid string, idx int, r Room) error {
+	var final Room
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var cur Room
+		if err := tn.Unmarshal(&cur); err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= len(cur.Board) {
+			return nil, ErrIllegalMove
+		}
+		if cur.Seq != r.Seq || cur.Status != "playing" || cur.Board[idx] != tictactoe.Empty {
+			return nil, ErrStaleMove
+		}
+		if cur.sideOf(pid) != cur.Turn {
+			return nil, ErrNotYourTurn
+		}
+		if cur.timeUp() {
+			return nil, ErrTimeUp
+		}
+		if cur.HandicapBlocks(idx) {
+			return nil, ErrHandicap
+		}
+
+		now := time.Now().Unix()
+		cur.spendThinking(pid, now)
+
+		// Game Logic
+		cur.Board[idx] = tictactoe.ParseCell(cur.Turn)
+		cur.Moves = append(cur.Moves, strconv.Itoa(idx))
+		winner, line := tictactoe.CheckWinner(cur.Board)
 
-	// When saving back, we save strict Room, effectively "fixing" the data
-	return client.NewRef("rooms/"+code).Set(context.Background(), r)
+		if winner != tictactoe.Empty {
+			cur.Winner = winner.String()
+			cur.WinningLine = line
+			cur.Status = "finished"
+			cur.Games++
+			if winner == tictactoe.X {
+				cur.WinsX++
+			} else {
+				cur.WinsO++
+			}
+			cur.Streak = addStreak(cur.Streak, winner.String())
+		} else if tictactoe.CheckDraw(cur.Board) {
+			cur.Status = "finished"
+			cur.Games++
+			cur.Streak = addStreak(cur.Streak, "")
+		} else {
+			if cur.Turn == "X" {
+				cur.Turn = "O"
+			} else {
+				cur.Turn = "X"
+			}
+		}
+		if cur.Status == "finished" {
+			cur.EndedAt = now
+		}
+		cur.UpdatedAt = now
+		cur.TurnAt = cur.UpdatedAt
+		cur.stamp()
+		final = cur
+		return cur, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: fmt.Sprintf("move %d: %v", idx, err), Seq: r.Seq})
+		return err
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "move", Player: pid, Detail: fmt.Sprintf("%d %s", idx, final.Status), Seq: final.Seq})
+	publishRoom(code, final)
+	notifyTurn(code, final, pid)
+	if final.Status == "finished" {
+		archiveGame(final, "finished")
+	}
+	return nil
 }
 
-func UpdateChessState(code string, state chess.GameState) error {
-	ref := client.NewRef("rooms/" + code)
+// UpdateChessMove plays from-to (promoting to promotion, "" for a queen)
+// for pid in r, the room state they were looking at, as UpdateMove does
+// for tic-tac-toe.
+func UpdateChessMove(code, pid string, from, to chess.Pos, promotion string, r Room) error {
+	var final Room
+	move := from.String() + to.String()
 	fn := func(tn db.TransactionNode) (interface{}, error) {
-		var r Room
-		if err := tn.Unmarshal(&r); err != nil {
+		var cur Room
+		if err := tn.Unmarshal(&cur); err != nil {
 			return nil, err
 		}
-		r.ChessState = state
-		r.Turn = state.Turn
+		if cur.Seq != r.Seq || cur.Status != "playing" {
+			return nil, ErrStaleMove
+		}
+		if cur.sideOf(pid) != cur.sideToMove() {
+			return nil, ErrNotYourTurn
+		}
+		if cur.timeUp() {
+			return nil, ErrTimeUp
+		}
+		if !from.OnBoard() || !to.OnBoard() {
+			return nil, ErrIllegalMove
+		}
+		piece := cur.ChessState.Board[from.Row][from.Col]
+		if piece.IsEmpty() || piece.IsWhite != (cur.Turn == "White") || !chess.GetLegalMoves(cur.ChessState, from.Row, from.Col)[to] {
+			return nil, ErrIllegalMove
+		}
+		now := time.Now().Unix()
+		cur.spendThinking(pid, now)
+		state := chess.ApplyMove(cur.ChessState, from, to, promotion)
+		cur.ChessState = state
+		cur.Turn = state.Turn
+		cur.Moves = append(cur.Moves, move)
 		if state.Status != "playing" {
-			r.Status = state.Status
-			r.Winner = state.Winner
+			cur.Status = state.Status
+			cur.Winner = state.Winner
+			cur.Games++
+			switch cur.Winner {
+			case "White":
+				cur.WinsX++
+			case "Black":
+				cur.WinsO++
+			}
+			cur.Streak = addStreak(cur.Streak, map[string]string{"White": "X", "Black": "O"}[cur.Winner])
+			cur.EndedAt = now
 		}
-		r.UpdatedAt = time.Now().Unix()
-		return r, nil
+		cur.UpdatedAt = now
+		cur.TurnAt = cur.UpdatedAt
+		cur.stamp()
+		final = cur
+		return cur, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: fmt.Sprintf("move %s: %v", move, err), Seq: r.Seq})
+		return err
 	}
-	return ref.Transaction(context.Background(), fn)
+	LogRoomEvent(code, RoomEvent{Kind: "move", Player: pid, Detail: move + " " + final.Status, Seq: final.Seq})
+	publishRoom(code, final)
+	notifyTurn(code, final, pid)
+	if final.Status != "playing" {
+		archiveGame(final, "finished")
+	}
+	return nil
 }
 
 func RestartGame(code string, nextTurn string) error {
 	ctx := context.Background()
-	ref := client.NewRef("rooms/" + code)
+	var final Room
 	fn := func(tn db.TransactionNode) (interface{}, error) {
 		var r Room
 		if err := tn.Unmarshal(&r); err != nil {
 			return nil, err
 		}
+		if r.Status != "finished" {
+			// Restarting is for rematches, not for escaping a game
+			return nil, fmt.Errorf("the game isn't over yet")
+		}
+		if r.Forfeit != "" {
+			return nil, fmt.Errorf("your opponent left the game")
+		}
 
 		if r.GameType == "chess" {
 			r.ChessState = chess.NewGame()
@@ -334,24 +1052,38 @@ func RestartGame(code string, nextTurn string) error {
 			r.ChessState.Turn = nextTurn // Sync
 		} else {
 
-			r.Board = [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "}
-			r.Turn = nextTurn
+			r.Board = tictactoe.Board{}
+			r.Turn = r.startingTurn(nextTurn)
 		}
 
 		r.Winner = ""
 		r.WinningLine = nil
+		r.Flagged = ""
+		r.Forfeit = ""
+		r.Dropped = nil
 		r.Status = "playing"
+		r.Moves = nil
+		r.Thinking = nil
+		r.EndedAt = 0
+		r.StartedAt = time.Now().Unix()
+		r.TurnAt = r.StartedAt
+		r.stamp()
+		final = r
 		return r, nil
 	}
-	return ref.Transaction(ctx, fn)
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "restart", Detail: final.Turn + " to start", Seq: final.Seq})
+	publishRoom(code, final)
+	notifyTurn(code, final, "")
+	return nil
 }
 
 func GetPublicRooms() ([]Room, error) {
-	ref := client.NewRef("rooms")
-
 	// 1. Fetch as map of RawRooms (tolerant to bad data)
-	var rawMap map[string]rawRoom
-	if err := ref.Get(context.Background(), &rawMap); err != nil {
+	rawMap, err := getRawRooms()
+	if err != nil {
 		log.Printf("Error fetching public rooms: %v", err)
 		return nil, err
 	}
@@ -366,30 +1098,78 @@ func GetPublicRooms() ([]Room, error) {
 		}
 	}
 
-	// 4. Sort
+	// 4. Sort, most recently listed first
 	sort.Slice(list, func(i, j int) bool {
+		if list[i].ListedAt != list[j].ListedAt {
+			return list[i].ListedAt > list[j].ListedAt
+		}
 		return list[i].Code < list[j].Code
 	})
 
 	return list, nil
 }
 
-// CleanZombies removes rooms that haven't been updated in 1 hour
+// HostedRooms returns the live rooms pid hosts that aren't finished,
+// most recently active first: rooms they backed out of, or lost when
+// their connection dropped. Games where their seat is held after a drop
+// (see drop.go) are included as guest too. Correspondence games are left
+// to CorrespondenceGames.
+func HostedRooms(pid string) ([]Room, error) {
+	raw, err := getRawRooms()
+	if err != nil {
+		return nil, err
+	}
+	var rooms []Room
+	for code, rr := range raw {
+		held := rr.Dropped[pid] != 0 && rr.Status == "playing"
+		if (rr.PlayerX == pid || held) && rr.Status != "finished" && !rr.Correspondence {
+			rooms = append(rooms, sanitizeRoom(code, rr))
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].UpdatedAt > rooms[j].UpdatedAt })
+	return rooms, nil
+}
+
+// getRawRooms reads every room, migrating old records in memory.
+func getRawRooms() (map[string]rawRoom, error) {
+	var recs map[string]map[string]interface{}
+	if err := withRef("rooms", func(ref *db.Ref) error { return ref.Get(context.Background(), &recs) }); err != nil {
+		return nil, err
+	}
+	rooms := make(map[string]rawRoom, len(recs))
+	for code, rec := range recs {
+		if rec == nil {
+			continue
+		}
+		migrate(rec, roomMigrations)
+		var raw rawRoom
+		if err := decodeRecord(rec, &raw); err != nil {
+			log.Printf("Skipping unreadable room %s: %v", code, err)
+			continue
+		}
+		rooms[code] = raw
+	}
+	return rooms, nil
+}
+
+// CleanZombies removes rooms past their ExpiresAt.
 func CleanZombies() {
-	ref := client.NewRef("rooms")
-	var rawMap map[string]rawRoom
-	if err := ref.Get(context.Background(), &rawMap); err != nil {
+	rawMap, err := getRawRooms()
+	if err != nil {
 		log.Printf("Janitor: Error fetching rooms: %v", err)
 		return
 	}
 
 	now := time.Now().Unix()
-	limit := int64(3600) // 1 hour
 
 	for code, r := range rawMap {
-		if now-r.UpdatedAt > limit {
+		room := sanitizeRoom(code, r)
+		if time.Now().After(room.ExpiresAt()) {
 			log.Printf("Janitor: Deleting zombie room %s (Last active: %ds ago)", code, now-r.UpdatedAt)
-			ref.Child(code).Delete(context.Background())
+			archiveIfAbandoned(room)
+			writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Delete(context.Background()) })
+			LogRoomEvent(code, RoomEvent{Kind: "delete", Detail: "idle", Seq: r.Seq})
+			deleteChat(code)
 		}
 	}
 }
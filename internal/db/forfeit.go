@@ -0,0 +1,63 @@
+package db
+
+import (
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+)
+
+// Leaving a game under way forfeits it: the opponent is credited with the
+// win and shown the result before the seat is given up, and a room whose
+// host forfeited is only deleted once the opponent leaves it too.
+
+// live reports whether leaving raw now forfeits its game: a move made
+// and two players seated, the bot not counting.
+func (raw rawRoom) live() bool {
+	return raw.Status == "playing" && len(raw.Moves) > 0 && raw.PlayerO != "" && raw.PlayerO != BotID
+}
+
+// forfeit ends raw's game with side ("X" or "O") the loser for leaving.
+func (raw *rawRoom) forfeit(side string) {
+	winner := "X"
+	if side == "X" {
+		winner = "O"
+	}
+	raw.Status = "finished"
+	raw.Forfeit = side
+	raw.Dropped = nil
+	raw.Games++
+	if winner == "X" {
+		raw.WinsX++
+	} else {
+		raw.WinsO++
+	}
+	raw.Streak = addStreak(raw.Streak, winner)
+	raw.Winner = winner
+	raw.WinningLine = nil
+	if raw.GameType == "chess" {
+		raw.Winner = map[string]string{"X": "White", "O": "Black"}[winner]
+		raw.ChessState.Status = "finished"
+		raw.ChessState.Winner = raw.Winner
+	}
+	raw.UpdatedAt = time.Now().Unix()
+	raw.EndedAt = raw.UpdatedAt
+}
+
+// clearBoard sets raw up for a new game, for a guest taking the seat of
+// one who forfeited.
+func (raw *rawRoom) clearBoard() {
+	raw.Winner = ""
+	raw.WinningLine = nil
+	raw.Flagged = ""
+	raw.Forfeit = ""
+	raw.Moves = nil
+	raw.Thinking, raw.EndedAt = nil, 0
+	if raw.GameType == "chess" {
+		raw.ChessState = chess.NewGame()
+		raw.Turn = "White"
+	} else {
+		raw.Board = tictactoe.Board{}
+		raw.Turn = "X"
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import "fmt"
+
+// Handicaps a host can set on a tic-tac-toe room, to even out games
+// between players of different strength. Strength is read from the
+// room's series score: the player with fewer wins is the weaker one,
+// and on a tie the host, who chose to give the handicap, is the stronger.
+const (
+	HandicapNone         = ""
+	HandicapWeakerStarts = "weaker-starts" // the weaker player moves first in every game
+	HandicapNoCenter     = "no-center"     // the stronger player's first mark can't go in the center
+)
+
+// Handicaps lists the handicaps in the order the room settings cycle
+// through them.
+var Handicaps = []string{HandicapNone, HandicapWeakerStarts, HandicapNoCenter}
+
+// ErrHandicap is returned for a move the room's handicap rules out.
+var ErrHandicap = fmt.Errorf("handicap: your first mark can't go in the center")
+
+// centerCell is the board index of the middle square.
+const centerCell = 4
+
+// Weaker returns the side ("X" or "O") of the player behind in the
+// series, "O" on a tie.
+func (r Room) Weaker() string {
+	if r.WinsX < r.WinsO {
+		return "X"
+	}
+	return "O"
+}
+
+// startingTurn is who moves first in a new game, given the turn the
+// players asked for. Only the weaker-starts handicap overrides it.
+func (r Room) startingTurn(asked string) string {
+	if r.GameType != "chess" && r.Handicap == HandicapWeakerStarts {
+		return r.Weaker()
+	}
+	return asked
+}
+
+// HandicapBlocks reports whether the no-center handicap rules out playing
+// idx for the side to move. The bot is never held to it.
+func (r Room) HandicapBlocks(idx int) bool {
+	if r.Handicap != HandicapNoCenter || idx != centerCell || r.Turn == r.Weaker() {
+		return false
+	}
+	if (r.Turn == "X" && r.PlayerX == BotID) || (r.Turn == "O" && r.PlayerO == BotID) {
+		return false
+	}
+	mark := r.Turn
+	for _, c := range r.Board {
+		if c.String() == mark {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// HeadToHead is the lifetime record between two players, over every room
+// they met in. Unlike a room's WinsX/WinsO it survives the room, and
+// follows the players rather than the seats. Records live at
+// /headtohead/<lower ID>~<higher ID>.
+type HeadToHead struct {
+	Wins  map[string]int `json:"wins"` // player ID -> games won against the other
+	Draws int            `json:"draws"`
+}
+
+// Of returns the wins of id and of the other player, and the draws.
+func (h HeadToHead) Of(id, other string) (won, lost, drawn int) {
+	return h.Wins[id], h.Wins[other], h.Draws
+}
+
+func headToHeadPath(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return "headtohead/" + a + "~" + b
+}
+
+// GetHeadToHead loads the record between players a and b. Players who
+// never finished a game together get an empty one.
+func GetHeadToHead(a, b string) (HeadToHead, error) {
+	var h HeadToHead
+	err := withRef(headToHeadPath(a, b), func(ref *db.Ref) error { return ref.Get(context.Background(), &h) })
+	return h, err
+}
+
+// CountsHeadToHead reports whether games between a and b are recorded:
+// only between two players known by their key, not guests or the bot.
+func CountsHeadToHead(a, b string) bool {
+	return a != "" && b != "" && a != b && a != BotID && b != BotID && !IsGuest(a) && !IsGuest(b)
+}
+
+// recordHeadToHead adds a finished game to its players' lifetime record.
+// Like archiving, a failure is only logged.
+func recordHeadToHead(g ArchivedGame) {
+	if !CountsHeadToHead(g.PlayerX, g.PlayerO) {
+		return
+	}
+	winner := ""
+	switch g.Winner {
+	case "X", "White":
+		winner = g.PlayerX
+	case "O", "Black":
+		winner = g.PlayerO
+	}
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var h HeadToHead
+		if err := tn.Unmarshal(&h); err != nil {
+			return nil, err
+		}
+		if h.Wins == nil {
+			h.Wins = map[string]int{}
+		}
+		if winner == "" {
+			h.Draws++
+		} else {
+			h.Wins[winner]++
+		}
+		return h, nil
+	}
+	if err := writeRef(headToHeadPath(g.PlayerX, g.PlayerO), func(ref *db.Ref) error {
+		return ref.Transaction(context.Background(), fn)
+	}); err != nil {
+		log.Printf("Head-to-head: room %s: %v", g.Room, err)
+	}
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"hash/crc32"
+
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+)
+
+// ErrStaleMove is returned when a move was made against a room state that
+// has since changed, e.g. both players' clients raced on the same turn.
+var ErrStaleMove = fmt.Errorf("room changed before the move landed")
+
+// Moves are checked and played out in the store's transaction, never
+// taken on a client's word: a move must come from the player whose turn
+// it is and be legal in the stored position, and the result (winner,
+// line, status) is worked out from there.
+var (
+	ErrNotYourTurn = fmt.Errorf("it isn't your move")
+	ErrIllegalMove = fmt.Errorf("that move isn't legal")
+)
+
+// Checksum hashes the parts of a room that are drawn on screen. Every write
+// stores it in Sum, so a client can tell when the state it received is not
+// the one that was written. It is built with fmt rather than JSON because
+// the store drops empty lists and maps on the way back.
+func (r Room) Checksum() uint32 {
+	h := crc32.NewIEEE()
+	fmt.Fprintf(h, "%d|%v|%s|%s|%s|%v|%s|%s|%d|%d|",
+		r.Seq, r.Board, r.Turn, r.Status, r.Winner, r.WinningLine,
+		r.PlayerX, r.PlayerO, r.WinsX, r.WinsO)
+	c := r.ChessState
+	fmt.Fprintf(h, "%v|%s|%s|%s", c.Board, c.Turn, c.Status, c.Winner)
+	return h.Sum32()
+}
+
+// stamp marks a write: Seq orders room states so late arrivals can be
+// dropped, and Sum lets readers verify what they got.
+func (r *Room) stamp() {
+	r.Seq++
+	r.Sum = r.Checksum()
+}
+
+func (raw *rawRoom) stamp(code string) {
+	raw.Seq++
+	raw.Sum = sanitizeRoom(code, *raw).Checksum()
+}
+
+// Verify reports why a room state can't be trusted, or nil if it looks
+// sound. Rooms written before checksums existed have no Sum and only get
+// the board checks.
+func (r Room) Verify() error {
+	if r.Sum != 0 && r.Sum != r.Checksum() {
+		return fmt.Errorf("checksum mismatch at seq %d", r.Seq)
+	}
+	if r.GameType == "chess" {
+		return nil
+	}
+
+	var xs, os int
+	for _, c := range r.Board {
+		switch c {
+		case tictactoe.X:
+			xs++
+		case tictactoe.O:
+			os++
+		}
+	}
+	// Either side may open a game, so the counts differ by at most one
+	if xs-os > 1 || os-xs > 1 {
+		return fmt.Errorf("impossible board: %d X, %d O", xs, os)
+	}
+	winner, _ := tictactoe.CheckWinner(r.Board)
+	switch r.Status {
+	case "playing":
+		if winner != tictactoe.Empty {
+			return fmt.Errorf("game still playing after %s won", winner)
+		}
+		if (xs > os && r.Turn != "O") || (os > xs && r.Turn != "X") {
+			return fmt.Errorf("turn %q out of step with the board", r.Turn)
+		}
+	case "finished":
+		if r.Winner != "" && r.Flagged == "" && r.Forfeit == "" && r.Winner != winner.String() {
+			return fmt.Errorf("winner %q not on the board", r.Winner)
+		}
+	}
+	return nil
+}
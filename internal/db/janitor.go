@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ExpiresAt is when the janitor removes r if nobody writes to it before
+// then: config.RoomIdleTTL after the last write, or longer for an
+// unfinished correspondence game.
+func (r Room) ExpiresAt() time.Time {
+	ttl := config.RoomIdleTTL
+	if r.Correspondence && r.Status != "finished" {
+		ttl = correspondenceKeep()
+	}
+	return time.Unix(r.UpdatedAt, 0).Add(ttl)
+}
+
+// RunJanitor removes expired rooms now and every config.JanitorInterval
+// after. It never returns.
+func RunJanitor() {
+	for {
+		CleanZombies()
+		time.Sleep(config.JanitorInterval)
+	}
+}
+
+// TouchRoom marks the room as in use, so the janitor's clock starts
+// again, e.g. for a host still waiting in the lobby.
+func TouchRoom(code string) error {
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX == "" {
+			return nil, fmt.Errorf("room not found")
+		}
+		raw.UpdatedAt = time.Now().Unix()
+		return raw, nil
+	}
+	return writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) })
+}
+
+// RelistRoom moves a public room still waiting for an opponent back to
+// the top of the public list, so it doesn't sink out of sight, and
+// restarts the janitor's clock on it.
+func RelistRoom(code string) error {
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX == "" || !raw.IsPublic || raw.PlayerO != "" {
+			return nil, fmt.Errorf("room is not waiting on the public list")
+		}
+		raw.ListedAt = time.Now().Unix()
+		raw.UpdatedAt = raw.ListedAt
+		return raw, nil
+	}
+	return writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) })
+}
+
+// OpenRoom puts a private room on the public list, e.g. when its host
+// gives up on the friend they invited. Only the host may open it.
+func OpenRoom(code, hostID string) error {
+	var final rawRoom
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var raw rawRoom
+		if err := tn.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+		if raw.PlayerX != hostID {
+			return nil, fmt.Errorf("only the host can open the room")
+		}
+		raw.IsPublic = true
+		raw.ListedAt = time.Now().Unix()
+		raw.UpdatedAt = raw.ListedAt
+		raw.stamp(code)
+		final = raw
+		return raw, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		return err
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "open", Player: hostID, Seq: final.Seq})
+	publishRoom(code, sanitizeRoom(code, final))
+	return nil
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// LeaderboardEntry is one player's all-time record.
+type LeaderboardEntry struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Draws  int    `json:"draws"`
+}
+
+// leaderboardSnapshot is the pre-sorted board stored at /leaderboard so a
+// cold server can serve it with a single read.
+type leaderboardSnapshot struct {
+	Entries []LeaderboardEntry `json:"entries"`
+	BuiltAt int64              `json:"builtAt"`
+}
+
+// less orders entries by wins, then fewer losses, then ID so every entry
+// has a unique, stable position for cursors.
+func (e LeaderboardEntry) less(o LeaderboardEntry) bool {
+	if e.Wins != o.Wins {
+		return e.Wins > o.Wins
+	}
+	if e.Losses != o.Losses {
+		return e.Losses < o.Losses
+	}
+	return e.ID < o.ID
+}
+
+func (e LeaderboardEntry) cursor() string {
+	return fmt.Sprintf("%d:%d:%s", e.Wins, e.Losses, e.ID)
+}
+
+func parseCursor(c string) (LeaderboardEntry, bool) {
+	parts := strings.SplitN(c, ":", 3)
+	if len(parts) != 3 {
+		return LeaderboardEntry{}, false
+	}
+	w, err1 := strconv.Atoi(parts[0])
+	l, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return LeaderboardEntry{}, false
+	}
+	return LeaderboardEntry{Wins: w, Losses: l, ID: parts[2]}, true
+}
+
+var lb struct {
+	mu      sync.Mutex
+	snap    leaderboardSnapshot
+	fetched time.Time // when this process last loaded or built snap
+	dirty   bool      // stored snapshot is known to be out of date
+}
+
+// LeaderboardPage returns up to n entries after cursor ("" for the top),
+// the cursor for the next page ("" on the last page), and when the board
+// was built. The cursor is the position of the last entry shown, so pages
+// stay consistent even if the board is rebuilt between requests.
+func LeaderboardPage(cursor string, n int) ([]LeaderboardEntry, string, time.Time, error) {
+	snap, err := leaderboard()
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	entries := snap.Entries
+
+	start := 0
+	if after, ok := parseCursor(cursor); ok {
+		start = sort.Search(len(entries), func(i int) bool { return after.less(entries[i]) })
+	}
+	end := start + n
+	if end > len(entries) {
+		end = len(entries)
+	}
+	page := append([]LeaderboardEntry(nil), entries[start:end]...)
+
+	next := ""
+	if end < len(entries) && len(page) > 0 {
+		next = page[len(page)-1].cursor()
+	}
+	return page, next, time.Unix(snap.BuiltAt, 0), nil
+}
+
+// LeaderboardRank is the 1-based position of the first entry after
+// cursor, for numbering a page.
+func LeaderboardRank(cursor string) int {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	after, ok := parseCursor(cursor)
+	if !ok {
+		return 1
+	}
+	entries := lb.snap.Entries
+	return sort.Search(len(entries), func(i int) bool { return after.less(entries[i]) }) + 1
+}
+
+// InvalidateLeaderboard forces the next read to rebuild the board, e.g.
+// after new summaries were written.
+func InvalidateLeaderboard() {
+	lb.mu.Lock()
+	lb.fetched = time.Time{}
+	lb.dirty = true
+	lb.mu.Unlock()
+}
+
+// leaderboard returns the cached board, reloading the stored snapshot or
+// rebuilding it from the daily summaries once it is older than
+// config.LeaderboardRefresh.
+func leaderboard() (leaderboardSnapshot, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if time.Since(lb.fetched) < config.LeaderboardRefresh {
+		return lb.snap, nil
+	}
+
+	var stored leaderboardSnapshot
+	if !lb.dirty {
+		// Another server (or a previous run) may have built it recently
+		if err := withRef("leaderboard", func(ref *db.Ref) error { return ref.Get(context.Background(), &stored) }); err == nil &&
+			time.Since(time.Unix(stored.BuiltAt, 0)) < config.LeaderboardRefresh {
+			lb.snap, lb.fetched = stored, time.Now()
+			return lb.snap, nil
+		}
+	}
+
+	snap, err := buildLeaderboard()
+	if err != nil {
+		if lb.snap.Entries != nil {
+			// Serve the stale board rather than nothing
+			log.Printf("Leaderboard: rebuild failed, serving cached: %v", err)
+			return lb.snap, nil
+		}
+		return snap, err
+	}
+	if err := writeRef("leaderboard", func(ref *db.Ref) error { return ref.Set(context.Background(), snap) }); err != nil {
+		log.Printf("Leaderboard: could not store snapshot: %v", err)
+	}
+	lb.snap, lb.fetched, lb.dirty = snap, time.Now(), false
+	return lb.snap, nil
+}
+
+// buildLeaderboard totals every daily summary, and stall penalties.
+// Summaries are small and there is one per day, so this stays cheap
+// however many players exist.
+func buildLeaderboard() (leaderboardSnapshot, error) {
+	var daily map[string]Summary
+	if err := withRef("stats/daily", func(ref *db.Ref) error { return ref.Get(context.Background(), &daily) }); err != nil {
+		return leaderboardSnapshot{}, err
+	}
+
+	// Oldest day first, so the most recent name a player used wins
+	days := make([]string, 0, len(daily))
+	for day := range daily {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	totals := make(map[string]LeaderboardEntry)
+	for _, day := range days {
+		for id, t := range daily[day].Players {
+			e := totals[id]
+			e.ID = id
+			e.Name = t.Name
+			e.Wins += t.Wins
+			e.Losses += t.Losses
+			e.Draws += t.Draws
+			totals[id] = e
+		}
+	}
+
+	// Stalling in ranked games costs extra losses, see stall.go
+	penalties, err := stallPenalties()
+	if err != nil {
+		return leaderboardSnapshot{}, err
+	}
+	for id, n := range penalties {
+		if e, ok := totals[id]; ok {
+			e.Losses += n * config.StallPenalty
+			totals[id] = e
+		}
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(totals))
+	for _, e := range totals {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].less(entries[j]) })
+	return leaderboardSnapshot{Entries: entries, BuiltAt: time.Now().Unix()}, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ErrMaintenance is returned for new rooms and new games while the server
+// is in maintenance mode.
+var ErrMaintenance = fmt.Errorf("the server is going down for maintenance, no new games for now")
+
+// Maintenance is the maintenance flag. While it is set, new connections
+// are turned away, games in progress show a countdown to Start, and no new
+// games can be started.
+type Maintenance struct {
+	Start   int64  `json:"start"` // when the server goes down, unix seconds
+	Message string `json:"message"`
+	By      string `json:"by"`
+}
+
+// Remaining is how long until the server goes down, never negative.
+func (mt Maintenance) Remaining() time.Duration {
+	return max(time.Until(time.Unix(mt.Start, 0)), 0)
+}
+
+// maintenancePoll is how often RunMaintenanceWatch re-reads the flag.
+const maintenancePoll = 10 * time.Second
+
+var maintenance atomic.Pointer[Maintenance]
+
+// CurrentMaintenance returns the maintenance flag as last read, or nil
+// when the server is running normally. It never touches the database.
+func CurrentMaintenance() *Maintenance {
+	return maintenance.Load()
+}
+
+// GetMaintenance reads the maintenance flag from the store.
+func GetMaintenance() (*Maintenance, error) {
+	var mt *Maintenance
+	if err := withRef("maintenance", func(ref *db.Ref) error { return ref.Get(context.Background(), &mt) }); err != nil {
+		return nil, err
+	}
+	return mt, nil
+}
+
+// SetMaintenance turns maintenance mode on, going down after the given
+// delay, or off when mt is nil. The change goes on the moderation audit
+// trail.
+func SetMaintenance(adminID string, mt *Maintenance) error {
+	ctx := context.Background()
+	err := withRef("maintenance", func(ref *db.Ref) error {
+		if mt == nil {
+			return ref.Delete(ctx)
+		}
+		return ref.Set(ctx, mt)
+	})
+	if err != nil {
+		return err
+	}
+	maintenance.Store(mt)
+
+	action := "maintenance on"
+	if mt == nil {
+		action = "maintenance off"
+	}
+	entry := AuditEntry{Admin: adminID, Action: action, At: time.Now().Unix()}
+	return withRef("moderation/audit", func(ref *db.Ref) error {
+		_, err := ref.Push(ctx, entry)
+		return err
+	})
+}
+
+// RunMaintenanceWatch keeps CurrentMaintenance up to date, so every server
+// sharing the store picks up the flag. It never returns.
+func RunMaintenanceWatch() {
+	for {
+		mt, err := GetMaintenance()
+		if err != nil {
+			log.Printf("Maintenance: reading flag failed: %v", err)
+		} else {
+			if (mt == nil) != (maintenance.Load() == nil) {
+				log.Printf("Maintenance: flag changed, active=%v", mt != nil)
+			}
+			maintenance.Store(mt)
+		}
+		time.Sleep(maintenancePoll)
+	}
+}
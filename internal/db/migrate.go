@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Stored records carry a schemaVersion. Reads upgrade old records in
+// memory (and write the upgrade back), and `termplay migrate` upgrades
+// everything at once. To change a record's shape, append a migration to
+// its list; the current version is always the length of the list.
+
+// migration upgrades a record from version to-1 to version to. Records
+// are handled as generic JSON so a migration can see fields the current
+// structs no longer have. Migrations must be safe to run twice.
+type migration struct {
+	to   int
+	desc string
+	up   func(rec map[string]interface{})
+}
+
+var roomMigrations = []migration{
+	{1, "board cells as strings, default game type", func(rec map[string]interface{}) {
+		if board, ok := rec["board"].([]interface{}); ok {
+			for i, v := range board {
+				if _, ok := v.(string); !ok {
+					board[i] = " "
+				}
+			}
+		}
+		if gt, _ := rec["gameType"].(string); gt == "" {
+			rec["gameType"] = "tictactoe"
+		}
+	}},
+}
+
+var profileMigrations = []migration{
+	{1, "fill in settings defaults", func(rec map[string]interface{}) {
+		settings, _ := rec["settings"].(map[string]interface{})
+		if settings == nil {
+			settings = make(map[string]interface{})
+			rec["settings"] = settings
+		}
+		def := DefaultSettings()
+		for key, val := range map[string]string{"theme": def.Theme, "keybindings": def.Keybindings, "locale": def.Locale} {
+			if s, _ := settings[key].(string); s == "" {
+				settings[key] = val
+			}
+		}
+	}},
+}
+
+var (
+	RoomSchemaVersion    = len(roomMigrations)
+	ProfileSchemaVersion = len(profileMigrations)
+)
+
+func schemaVersion(rec map[string]interface{}) int {
+	v, _ := rec["schemaVersion"].(float64)
+	return int(v)
+}
+
+// migrate applies every migration newer than the record's version and
+// reports whether anything ran.
+func migrate(rec map[string]interface{}, ms []migration) bool {
+	from := schemaVersion(rec)
+	if from >= len(ms) {
+		return false
+	}
+	for _, m := range ms[from:] {
+		m.up(rec)
+		rec["schemaVersion"] = m.to
+	}
+	return true
+}
+
+// decodeRecord converts a generic record into one of the typed structs.
+func decodeRecord(rec map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// upgradeRecord migrates the record at path in place. It is a transaction
+// so a concurrent write is never overwritten with the stale copy.
+func upgradeRecord(path string, ms []migration) (bool, error) {
+	changed := false
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var rec map[string]interface{}
+		if err := tn.Unmarshal(&rec); err != nil {
+			return nil, err
+		}
+		changed = rec != nil && migrate(rec, ms)
+		return rec, nil
+	}
+	err := writeRef(path, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) })
+	return changed, err
+}
+
+// upgradeLater writes a lazily migrated record back without holding up
+// the read that found it.
+func upgradeLater(path string, ms []migration) {
+	go func() {
+		if _, err := upgradeRecord(path, ms); err != nil {
+			log.Printf("Migrate: %s: %v", path, err)
+		}
+	}()
+}
+
+// MigrateAll eagerly upgrades every room and profile, returning how many
+// records were changed.
+func MigrateAll() (rooms, profiles int, err error) {
+	for _, c := range []struct {
+		path  string
+		ms    []migration
+		count *int
+	}{
+		{"rooms", roomMigrations, &rooms},
+		{"profiles", profileMigrations, &profiles},
+	} {
+		var keys map[string]json.RawMessage
+		if err := withRef(c.path, func(ref *db.Ref) error { return ref.Get(context.Background(), &keys) }); err != nil {
+			return rooms, profiles, err
+		}
+		for key := range keys {
+			changed, err := upgradeRecord(c.path+"/"+key, c.ms)
+			if err != nil {
+				return rooms, profiles, err
+			}
+			if changed {
+				*c.count++
+			}
+		}
+	}
+	return rooms, profiles, nil
+}
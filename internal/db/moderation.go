@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Report is an entry in the moderation queue: either a player reporting
+// their opponent, or a chat message flagged automatically.
+type Report struct {
+	ID         string        `json:"-"`
+	Kind       string        `json:"kind"` // "report" or "flag"
+	Reporter   string        `json:"reporter"`
+	Target     string        `json:"target"`
+	TargetName string        `json:"targetName"`
+	Room       string        `json:"room"`
+	Reason     string        `json:"reason"`
+	Transcript []ChatMessage `json:"transcript"` // recent chat for context
+	At         int64         `json:"at"`
+}
+
+// AuditEntry records a moderation decision. The report it settled is
+// kept inline so the trail stands on its own after the queue is cleared.
+type AuditEntry struct {
+	Admin  string `json:"admin"`
+	Action string `json:"action"` // "warn", "mute", "ban", "dismiss", "feature", "unfeature", "maintenance on", "maintenance off", "min protocol N" or "poll tuning ..."
+	Target string `json:"target"`
+	Report Report `json:"report"`
+	At     int64  `json:"at"`
+}
+
+// FileReport adds a report to the moderation queue.
+func FileReport(r Report) error {
+	r.At = time.Now().Unix()
+	return writeRef("moderation/queue", func(ref *db.Ref) error {
+		_, err := ref.Push(context.Background(), r)
+		return err
+	})
+}
+
+// GetOpenReports returns the moderation queue, oldest first.
+func GetOpenReports() ([]Report, error) {
+	var queue map[string]Report
+	if err := withRef("moderation/queue", func(ref *db.Ref) error { return ref.Get(context.Background(), &queue) }); err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(queue))
+	for id, r := range queue {
+		r.ID = id
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].At < reports[j].At })
+	return reports, nil
+}
+
+// Moderate applies an admin's decision to the reported player, writes it
+// to the audit trail and removes the report from the queue.
+func Moderate(adminID string, r Report, action string) error {
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	var update map[string]interface{}
+	switch action {
+	case "warn":
+		update = map[string]interface{}{"warning": r.Reason}
+	case "mute":
+		update = map[string]interface{}{"chatMuted": true}
+	case "ban":
+		update = map[string]interface{}{"banned": true}
+	case "dismiss":
+	default:
+		return fmt.Errorf("unknown moderation action %q", action)
+	}
+	if update != nil && r.Target != "" {
+		update["updatedAt"] = now
+		if err := writeRef("profiles/"+r.Target, func(ref *db.Ref) error { return ref.Update(ctx, update) }); err != nil {
+			return err
+		}
+	}
+
+	entry := AuditEntry{Admin: adminID, Action: action, Target: r.Target, Report: r, At: now}
+	if err := withRef("moderation/audit", func(ref *db.Ref) error {
+		_, err := ref.Push(ctx, entry)
+		return err
+	}); err != nil {
+		return err
+	}
+	return writeRef("moderation/queue/"+r.ID, func(ref *db.Ref) error { return ref.Delete(ctx) })
+}
+
+// ClearWarning marks a moderator's warning as seen by the player.
+func ClearWarning(id string) error {
+	return writeRef("profiles/"+id, func(ref *db.Ref) error {
+		return ref.Update(context.Background(), map[string]interface{}{"warning": ""})
+	})
+}
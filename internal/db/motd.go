@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// motd is the message of the day set with `termplay motd`. It overrides
+// config.MOTDFile, so an announcement can go out without a redeploy.
+type motd struct {
+	Text string `json:"text"`
+	At   int64  `json:"at"`
+}
+
+// GetMOTD returns the stored message of the day, or "" if none is set.
+func GetMOTD() (string, error) {
+	var m motd
+	if err := withRef("motd", func(ref *db.Ref) error { return ref.Get(context.Background(), &m) }); err != nil {
+		return "", err
+	}
+	return m.Text, nil
+}
+
+// SetMOTD stores the message of the day, or clears it when text is "".
+func SetMOTD(text string) error {
+	ctx := context.Background()
+	return withRef("motd", func(ref *db.Ref) error {
+		if text == "" {
+			return ref.Delete(ctx)
+		}
+		return ref.Set(ctx, motd{Text: text, At: time.Now().Unix()})
+	})
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ErrNameTaken is returned when a name is reserved by another player.
+var ErrNameTaken = fmt.Errorf("that name is reserved by another player")
+
+// NameTag is a short tag derived from a player ID, shown after names
+// ("sam#4f2a") so two players with the same name can be told apart. It is
+// the same on every server and every connection with the same key.
+func NameTag(pid string) string {
+	sum := sha256.Sum256([]byte(pid))
+	return hex.EncodeToString(sum[:2])
+}
+
+// nameKey is the /names key for a name. Reservations ignore case and
+// surrounding space, and the name is hex-encoded because player names
+// may contain characters Firebase keys can't.
+func nameKey(name string) string {
+	return hex.EncodeToString([]byte(strings.ToLower(strings.TrimSpace(name))))
+}
+
+// SameName reports whether a and b are the same name for reservation
+// purposes.
+func SameName(a, b string) bool {
+	return nameKey(a) == nameKey(b)
+}
+
+// NameOwner returns the ID of the player who reserved name, or "".
+func NameOwner(name string) (string, error) {
+	var owner string
+	err := withRef("names/"+nameKey(name), func(ref *db.Ref) error { return ref.Get(context.Background(), &owner) })
+	return owner, err
+}
+
+// ReserveName makes name unique to pid, releasing any name pid reserved
+// before. Only players with an SSH key should reserve names; without one
+// their ID changes with every connection.
+func ReserveName(pid, name string) error {
+	ctx := context.Background()
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var owner string
+		if err := tn.Unmarshal(&owner); err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != pid {
+			return nil, ErrNameTaken
+		}
+		return pid, nil
+	}
+	if err := writeRef("names/"+nameKey(name), func(ref *db.Ref) error { return ref.Transaction(ctx, fn) }); err != nil {
+		return err
+	}
+
+	p, err := GetProfile(pid)
+	if err != nil {
+		return err
+	}
+	if p.ReservedName != "" && !SameName(p.ReservedName, name) {
+		old := "names/" + nameKey(p.ReservedName)
+		if err := writeRef(old, func(ref *db.Ref) error { return ref.Delete(ctx) }); err != nil {
+			return err
+		}
+	}
+	return writeRef("profiles/"+pid, func(ref *db.Ref) error {
+		return ref.Update(ctx, map[string]interface{}{
+			"id":           pid,
+			"reservedName": strings.TrimSpace(name),
+			"updatedAt":    time.Now().Unix(),
+		})
+	})
+}
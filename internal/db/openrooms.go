@@ -0,0 +1,32 @@
+package db
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aminshahid573/termplay/internal/bus"
+)
+
+// OpenRoomsTopic is the bus topic public rooms are pushed on while they
+// wait for an opponent: when they are created, and whenever a write
+// leaves their seat open. Players watching for an open room listen to it.
+const OpenRoomsTopic = "open-rooms"
+
+// IsOpen reports whether r is a public room waiting for an opponent.
+func (r Room) IsOpen() bool {
+	return r.IsPublic && r.PlayerX != "" && r.PlayerO == "" && r.Status == "waiting"
+}
+
+// publishOpenRoom pushes r on OpenRoomsTopic, if it is open.
+func publishOpenRoom(r Room) {
+	if !r.IsOpen() {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := bus.Default.Publish(OpenRoomsTopic, data); err != nil {
+		log.Printf("Bus: publish %s failed: %v", OpenRoomsTopic, err)
+	}
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Settings are per-player preferences. They live on the profile so they
+// follow the player's SSH key from one connection to the next.
+type Settings struct {
+	Theme        string `json:"theme"`
+	Keybindings  string `json:"keybindings"` // "default" (arrows/hjkl) or "wasd"
+	ReduceMotion bool   `json:"reduceMotion"`
+	BellOnTurn   bool   `json:"bellOnTurn"`
+	ASCII        bool   `json:"ascii"`
+	Locale       string `json:"locale"`
+	ConfirmMove  bool   `json:"confirmMove"` // first Enter marks a move, second commits it
+	Mark         string `json:"mark"`        // tic-tac-toe glyph shown for this player, "" for X/O
+	MarkColor    string `json:"markColor"`   // color of that glyph, "" for the side's own
+
+	// Low-bandwidth mode: "on", "off", or "" to switch it on when the
+	// link turns out to be slow
+	LowBandwidth string `json:"lowBandwidth"`
+
+	// Where to be told it's their move in a correspondence game, see
+	// package notify. Empty for no alerts.
+	TurnNotify string `json:"turnNotify"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Theme:       "default",
+		Keybindings: "default",
+		Locale:      "en",
+	}
+}
+
+// Profile is everything remembered about a player, keyed by session ID
+// (the sanitized SSH key fingerprint).
+type Profile struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Settings  Settings `json:"settings"`
+	UpdatedAt int64    `json:"updatedAt"`
+
+	TutorialDone bool            `json:"tutorialDone"`
+	Puzzles      map[string]bool `json:"puzzles"`      // puzzle ID -> solved
+	Badges       map[string]bool `json:"badges"`       // see badges.go
+	ReservedName string          `json:"reservedName"` // see names.go
+	Keys         map[string]bool `json:"keys"`         // other keys linked to this profile, see accounts.go
+	Charm        string          `json:"charm"`        // Charm ID signed in with, see accounts.go
+
+	// Set from the admin console (see moderation.go)
+	Warning   string `json:"warning"` // shown once on next login
+	ChatMuted bool   `json:"chatMuted"`
+	Banned    bool   `json:"banned"`
+
+	Stalls StallRecord `json:"stalls"` // see stall.go
+
+	SchemaVersion int `json:"schemaVersion"` // see migrate.go
+}
+
+// GetProfile loads a player's profile. First-time players get a fresh
+// profile with default settings; nothing is written until they change
+// something.
+func GetProfile(id string) (*Profile, error) {
+	var rec map[string]interface{}
+	if err := withRef("profiles/"+id, func(ref *db.Ref) error { return ref.Get(context.Background(), &rec) }); err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return &Profile{ID: id, Settings: DefaultSettings(), SchemaVersion: ProfileSchemaVersion}, nil
+	}
+	if migrate(rec, profileMigrations) {
+		upgradeLater("profiles/"+id, profileMigrations)
+	}
+	var p Profile
+	if err := decodeRecord(rec, &p); err != nil {
+		return nil, err
+	}
+	if p.ID == "" {
+		p.ID = id
+	}
+	return &p, nil
+}
+
+// GuestPrefix starts the ID of a player who connected without an SSH key.
+// Such an ID changes with every connection, so nothing is kept for guests
+// and their games are unranked.
+const GuestPrefix = "guest-"
+
+// IsGuest reports whether id belongs to a guest.
+func IsGuest(id string) bool {
+	return strings.HasPrefix(id, GuestPrefix)
+}
+
+// updateProfile sets fields on a player's profile. Guests have none, so
+// for them it does nothing.
+func updateProfile(id string, fields map[string]interface{}) error {
+	if IsGuest(id) {
+		return nil
+	}
+	fields["id"] = id
+	fields["updatedAt"] = time.Now().Unix()
+	return writeRef("profiles/"+id, func(ref *db.Ref) error {
+		return ref.Update(context.Background(), fields)
+	})
+}
+
+func SaveSettings(id string, s Settings) error {
+	return updateProfile(id, map[string]interface{}{"settings": s})
+}
+
+func MarkTutorialDone(id string) error {
+	return updateProfile(id, map[string]interface{}{"tutorialDone": true})
+}
+
+func MarkPuzzleSolved(id, puzzleID string) error {
+	return updateProfile(id, map[string]interface{}{"puzzles/" + puzzleID: true})
+}
+
+// SaveProfileName remembers the last name a player used so it can be
+// pre-filled next time.
+func SaveProfileName(id, name string) error {
+	return updateProfile(id, map[string]interface{}{"name": name})
+}
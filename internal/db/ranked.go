@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Ranked rooms count towards the leaderboard, so they are played straight:
+// every move is on config.RankedMoveTime, and no bot or handicap may
+// join in. Casual rooms are archived as such and left out of the stats.
+//
+// A move only reaches the server after the player's keystroke crossed
+// their link, so the side to move gets their round-trip time, as
+// reported with SetLag and capped at config.LagGraceMax, on top of the
+// clock before they are out of time.
+var (
+	ErrRanked = fmt.Errorf("ranked rooms can't have a handicap or a bot")
+	ErrTimeUp = fmt.Errorf("out of time for this move")
+)
+
+// MoveTime is the clock on each move in r: config.RankedMoveTime in a
+// ranked game, config.CorrespondenceMoveTime in a correspondence one, and
+// 0 if moves aren't timed.
+func (r Room) MoveTime() time.Duration {
+	switch {
+	case r.Correspondence:
+		return config.CorrespondenceMoveTime
+	case r.Ranked && config.RankedMoveTime > 0:
+		return config.RankedMoveTime
+	}
+	return 0
+}
+
+// MoveDeadline is when the side to move in a ranked or correspondence
+// game runs out of time, or the zero time if no clock is running. It does
+// not include the lag grace; see Grace.
+func (r Room) MoveDeadline() time.Time {
+	if r.Status != "playing" || r.TurnAt == 0 || r.MoveTime() <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(r.TurnAt, 0).Add(r.MoveTime())
+}
+
+// Grace is the extra time the side to move gets for their lag.
+func (r Room) Grace() time.Duration {
+	pid := r.PlayerX
+	if r.Turn == "O" || r.Turn == "Black" {
+		pid = r.PlayerO
+	}
+	g := time.Duration(r.Lag[pid]) * time.Millisecond
+	if g > config.LagGraceMax {
+		g = config.LagGraceMax
+	}
+	if g < 0 {
+		g = 0
+	}
+	return g
+}
+
+// timeUp reports whether the side to move has run out of time, grace
+// included.
+func (r Room) timeUp() bool {
+	d := r.MoveDeadline()
+	return !d.IsZero() && time.Now().After(d.Add(r.Grace()))
+}
+
+// SetLag records pid's measured round-trip time in the room, for the lag
+// grace on their moves. It is a side note to the room, so it neither
+// bumps Seq nor goes out to the other players.
+func SetLag(code, pid string, rtt time.Duration) error {
+	return writeRef("rooms/"+code+"/lag/"+pid, func(ref *db.Ref) error {
+		return ref.Set(context.Background(), rtt.Milliseconds())
+	})
+}
+
+// sideOf returns the seat ("X" or "O") pid plays in, or "".
+func (r Room) sideOf(pid string) string {
+	switch pid {
+	case r.PlayerX:
+		return "X"
+	case r.PlayerO:
+		return "O"
+	}
+	return ""
+}
+
+// sideToMove returns the seat ("X" or "O") whose turn it is.
+func (r Room) sideToMove() string {
+	if r.GameType == "chess" {
+		return map[string]string{"White": "X", "Black": "O"}[r.Turn]
+	}
+	return r.Turn
+}
+
+// ClaimTimeout ends a ranked game in pid's favor once their opponent has
+// let the clock run out. r is the room state the claim was made against,
+// as for UpdateMove.
+func ClaimTimeout(code, pid string, r Room) error {
+	var final Room
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var cur Room
+		if err := tn.Unmarshal(&cur); err != nil {
+			return nil, err
+		}
+		if cur.Seq != r.Seq {
+			return nil, ErrStaleMove
+		}
+		side, toMove := cur.sideOf(pid), cur.sideToMove()
+		if side == "" || side == toMove {
+			return nil, fmt.Errorf("only the waiting player can claim a win on time")
+		}
+		if !cur.timeUp() {
+			// The move landed first
+			return nil, ErrStaleMove
+		}
+
+		now := time.Now().Unix()
+		cur.spendThinking(cur.playerOn(toMove), now)
+		cur.Status = "finished"
+		cur.Flagged = toMove
+		cur.Games++
+		if side == "X" {
+			cur.WinsX++
+		} else {
+			cur.WinsO++
+		}
+		cur.Streak = addStreak(cur.Streak, side)
+		cur.Winner = side
+		if cur.GameType == "chess" {
+			cur.Winner = map[string]string{"X": "White", "O": "Black"}[side]
+			cur.ChessState.Status = "finished"
+			cur.ChessState.Winner = cur.Winner
+		}
+		cur.UpdatedAt = now
+		cur.EndedAt = now
+		cur.stamp()
+		final = cur
+		return cur, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: "timeout claim: " + err.Error(), Seq: r.Seq})
+		return err
+	}
+	log.Printf("Room %s: %s lost on time", code, final.Flagged)
+	LogRoomEvent(code, RoomEvent{Kind: "timeout", Player: pid, Detail: final.Flagged + " out of time", Seq: final.Seq})
+	publishRoom(code, final)
+	archiveGame(final, "finished")
+	if final.Ranked {
+		go recordStall(map[string]string{"X": final.PlayerX, "O": final.PlayerO}[final.Flagged], code, "out of time")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/metrics"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// RoomEvent is one entry in a room's event log, kept so reports like "my
+// move disappeared" can be traced afterwards. Logs live under
+// /roomlog/<UTC day>/<room code>, so whole days can be dropped once they
+// are older than config.RoomLogDays without reading them first.
+type RoomEvent struct {
+	At     int64  `json:"at"`     // unix milliseconds
+	Kind   string `json:"kind"`   // "create", "join", "spectate", "leave", "bot", "kick", "move", "swap", "timeout", "restart", "reject", "delete", "away", "stall", "forfeit", "open", "drop"
+	Player string `json:"player"` // who caused it, "" for the server
+	Detail string `json:"detail"`
+	Seq    int64  `json:"seq"`    // room Seq after the event, or the one a rejected write was made against
+	Server string `json:"server"` // build that logged it, see metrics.BuildVersion
+}
+
+// LogRoomEvent appends e to the log of room code. Like the archive, it
+// only logs failures: the event log must never get in the way of play.
+func LogRoomEvent(code string, e RoomEvent) {
+	if config.RoomLogDays <= 0 || code == "" {
+		return
+	}
+	now := time.Now()
+	e.At = now.UnixMilli()
+	e.Server = metrics.BuildVersion()
+	path := "roomlog/" + now.UTC().Format("2006-01-02") + "/" + code
+	if err := writeRef(path, func(ref *db.Ref) error {
+		_, err := ref.Push(context.Background(), e)
+		return err
+	}); err != nil {
+		log.Printf("Room log: room %s: %v", code, err)
+	}
+}
+
+// GetRoomLog returns the events logged for room code over the last
+// config.RoomLogDays days, oldest first. Room codes get reused, so the
+// log may cover more than one room by that code.
+func GetRoomLog(code string) ([]RoomEvent, error) {
+	var events []RoomEvent
+	today := time.Now().UTC()
+	for i := config.RoomLogDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		var logged map[string]RoomEvent
+		if err := withRef("roomlog/"+day+"/"+code, func(ref *db.Ref) error { return ref.Get(context.Background(), &logged) }); err != nil {
+			return nil, err
+		}
+		for _, e := range logged {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+	return events, nil
+}
+
+// roomLogSweep is how many days past the retention the nightly trim
+// looks back, covering nights the server was down.
+const roomLogSweep = 7
+
+// trimRoomLogs drops the room logs of days past config.RoomLogDays.
+func trimRoomLogs() {
+	if config.RoomLogDays <= 0 {
+		return
+	}
+	oldest := time.Now().UTC().AddDate(0, 0, -config.RoomLogDays)
+	for i := 0; i < roomLogSweep; i++ {
+		day := oldest.AddDate(0, 0, -i).Format("2006-01-02")
+		if err := writeRef("roomlog/"+day, func(ref *db.Ref) error { return ref.Delete(context.Background()) }); err != nil {
+			log.Printf("Room log: trimming %s: %v", day, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Rules are the options a host picks when creating a room. Handicap and
+// PieRule are for tic-tac-toe only; chess rooms ignore them.
+type Rules struct {
+	Handicap string // see handicap.go
+	PieRule  bool   // O may take over X's opening instead of answering it
+	Ranked   bool   // see ranked.go
+
+	Correspondence bool // days-long game, see correspondence.go
+}
+
+// pieSwap is the entry SwapSides adds to Moves, so the archive shows the
+// swap and it can only happen once per game.
+const pieSwap = "swap"
+
+// CanSwap reports whether the pie rule lets O swap sides now: right
+// after X's first move of the game, and never for the bot.
+func (r Room) CanSwap() bool {
+	return r.PieRule && r.GameType != "chess" && r.Status == "playing" &&
+		r.Turn == "O" && len(r.Moves) == 1 && r.PlayerO != BotID && r.PlayerX != BotID
+}
+
+// SwapSides applies the pie rule: instead of replying to X's opening, O
+// takes it over. The players trade seats, and with them the series score
+// and hosting the room, and the opener moves next as O. r is the room
+// state the player was looking at, as for UpdateMove.
+func SwapSides(code, pid string, r Room) error {
+	var final Room
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		var cur Room
+		if err := tn.Unmarshal(&cur); err != nil {
+			return nil, err
+		}
+		if cur.Seq != r.Seq || !cur.CanSwap() {
+			return nil, ErrStaleMove
+		}
+		if cur.PlayerO != pid {
+			return nil, fmt.Errorf("only the second player can swap")
+		}
+		cur.PlayerX, cur.PlayerO = cur.PlayerO, cur.PlayerX
+		cur.PlayerXName, cur.PlayerOName = cur.PlayerOName, cur.PlayerXName
+		cur.WinsX, cur.WinsO = cur.WinsO, cur.WinsX
+		cur.Streak = -cur.Streak
+		cur.Moves = append(cur.Moves, pieSwap)
+		cur.UpdatedAt = time.Now().Unix()
+		cur.spendThinking(pid, cur.UpdatedAt)
+		cur.TurnAt = cur.UpdatedAt
+		cur.stamp()
+		final = cur
+		return cur, nil
+	}
+	if err := writeRef("rooms/"+code, func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		LogRoomEvent(code, RoomEvent{Kind: "reject", Player: pid, Detail: "swap: " + err.Error(), Seq: r.Seq})
+		return err
+	}
+	log.Printf("Room %s: %s swapped sides", code, final.PlayerXName)
+	LogRoomEvent(code, RoomEvent{Kind: "swap", Player: pid, Seq: final.Seq})
+	publishRoom(code, final)
+	return nil
+}
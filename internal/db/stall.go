@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Stalling is running out a ranked game rather than losing it: letting
+// the clock expire, or walking away from a lost position. Each time is
+// recorded in the player's profile, and config.StallLimit of them within
+// config.StallWindow cost config.StallPenalty losses on the leaderboard
+// and a config.StallLockout break from ranked rooms.
+
+// ErrStalling turns a player away from ranked rooms while they serve a
+// stall lockout.
+var ErrStalling = fmt.Errorf("you are barred from ranked games for stalling, casual rooms are still open")
+
+// stallMaterialGap is how far behind in material a chess player must be
+// for leaving to count as walking away from a lost game.
+const stallMaterialGap = 3
+
+// StallRecord is a player's history of stalling, kept in their profile.
+type StallRecord struct {
+	Recent      []int64 `json:"recent"`      // when each stall within config.StallWindow happened
+	Penalties   int     `json:"penalties"`   // how many times the player was penalized
+	LockedUntil int64   `json:"lockedUntil"` // no ranked games before this
+}
+
+// Lockout returns ErrStalling, with the time left, while the player is
+// serving a lockout, and nil otherwise.
+func (s StallRecord) Lockout() error {
+	left := time.Until(time.Unix(s.LockedUntil, 0))
+	if s.LockedUntil == 0 || left <= 0 {
+		return nil
+	}
+	return fmt.Errorf("%w (%s left)", ErrStalling, left.Round(time.Minute))
+}
+
+// rankedLockout is the Lockout of pid's profile.
+func rankedLockout(pid string) error {
+	if IsGuest(pid) {
+		return nil
+	}
+	p, err := GetProfile(pid)
+	if err != nil {
+		// Never block play on a failed read
+		return nil
+	}
+	return p.Stalls.Lockout()
+}
+
+// losing reports whether side ("X" or "O") stands to lose r's game: for
+// tic-tac-toe, against perfect play; for chess, by being
+// stallMaterialGap or more behind in material.
+func (r Room) losing(side string) bool {
+	if r.GameType == "chess" {
+		white := side == "X"
+		b := r.ChessState.Board
+		return chess.Material(b, !white)-chess.Material(b, white) >= stallMaterialGap
+	}
+	return tictactoe.Losing(r.Board, tictactoe.ParseCell(side), tictactoe.ParseCell(r.sideToMove()))
+}
+
+// walksAway reports whether pid leaving r now is stalling: a ranked game
+// under way that they are losing.
+func (r Room) walksAway(pid string) bool {
+	side := r.sideOf(pid)
+	return r.Ranked && r.Status == "playing" && len(r.Moves) > 0 && side != "" && r.losing(side)
+}
+
+// recordStall notes a stall by pid in room code, and penalizes them if it
+// is one too many. Failures are only logged.
+func recordStall(pid, code, why string) {
+	if config.StallLimit <= 0 || pid == "" || pid == BotID || IsGuest(pid) {
+		return
+	}
+	now := time.Now()
+	var rec StallRecord
+	var penalized bool
+	fn := func(tn db.TransactionNode) (interface{}, error) {
+		rec, penalized = StallRecord{}, false
+		if err := tn.Unmarshal(&rec); err != nil {
+			return nil, err
+		}
+		cutoff := now.Add(-config.StallWindow).Unix()
+		var recent []int64
+		for _, t := range rec.Recent {
+			if t > cutoff {
+				recent = append(recent, t)
+			}
+		}
+		rec.Recent = append(recent, now.Unix())
+		if len(rec.Recent) >= config.StallLimit {
+			rec.Recent = nil
+			rec.Penalties++
+			rec.LockedUntil = now.Add(config.StallLockout).Unix()
+			penalized = true
+		}
+		return rec, nil
+	}
+	if err := writeRef("profiles/"+pid+"/stalls", func(ref *db.Ref) error { return ref.Transaction(context.Background(), fn) }); err != nil {
+		log.Printf("Stalls: %s: %v", pid, err)
+		return
+	}
+	LogRoomEvent(code, RoomEvent{Kind: "stall", Player: pid, Detail: why})
+	if !penalized {
+		return
+	}
+	log.Printf("Stalls: %s penalized (%d so far), no ranked games until %s", pid, rec.Penalties, time.Unix(rec.LockedUntil, 0).Format(time.RFC3339))
+	// Mirrored outside the profile so the leaderboard needs one read for
+	// everyone's penalties
+	if err := writeRef("penalties/"+pid, func(ref *db.Ref) error { return ref.Set(context.Background(), rec.Penalties) }); err != nil {
+		log.Printf("Stalls: %s: %v", pid, err)
+		return
+	}
+	InvalidateLeaderboard()
+}
+
+// stallPenalties returns every player's penalty count.
+func stallPenalties() (map[string]int, error) {
+	var penalties map[string]int
+	err := withRef("penalties", func(ref *db.Ref) error { return ref.Get(context.Background(), &penalties) })
+	return penalties, err
+}
@@ -0,0 +1,372 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// PlayerTally is one player's results within a summary period.
+type PlayerTally struct {
+	Name   string `json:"name"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Draws  int    `json:"draws"`
+}
+
+// Summary rolls up the archived games of one day or ISO week. Summaries
+// are written once by the aggregator, for days that are over, and read by
+// the leaderboard and the admin stats screen, so neither has to scan the
+// raw archive.
+type Summary struct {
+	Period        string                 `json:"period"` // "2006-01-02" or "2006-W01"
+	Games         int                    `json:"games"`
+	Abandoned     int                    `json:"abandoned"`
+	UniquePlayers int                    `json:"uniquePlayers"`
+	AvgDuration   int64                  `json:"avgDuration"`   // seconds, over games with a known start
+	DurationCount int64                  `json:"durationCount"` // games AvgDuration is over
+	ByGame        map[string]int         `json:"byGame"`
+	Players       map[string]PlayerTally `json:"players"` // ranked results only
+
+	// Everyone who played, ranked or not, guests included
+	Participants map[string]bool `json:"participants"`
+
+	AggregatedAt int64 `json:"aggregatedAt"` // when a daily summary was stored
+
+	durationSum int64
+}
+
+func newSummary(period string) Summary {
+	return Summary{
+		Period:       period,
+		ByGame:       make(map[string]int),
+		Players:      make(map[string]PlayerTally),
+		Participants: make(map[string]bool),
+	}
+}
+
+// add counts one archived game into the summary.
+func (s *Summary) add(g ArchivedGame) {
+	s.Games++
+	s.ByGame[g.GameType]++
+	if g.Result == "abandoned" {
+		s.Abandoned++
+	}
+	if g.StartedAt > 0 && g.EndedAt >= g.StartedAt {
+		s.durationSum += g.EndedAt - g.StartedAt
+		s.DurationCount++
+	}
+	for _, id := range []string{g.PlayerX, g.PlayerO} {
+		if id != "" && id != BotID {
+			s.Participants[id] = true
+		}
+	}
+
+	if g.Casual || IsGuest(g.PlayerX) || IsGuest(g.PlayerO) {
+		// Unranked: a guest's results can't follow them, and wins over
+		// throwaway guests shouldn't climb the board either
+		return
+	}
+	xWon := g.Winner == "X" || g.Winner == "White"
+	oWon := g.Winner == "O" || g.Winner == "Black"
+	draw := g.Winner == "Draw"
+	s.tally(g.PlayerX, g.PlayerXName, xWon, oWon, draw)
+	s.tally(g.PlayerO, g.PlayerOName, oWon, xWon, draw)
+}
+
+func (s *Summary) tally(id, name string, won, lost, draw bool) {
+	if id == "" || id == BotID {
+		return
+	}
+	t := s.Players[id]
+	t.Name = name
+	switch {
+	case won:
+		t.Wins++
+	case lost:
+		t.Losses++
+	case draw:
+		t.Draws++
+	}
+	s.Players[id] = t
+}
+
+// merge folds a daily summary into a weekly one.
+func (s *Summary) merge(d Summary) {
+	s.Games += d.Games
+	s.Abandoned += d.Abandoned
+	for k, v := range d.ByGame {
+		s.ByGame[k] += v
+	}
+	// Daily averages are re-weighted by the games they are over
+	s.durationSum += d.AvgDuration * d.DurationCount
+	s.DurationCount += d.DurationCount
+	for id := range d.Participants {
+		s.Participants[id] = true
+	}
+	for id, t := range d.Players {
+		cur := s.Players[id]
+		cur.Name = t.Name
+		cur.Wins += t.Wins
+		cur.Losses += t.Losses
+		cur.Draws += t.Draws
+		s.Players[id] = cur
+	}
+}
+
+func (s *Summary) finish() {
+	s.UniquePlayers = len(s.Participants)
+	if s.DurationCount > 0 {
+		s.AvgDuration = s.durationSum / s.DurationCount
+	}
+}
+
+// pushKeyPrefix is the first 8 characters of a Firebase push ID created at
+// t. Push IDs sort by creation time, so archive entries between two times
+// can be selected by key without a database index.
+func pushKeyPrefix(t time.Time) string {
+	const chars = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+	ms := t.UnixMilli()
+	var b [8]byte
+	for i := 7; i >= 0; i-- {
+		b[i] = chars[ms%64]
+		ms /= 64
+	}
+	return string(b[:])
+}
+
+// pushKeyTime is when the Firebase push ID key was created.
+func pushKeyTime(key string) time.Time {
+	const chars = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+	var ms int64
+	for i := 0; i < 8 && i < len(key); i++ {
+		ms = ms*64 + int64(strings.IndexByte(chars, key[i]))
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+// utcDay is the start of the UTC day t falls on.
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AggregateDay summarizes the games archived on the given UTC day, and
+// stores the summary. The day must be over: a summary stored while games
+// are still being played would be served as final.
+func AggregateDay(day time.Time) (Summary, error) {
+	start := utcDay(day)
+	if !start.Before(utcDay(time.Now())) {
+		return Summary{}, fmt.Errorf("%s is not over yet", start.Format("2006-01-02"))
+	}
+	s, err := summarizeDay(start)
+	if err != nil {
+		return s, err
+	}
+	s.AggregatedAt = time.Now().Unix()
+	err = writeRef("stats/daily/"+s.Period, func(ref *db.Ref) error { return ref.Set(context.Background(), s) })
+	return s, err
+}
+
+// summarizeDay summarizes the games archived on the given UTC day.
+func summarizeDay(day time.Time) (Summary, error) {
+	start := utcDay(day)
+	end := start.AddDate(0, 0, 1)
+	s := newSummary(start.Format("2006-01-02"))
+
+	var nodes []db.QueryNode
+	err := withRef("archive", func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().StartAt(pushKeyPrefix(start)).EndAt(pushKeyPrefix(end)).GetOrdered(context.Background())
+		return err
+	})
+	if err != nil {
+		return s, err
+	}
+	for _, n := range nodes {
+		var g ArchivedGame
+		if err := n.Unmarshal(&g); err == nil {
+			s.add(g)
+		}
+	}
+	s.finish()
+	return s, nil
+}
+
+// AggregateWeek rolls the daily summaries of the ISO week containing day
+// into a weekly summary. Missing days are aggregated first.
+func AggregateWeek(day time.Time) (Summary, error) {
+	day = utcDay(day)
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	year, week := monday.ISOWeek()
+	s := newSummary(fmt.Sprintf("%d-W%02d", year, week))
+
+	for i := 0; i < 7; i++ {
+		d, err := GetDailySummary(monday.AddDate(0, 0, i))
+		if err != nil {
+			return s, err
+		}
+		s.merge(*d)
+	}
+	s.finish()
+
+	err := writeRef("stats/weekly/"+s.Period, func(ref *db.Ref) error { return ref.Set(context.Background(), s) })
+	return s, err
+}
+
+// GetDailySummary returns the stored summary for a day, aggregating it
+// on the spot if the nightly job has not covered it yet. Today, or any
+// day not over yet, is summarized as it stands and not stored.
+func GetDailySummary(day time.Time) (*Summary, error) {
+	if !utcDay(day).Before(utcDay(time.Now())) {
+		s, err := summarizeDay(day)
+		if err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	period := day.UTC().Format("2006-01-02")
+	var s Summary
+	if err := withRef("stats/daily/"+period, func(ref *db.Ref) error { return ref.Get(context.Background(), &s) }); err != nil {
+		return nil, err
+	}
+	if !s.final() {
+		agg, err := AggregateDay(day)
+		if err != nil {
+			return nil, err
+		}
+		s = agg
+	}
+	return &s, nil
+}
+
+// final reports whether a stored daily summary was made after its day was
+// over. One that isn't, or that predates AggregatedAt, is made again.
+func (s Summary) final() bool {
+	day, err := time.Parse("2006-01-02", s.Period)
+	return err == nil && s.AggregatedAt >= day.AddDate(0, 0, 1).Unix()
+}
+
+// GetWeeklySummaries returns up to n of the most recent weekly summaries,
+// newest first.
+func GetWeeklySummaries(n int) ([]Summary, error) {
+	var nodes []db.QueryNode
+	err := withRef("stats/weekly", func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().LimitToLast(n).GetOrdered(context.Background())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Summary, 0, len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		var s Summary
+		if err := nodes[i].Unmarshal(&s); err == nil {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+// catchUpDays stores a summary for every day that is over but has none
+// yet, from the day after the last stored summary, or from the first
+// archived game, through yesterday, so days the aggregator was down for
+// still reach the leaderboard. Weeks ended among them are rolled up too.
+// It returns how many days it stored.
+func catchUpDays() (int, error) {
+	from, err := firstMissingDay()
+	if err != nil || from.IsZero() {
+		return 0, err
+	}
+	today := utcDay(time.Now())
+	n := 0
+	for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+		s, err := AggregateDay(day)
+		if err != nil {
+			// Left for the next run, so no day is skipped
+			return n, fmt.Errorf("%s: %v", day.Format("2006-01-02"), err)
+		}
+		log.Printf("Stats: %s: %d games, %d players", s.Period, s.Games, s.UniquePlayers)
+		n++
+		if day.Weekday() == time.Sunday {
+			if _, err := AggregateWeek(day); err != nil {
+				log.Printf("Stats: weekly aggregation for %s failed: %v", day.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return n, nil
+}
+
+// firstMissingDay is the day after the last stored daily summary, or
+// that day itself if it was stored before it was over; with none stored,
+// the day of the first archived game. It is zero if there is nothing to
+// summarize.
+func firstMissingDay() (time.Time, error) {
+	var nodes []db.QueryNode
+	err := withRef("stats/daily", func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().LimitToLast(1).GetOrdered(context.Background())
+		return err
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(nodes) > 0 {
+		last, err := time.Parse("2006-01-02", nodes[0].Key())
+		if err != nil {
+			return time.Time{}, err
+		}
+		var s Summary
+		if err := nodes[0].Unmarshal(&s); err == nil && !s.final() {
+			return last, nil
+		}
+		return last.AddDate(0, 0, 1), nil
+	}
+
+	err = withRef("archive", func(ref *db.Ref) error {
+		var err error
+		nodes, err = ref.OrderByKey().LimitToFirst(1).GetOrdered(context.Background())
+		return err
+	})
+	if err != nil || len(nodes) == 0 {
+		return time.Time{}, err
+	}
+	return utcDay(pushKeyTime(nodes[0].Key())), nil
+}
+
+// RunStatsAggregator summarizes every day that is over and not yet
+// summarized, at start and shortly after every UTC midnight, and the week
+// just ended every Monday. It never returns.
+func RunStatsAggregator() {
+	if n, err := catchUpDays(); err != nil {
+		log.Printf("Stats: catching up failed: %v", err)
+	} else if n > 0 {
+		InvalidateLeaderboard()
+	}
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 0, 10, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(next.Sub(now))
+
+		yesterday := next.AddDate(0, 0, -1)
+		if _, err := catchUpDays(); err != nil {
+			log.Printf("Stats: daily aggregation failed: %v", err)
+		} else {
+			InvalidateLeaderboard()
+			awardMilestones()
+		}
+		trimLobbyChat(lobbyChatKeep)
+		trimRoomLogs()
+		if next.Day() == 1 {
+			awardSeasonChampion(yesterday)
+		}
+	}
+}
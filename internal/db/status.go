@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// ServerStatus is the store-wide part of the server status screen.
+type ServerStatus struct {
+	Rooms      int // open rooms, waiting or playing
+	Playing    int // rooms with a game in progress
+	GamesToday int // games archived since UTC midnight
+
+	ByState map[RoomState]int // rooms by game type and status, for the metrics endpoint
+}
+
+// RoomState is a game type and a room status ("waiting", "playing" or
+// "finished").
+type RoomState struct {
+	GameType, Status string
+}
+
+// statusTTL is how long a ServerStatus is reused. Counting means reading
+// every room and today's archive, so a screen refreshing every couple of
+// seconds must not trigger a scan each time.
+const statusTTL = 15 * time.Second
+
+var status struct {
+	mu      sync.Mutex
+	s       ServerStatus
+	fetched time.Time
+}
+
+// GetServerStatus returns the room and game counts, at most statusTTL old.
+func GetServerStatus() (ServerStatus, error) {
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	if time.Since(status.fetched) < statusTTL {
+		return status.s, nil
+	}
+
+	rooms, err := getRawRooms()
+	if err != nil {
+		return status.s, err
+	}
+	s := ServerStatus{ByState: make(map[RoomState]int)}
+	for code, r := range rooms {
+		s.Rooms++
+		if r.Status == "playing" {
+			s.Playing++
+		}
+		clean := sanitizeRoom(code, r)
+		s.ByState[RoomState{clean.GameType, clean.Status}]++
+	}
+
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var today map[string]json.RawMessage
+	err = withRef("archive", func(ref *db.Ref) error {
+		// Push keys sort by creation time, so today's games are the keys
+		// from midnight on
+		return ref.OrderByKey().StartAt(pushKeyPrefix(midnight)).Get(context.Background(), &today)
+	})
+	if err != nil {
+		return status.s, err
+	}
+	s.GamesToday = len(today)
+
+	status.s = s
+	status.fetched = time.Now()
+	return s, nil
+}
@@ -0,0 +1,30 @@
+package db
+
+// addStreak extends a series' Streak with the result of a game: side is
+// the winner, "X" or "O", or "" for a draw, which ends any streak.
+func addStreak(streak int, side string) int {
+	switch {
+	case side == "X" && streak > 0:
+		return streak + 1
+	case side == "X":
+		return 1
+	case side == "O" && streak < 0:
+		return streak - 1
+	case side == "O":
+		return -1
+	}
+	return 0
+}
+
+// StreakOf returns the side on a winning streak in the series, and how
+// many games in a row it has won, or "" and 0 if the last game was a draw
+// or none has been played.
+func (r Room) StreakOf() (string, int) {
+	switch {
+	case r.Streak > 0:
+		return "X", r.Streak
+	case r.Streak < 0:
+		return "O", -r.Streak
+	}
+	return "", 0
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	db "firebase.google.com/go/v4/db"
+)
+
+// Tuning overrides the poll cadence of config.SyncInterval,
+// config.PollActiveInterval and config.PollIdleInterval on every server
+// sharing the store, without a restart: e.g. to slow polling down while
+// the database is under strain. Zero fields keep the configured value.
+type Tuning struct {
+	Sync   int64  `json:"sync"`   // ms, see config.SyncInterval
+	Active int64  `json:"active"` // ms, see config.PollActiveInterval
+	Idle   int64  `json:"idle"`   // ms, see config.PollIdleInterval
+	By     string `json:"by"`
+	At     int64  `json:"at"`
+}
+
+// PollIntervals is the poll cadence in force.
+type PollIntervals struct {
+	Sync   time.Duration // while it is the player's move
+	Active time.Duration // while waiting on the opponent, or watching
+	Idle   time.Duration // in the lobby and after a game
+}
+
+// Poll interval overrides are kept within these bounds, so a slip of the
+// finger can neither hammer the store nor freeze every game.
+const (
+	minPollInterval = 50 * time.Millisecond
+	maxPollInterval = time.Minute
+)
+
+// tuningPoll is how often RunTuningWatch re-reads the overrides.
+const tuningPoll = 30 * time.Second
+
+var tuning atomic.Pointer[Tuning]
+
+// Intervals returns the poll cadence in force, with the tuning as last
+// read. It never touches the database.
+func Intervals() PollIntervals {
+	return tuning.Load().Intervals()
+}
+
+// Intervals returns config's poll cadence with the overrides of t on top.
+// A nil t overrides nothing.
+func (t *Tuning) Intervals() PollIntervals {
+	iv := PollIntervals{Sync: config.SyncInterval, Active: config.PollActiveInterval, Idle: config.PollIdleInterval}
+	if t == nil {
+		return iv
+	}
+	override := func(d *time.Duration, ms int64) {
+		if ms > 0 {
+			*d = clampPoll(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	override(&iv.Sync, t.Sync)
+	override(&iv.Active, t.Active)
+	override(&iv.Idle, t.Idle)
+	return iv
+}
+
+func clampPoll(d time.Duration) time.Duration {
+	return min(max(d, minPollInterval), maxPollInterval)
+}
+
+// CurrentTuning returns the overrides as last read, or nil when the
+// configured cadence is in force.
+func CurrentTuning() *Tuning {
+	return tuning.Load()
+}
+
+// Scaled is the tuning that runs every interval of iv factor times as
+// long, within bounds, by adminID.
+func (iv PollIntervals) Scaled(factor float64, adminID string) *Tuning {
+	ms := func(d time.Duration) int64 {
+		return clampPoll(time.Duration(float64(d) * factor)).Milliseconds()
+	}
+	return &Tuning{Sync: ms(iv.Sync), Active: ms(iv.Active), Idle: ms(iv.Idle), By: adminID, At: time.Now().Unix()}
+}
+
+// GetTuning reads the overrides, nil when none are set.
+func GetTuning() (*Tuning, error) {
+	var t *Tuning
+	if err := withRef("tuning", func(ref *db.Ref) error { return ref.Get(context.Background(), &t) }); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetTuning sets the overrides, or removes them when t is nil. The change
+// goes on the moderation audit trail.
+func SetTuning(adminID string, t *Tuning) error {
+	ctx := context.Background()
+	err := withRef("tuning", func(ref *db.Ref) error {
+		if t == nil {
+			return ref.Delete(ctx)
+		}
+		return ref.Set(ctx, t)
+	})
+	if err != nil {
+		return err
+	}
+	tuning.Store(t)
+
+	action := "poll tuning cleared"
+	if t != nil {
+		iv := t.Intervals()
+		action = fmt.Sprintf("poll tuning %s/%s/%s", iv.Sync, iv.Active, iv.Idle)
+	}
+	entry := AuditEntry{Admin: adminID, Action: action, At: time.Now().Unix()}
+	return withRef("moderation/audit", func(ref *db.Ref) error {
+		_, err := ref.Push(ctx, entry)
+		return err
+	})
+}
+
+// RunTuningWatch keeps Intervals up to date, so every server sharing the
+// store picks up the overrides. It never returns.
+func RunTuningWatch() {
+	for {
+		t, err := GetTuning()
+		if err != nil {
+			log.Printf("Tuning: reading overrides failed: %v", err)
+		} else {
+			was := tuning.Load()
+			tuning.Store(t)
+			if (t == nil) != (was == nil) || (t != nil && *t != *was) {
+				iv := Intervals()
+				log.Printf("Tuning: polling every %s on your move, %s waiting, %s idle", iv.Sync, iv.Active, iv.Idle)
+			}
+		}
+		time.Sleep(tuningPoll)
+	}
+}
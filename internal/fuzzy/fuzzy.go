@@ -0,0 +1,121 @@
+// Package fuzzy matches search queries the way fzf does: the characters
+// of each word of the query must appear in the text in order, but not
+// necessarily next to each other, and matches are scored so that tight
+// matches at the start of words come first.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scoring, after fzf's: every matched character earns scoreMatch, more
+// if it starts a word or follows the previous match, and gaps between
+// matched characters cost.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusCamel       = 7
+	bonusConsecutive = 4
+	bonusFirstChar   = 2 // multiplier for the bonus of the first character
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// Match scores text against query, ignoring case. Each space-separated
+// word of query must match on its own. pos holds the rune indexes of the
+// matched characters in text, in order. An empty query matches anything
+// with score 0.
+func Match(query, text string) (score int, pos []int, ok bool) {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	seen := map[int]bool{}
+	for _, word := range strings.Fields(query) {
+		w := []rune(word)
+		for i, r := range w {
+			w[i] = unicode.ToLower(r)
+		}
+		s, p, ok := matchWord(w, runes, lower)
+		if !ok {
+			return 0, nil, false
+		}
+		score += s
+		for _, i := range p {
+			if !seen[i] {
+				seen[i] = true
+				pos = append(pos, i)
+			}
+		}
+	}
+	sort.Ints(pos)
+	return score, pos, true
+}
+
+// matchWord finds the shortest window of text holding word in order: the
+// first match scanning forward fixes its end, and scanning back from
+// there fixes its start.
+func matchWord(word, runes, lower []rune) (int, []int, bool) {
+	if len(word) == 0 {
+		return 0, nil, true
+	}
+	end, w := -1, 0
+	for i := 0; i < len(lower) && w < len(word); i++ {
+		if lower[i] == word[w] {
+			w++
+			end = i
+		}
+	}
+	if w < len(word) {
+		return 0, nil, false
+	}
+	pos := make([]int, len(word))
+	w = len(word) - 1
+	for i := end; i >= 0 && w >= 0; i-- {
+		if lower[i] == word[w] {
+			pos[w] = i
+			w--
+		}
+	}
+	return score(runes, pos), pos, true
+}
+
+func score(runes []rune, pos []int) int {
+	total := 0
+	for k, i := range pos {
+		s := scoreMatch
+		b := bonusAt(runes, i)
+		if k == 0 {
+			b *= bonusFirstChar
+		}
+		s += b
+		if k > 0 {
+			if gap := i - pos[k-1] - 1; gap == 0 {
+				s += bonusConsecutive
+			} else {
+				s -= penaltyGapStart + penaltyGapExtend*(gap-1)
+			}
+		}
+		total += s
+	}
+	return total
+}
+
+// bonusAt is the bonus for a match at runes[i]: at the start of the text
+// or of a word, or at a lower-to-upper case change.
+func bonusAt(runes []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, cur := runes[i-1], runes[i]
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	}
+	return 0
+}
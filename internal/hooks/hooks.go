@@ -0,0 +1,125 @@
+// Package hooks lets integrators attach their own behavior to game
+// lifecycle events, such as logging them elsewhere, handing out rewards
+// or sending notifications, without touching the update loop. Register
+// handlers from an init function in a file of your own in cmd/server:
+//
+//	func init() {
+//		hooks.OnGameFinished(func(e hooks.GameFinished) {
+//			log.Info("Game over", "room", e.Room, "winner", e.Winner)
+//		})
+//	}
+//
+// Each handler runs in a goroutine of its own, after the event has been
+// stored, so a slow handler never holds up play. A handler that panics is
+// logged and otherwise ignored.
+package hooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// RoomCreated is a room that was just opened.
+type RoomCreated struct {
+	Code     string
+	GameType string // "tictactoe" or "chess"
+	Host     string // player ID
+	HostName string
+	Public   bool
+	Ranked   bool
+	At       time.Time
+}
+
+// GameFinished is a game that ended, whether played out or abandoned.
+type GameFinished struct {
+	Room        string
+	GameType    string
+	PlayerX     string // player IDs; X is White in chess
+	PlayerXName string
+	PlayerO     string
+	PlayerOName string
+	Winner      string // X/O or White/Black, "Draw", or "" if abandoned
+	Result      string // "finished" or "abandoned"
+	Moves       int
+	Ranked      bool
+	StartedAt   time.Time
+	EndedAt     time.Time
+}
+
+// PlayerConnected is a session that just started on this server.
+type PlayerConnected struct {
+	SessionID string // the player ID
+	Addr      string // remote host:port
+	User      string // SSH user name
+	Guest     bool   // connected without an SSH key
+	At        time.Time
+}
+
+var (
+	mu              sync.RWMutex
+	roomCreated     []func(RoomCreated)
+	gameFinished    []func(GameFinished)
+	playerConnected []func(PlayerConnected)
+)
+
+// OnRoomCreated calls fn for every room created on this server.
+func OnRoomCreated(fn func(RoomCreated)) {
+	mu.Lock()
+	defer mu.Unlock()
+	roomCreated = append(roomCreated, fn)
+}
+
+// OnGameFinished calls fn for every game that ends on this server.
+func OnGameFinished(fn func(GameFinished)) {
+	mu.Lock()
+	defer mu.Unlock()
+	gameFinished = append(gameFinished, fn)
+}
+
+// OnPlayerConnected calls fn for every session started on this server.
+func OnPlayerConnected(fn func(PlayerConnected)) {
+	mu.Lock()
+	defer mu.Unlock()
+	playerConnected = append(playerConnected, fn)
+}
+
+// FireRoomCreated passes e to the handlers registered for it.
+func FireRoomCreated(e RoomCreated) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, fn := range roomCreated {
+		run("room created", func() { fn(e) })
+	}
+}
+
+// FireGameFinished passes e to the handlers registered for it.
+func FireGameFinished(e GameFinished) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, fn := range gameFinished {
+		run("game finished", func() { fn(e) })
+	}
+}
+
+// FirePlayerConnected passes e to the handlers registered for it.
+func FirePlayerConnected(e PlayerConnected) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, fn := range playerConnected {
+		run("player connected", func() { fn(e) })
+	}
+}
+
+// run calls a handler in the background, logging it if it panics.
+func run(event string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("Hook panicked", "event", event, "err", r)
+			}
+		}()
+		fn()
+	}()
+}
@@ -0,0 +1,113 @@
+// Package logfile writes server logs to a file that rotates itself, so a
+// long-running server keeps its history without filling the disk. The
+// file is moved aside, to its name plus the time, once it grows too big
+// or too old, and moved-aside files are removed after a while.
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stampFormat is appended to the name of a rotated file.
+const stampFormat = "2006-01-02T15-04-05.000"
+
+// File is an io.Writer onto a rotating log file. It is safe for
+// concurrent use.
+type File struct {
+	path    string
+	maxSize int64         // rotate before growing past this; 0 never
+	every   time.Duration // rotate once the file is this old; 0 never
+	maxAge  time.Duration // remove rotated files this old; 0 never
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// Open appends to the log file at path, rotating it as configured.
+func Open(path string, maxSize int64, every, maxAge time.Duration) (*File, error) {
+	l := &File{path: path, maxSize: maxSize, every: every, maxAge: maxAge}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	go l.prune()
+	return l, nil
+}
+
+func (l *File) open() error {
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size, l.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past its
+// limits. A line is never split across two files.
+func (l *File) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.due(len(p)) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+func (l *File) due(n int) bool {
+	if l.size == 0 {
+		return false
+	}
+	return l.maxSize > 0 && l.size+int64(n) > l.maxSize ||
+		l.every > 0 && time.Since(l.opened) >= l.every
+}
+
+// rotate moves the file aside and starts a new one.
+func (l *File) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, l.path+"."+time.Now().Format(stampFormat)); err != nil {
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	go l.prune()
+	return nil
+}
+
+// prune removes rotated files older than maxAge.
+func (l *File) prune() {
+	if l.maxAge <= 0 {
+		return
+	}
+	old, _ := filepath.Glob(l.path + ".*")
+	for _, name := range old {
+		stamp := name[len(l.path)+1:]
+		if t, err := time.ParseInLocation(stampFormat, stamp, time.Local); err == nil && time.Since(t) > l.maxAge {
+			os.Remove(name)
+		}
+	}
+}
+
+// Close closes the current file.
+func (l *File) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
@@ -0,0 +1,148 @@
+// Package metrics holds process-wide counters that operators and the
+// admin screens can read without touching the database.
+package metrics
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// DBReinits counts how often the database client was re-created after
+	// an authentication failure.
+	DBReinits atomic.Int64
+
+	// DBCalls counts database round-trips.
+	DBCalls atomic.Int64
+
+	// dbLatency averages database round-trip times.
+	dbLatency Latency
+)
+
+// Latency is a moving average of round-trip times. The zero value is
+// ready to use, and a nil *Latency reads as zero.
+type Latency struct {
+	avg atomic.Int64 // nanoseconds
+}
+
+// Observe records one round-trip.
+func (l *Latency) Observe(d time.Duration) {
+	for {
+		old := l.avg.Load()
+		next := int64(d)
+		if old != 0 {
+			// Weight the newest sample at 1/8 so one slow trip doesn't
+			// swing the reading
+			next = old + (int64(d)-old)/8
+		}
+		if l.avg.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Get is the current average, or zero before the first sample.
+func (l *Latency) Get() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return time.Duration(l.avg.Load())
+}
+
+// Throughput is a moving average of how fast a client takes the output
+// sent to it, in bytes a second. Writes to an SSH channel only block once
+// the client's window is full, i.e. once the link has fallen behind, so
+// only those writes are measured; a link that keeps up reads as zero, as
+// does one that hasn't fallen behind for a while. The zero value is ready
+// to use, and a nil *Throughput reads as zero.
+type Throughput struct {
+	rate atomic.Int64 // bytes a second
+	at   atomic.Int64 // unix nanoseconds of the last sample
+
+	sent atomic.Int64 // bytes written, blocked or not
+}
+
+// throughputStale is how long a Throughput reading lasts without samples.
+const throughputStale = 30 * time.Second
+
+// Observe records a write of n bytes that blocked for d.
+func (t *Throughput) Observe(n int, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	rate := int64(float64(n) / d.Seconds())
+	stale := time.Since(time.Unix(0, t.at.Load())) > throughputStale
+	for {
+		old := t.rate.Load()
+		next := rate
+		if old != 0 && !stale {
+			next = old + (rate-old)/8
+		}
+		if t.rate.CompareAndSwap(old, next) {
+			break
+		}
+	}
+	t.at.Store(time.Now().UnixNano())
+}
+
+// Get is the current average, or zero if the link has kept up lately.
+func (t *Throughput) Get() int64 {
+	if t == nil || time.Since(time.Unix(0, t.at.Load())) > throughputStale {
+		return 0
+	}
+	return t.rate.Load()
+}
+
+// AddSent counts n bytes written to the client.
+func (t *Throughput) AddSent(n int) {
+	t.sent.Add(int64(n))
+}
+
+// Sent is how many bytes have been written to the client in all.
+func (t *Throughput) Sent() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.sent.Load()
+}
+
+// ObserveDB records how long one database round-trip took.
+func ObserveDB(d time.Duration) {
+	DBCalls.Add(1)
+	dbLatency.Observe(d)
+}
+
+// DBLatency is the moving average round-trip time of database calls, or
+// zero before the first call.
+func DBLatency() time.Duration {
+	return dbLatency.Get()
+}
+
+// Started is when the process started.
+var Started = time.Now()
+
+// Sessions counts players connected to this server right now.
+var Sessions atomic.Int64
+
+// Version is the release this binary was built from, set at build time
+// with -ldflags "-X github.com/aminshahid573/termplay/internal/metrics.Version=...".
+var Version = "dev"
+
+// BuildVersion is Version, followed by the VCS revision Go embedded in
+// the binary when Version wasn't set.
+func BuildVersion() string {
+	if Version != "dev" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Version
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) >= 7 {
+			return Version + " (" + s.Value[:7] + ")"
+		}
+	}
+	return Version
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gameBuckets are the upper bounds, in seconds, of the game duration
+// histogram: from a quick tic-tac-toe game to a correspondence one.
+var gameBuckets = []float64{30, 60, 120, 300, 600, 1800, 3600, 6 * 3600, 24 * 3600, 7 * 24 * 3600}
+
+// histogram counts observations into gameBuckets.
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// gameKey labels the game metrics.
+type gameKey struct {
+	game, result string
+}
+
+// games holds the games that ended on this server since it started.
+var games struct {
+	sync.Mutex
+	ended     map[gameKey]uint64
+	durations map[string]*histogram // by game type
+}
+
+// ObserveGame records a game of gameType that ended with result
+// ("finished" or "abandoned") after running for d. d is zero when the
+// start of the game isn't known, and only the count is kept then.
+func ObserveGame(gameType, result string, d time.Duration) {
+	games.Lock()
+	defer games.Unlock()
+	if games.ended == nil {
+		games.ended = make(map[gameKey]uint64)
+		games.durations = make(map[string]*histogram)
+	}
+	games.ended[gameKey{gameType, result}]++
+	if d <= 0 || result != "finished" {
+		return
+	}
+	h := games.durations[gameType]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(gameBuckets)+1)}
+		games.durations[gameType] = h
+	}
+	secs := d.Seconds()
+	i := sort.SearchFloat64s(gameBuckets, secs)
+	h.counts[i]++
+	h.sum += secs
+	h.count++
+}
+
+// Sample is one value of a metric, with its labels as name/value pairs.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// WriteGauge writes a gauge in the Prometheus text format.
+func WriteGauge(w io.Writer, name, help string, samples ...Sample) {
+	writeFamily(w, name, help, "gauge", samples)
+}
+
+func writeFamily(w io.Writer, name, help, kind string, samples []Sample) {
+	sortSamples(samples)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %g\n", name, labels(s.Labels...), s.Value)
+	}
+}
+
+// labels formats name/value pairs as {name="value",...}.
+func labels(kv ...string) string {
+	if len(kv) == 0 {
+		return ""
+	}
+	var parts []string
+	for i := 0; i+1 < len(kv); i += 2 {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(kv[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, kv[i], v))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// WriteProcess writes this server's own metrics in the Prometheus text
+// format: sessions, database calls and the games that ended here.
+func WriteProcess(w io.Writer) {
+	WriteGauge(w, "termplay_sessions", "Players connected to this server.", Sample{Value: float64(Sessions.Load())})
+	WriteGauge(w, "termplay_uptime_seconds", "Seconds since this server started.", Sample{Value: time.Since(Started).Seconds()})
+	writeFamily(w, "termplay_db_calls_total", "Database round-trips made by this server.", "counter",
+		[]Sample{{Value: float64(DBCalls.Load())}})
+	writeFamily(w, "termplay_db_reinits_total", "Database client re-creations after authentication failures.", "counter",
+		[]Sample{{Value: float64(DBReinits.Load())}})
+	WriteGauge(w, "termplay_db_latency_seconds", "Moving average database round-trip time.", Sample{Value: DBLatency().Seconds()})
+
+	games.Lock()
+	defer games.Unlock()
+	var ended []Sample
+	for k, n := range games.ended {
+		ended = append(ended, Sample{Labels: []string{"game", k.game, "result", k.result}, Value: float64(n)})
+	}
+	writeFamily(w, "termplay_games_ended_total", "Games that ended on this server, by game type and result.", "counter", ended)
+
+	const name = "termplay_game_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s How long finished games ran, by game type.\n# TYPE %s histogram\n", name, name)
+	types := make([]string, 0, len(games.durations))
+	for t := range games.durations {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		h := games.durations[t]
+		var cum uint64
+		for i, le := range gameBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("game", t, "le", fmt.Sprintf("%g", le)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("game", t, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %g\n", name, labels("game", t), h.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels("game", t), h.count)
+	}
+}
+
+// sortSamples orders samples by their labels, so scrapes read the same
+// from one to the next.
+func sortSamples(s []Sample) {
+	sort.Slice(s, func(i, j int) bool {
+		return strings.Join(s[i].Labels, "\x00") < strings.Join(s[j].Labels, "\x00")
+	})
+}
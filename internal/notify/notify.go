@@ -0,0 +1,183 @@
+// Package notify tells players away from the server that a correspondence
+// game is waiting on their move. A player picks one endpoint in settings:
+//
+//	https://example.com/hook   a webhook, sent a JSON POST
+//	ntfy:my-topic              a topic on config.NtfyServer
+//	me@example.com             an email, through config.SMTPAddr
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+)
+
+// Turn is a correspondence game that just became a player's move.
+type Turn struct {
+	Room     string    `json:"room"`
+	GameType string    `json:"gameType"`
+	Opponent string    `json:"opponent"`
+	Deadline time.Time `json:"deadline"`
+	Test     bool      `json:"test,omitempty"` // sent from settings to try the endpoint
+}
+
+// Text is the alert as one line, e.g. "Your move against bob in room
+// K7QP (chess). Play by Tue 14:05 UTC or lose on time."
+func (t Turn) Text() string {
+	if t.Test {
+		return "termplay turn alerts work: you'll hear here when a correspondence game is waiting on you."
+	}
+	return fmt.Sprintf("Your move against %s in room %s (%s). Play by %s or lose on time.",
+		t.Opponent, t.Room, t.GameType, t.Deadline.UTC().Format("Mon 15:04 MST"))
+}
+
+const (
+	kindWebhook = "webhook"
+	kindNtfy    = "ntfy"
+	kindEmail   = "email"
+)
+
+// parse works out what kind of endpoint e is, and where it leads.
+func parse(e string) (kind, target string, err error) {
+	e = strings.TrimSpace(e)
+	switch {
+	case strings.HasPrefix(e, "ntfy:"):
+		topic := strings.TrimPrefix(e, "ntfy:")
+		if topic == "" || strings.ContainsAny(topic, "/?# ") {
+			return "", "", fmt.Errorf("ntfy topic must be a single word, e.g. ntfy:alice-termplay")
+		}
+		return kindNtfy, topic, nil
+	case strings.HasPrefix(e, "https://"), strings.HasPrefix(e, "http://"):
+		u, err := url.Parse(e)
+		if err != nil || u.Host == "" {
+			return "", "", fmt.Errorf("not a valid webhook URL")
+		}
+		return kindWebhook, u.String(), nil
+	case strings.Contains(e, "@"):
+		addr, err := mail.ParseAddress(strings.TrimPrefix(e, "mailto:"))
+		if err != nil {
+			return "", "", fmt.Errorf("not a valid email address")
+		}
+		if config.SMTPAddr == "" {
+			return "", "", fmt.Errorf("this server can't send email; use a webhook or ntfy:topic")
+		}
+		return kindEmail, addr.Address, nil
+	}
+	return "", "", fmt.Errorf("use a webhook URL, ntfy:topic or an email address")
+}
+
+// Validate reports what is wrong with endpoint e, if anything.
+func Validate(e string) error {
+	_, _, err := parse(e)
+	return err
+}
+
+// Send delivers t to endpoint e.
+func Send(e string, t Turn) error {
+	kind, target, err := parse(e)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case kindNtfy:
+		return sendNtfy(target, t)
+	case kindEmail:
+		return sendEmail(target, t)
+	}
+	return sendWebhook(target, t)
+}
+
+func sendWebhook(u string, t Turn) error {
+	body, err := json.Marshal(struct {
+		Event   string `json:"event"`
+		Message string `json:"message"`
+		Turn
+	}{"your_turn", t.Text(), t})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(webhookClient, req)
+}
+
+func sendNtfy(topic string, t Turn) error {
+	req, err := http.NewRequest(http.MethodPost, config.NtfyServer+"/"+topic, strings.NewReader(t.Text()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", "Your move in termplay")
+	req.Header.Set("Tags", "hourglass")
+	return do(ntfyClient, req)
+}
+
+func sendEmail(to string, t Turn) error {
+	var auth smtp.Auth
+	if config.SMTPUser != "" {
+		host, _, _ := net.SplitHostPort(config.SMTPAddr)
+		auth = smtp.PlainAuth("", config.SMTPUser, config.SMTPPassword, host)
+	}
+	from := config.SMTPFrom
+	if from == "" {
+		from = config.SMTPUser
+	}
+	msg := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: Your move in termplay\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + t.Text() + "\r\n"
+	return smtp.SendMail(config.SMTPAddr, auth, from, []string{to}, []byte(msg))
+}
+
+func do(c *http.Client, req *http.Request) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// ntfyClient reaches config.NtfyServer, which the operator chose, so it
+// may be on their own network.
+var ntfyClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookClient only reaches public addresses, so a player can't point
+// the server at its own network. Redirects are checked the same way, as
+// every connection goes through the dialer.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+					return fmt.Errorf("webhook address %s is not public", host)
+				}
+				return nil
+			},
+		}).DialContext,
+	},
+}
@@ -77,6 +77,10 @@ type TickMsg struct{}
 type Model struct {
 	TermW, TermH int
 
+	// ReduceMotion holds the title and food still; the snake itself
+	// still moves
+	ReduceMotion bool
+
 	// game board
 	snake     []Point
 	dir       Direction
@@ -166,8 +170,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	case TickMsg:
 		// ── advance animation ──
-		m.uiFrame++
-		m.foodAnim = (m.uiFrame / 2) % 4
+		if !m.ReduceMotion {
+			m.uiFrame++
+			m.foodAnim = (m.uiFrame / 2) % 4
+		}
 
 		// ── advance snake (only while playing) ──
 		if m.State == StatePlaying {
@@ -22,6 +22,7 @@ var (
 	ChessSelected    = lipgloss.Color("#66CCFF")
 	ChessBlocked     = lipgloss.Color("#FF3333")
 	ChessCapture     = lipgloss.Color("#FF6666")
+	ChessPending     = lipgloss.Color("#FFA500")
 )
 
 var (
@@ -0,0 +1,133 @@
+package tictactoe
+
+import "math/rand"
+
+// Opponent returns the other player's mark.
+func Opponent(mark Cell) Cell {
+	if mark == X {
+		return O
+	}
+	return X
+}
+
+// EmptyCells lists the indexes of cells nobody has played yet.
+func EmptyCells(b Board) []int {
+	var cells []int
+	for i, v := range b {
+		if v == Empty {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// RandomMove picks any empty cell, or -1 if the board is full.
+func RandomMove(b Board) int {
+	cells := EmptyCells(b)
+	if len(cells) == 0 {
+		return -1
+	}
+	return cells[rand.Intn(len(cells))]
+}
+
+// HeuristicMove plays like a casual human: take a win, block the
+// opponent's win, prefer the center, then a corner, otherwise anything.
+// Returns -1 if the board is full.
+func HeuristicMove(b Board, mark Cell) int {
+	for _, who := range []Cell{mark, Opponent(mark)} {
+		for _, i := range EmptyCells(b) {
+			b[i] = who
+			winner, _ := CheckWinner(b)
+			b[i] = Empty
+			if winner == who {
+				return i
+			}
+		}
+	}
+	if b[4] == Empty {
+		return 4
+	}
+	var corners []int
+	for _, i := range []int{0, 2, 6, 8} {
+		if b[i] == Empty {
+			corners = append(corners, i)
+		}
+	}
+	if len(corners) > 0 {
+		return corners[rand.Intn(len(corners))]
+	}
+	return RandomMove(b)
+}
+
+// Bot difficulty levels as stored on a room.
+const (
+	LevelRandom       = "random"
+	LevelIntermediate = "intermediate"
+	LevelPerfect      = "perfect"
+)
+
+// Levels lists the difficulties in menu order.
+var Levels = []string{LevelRandom, LevelIntermediate, LevelPerfect}
+
+// BotMove picks a move for mark at the given difficulty. Unknown levels
+// play as intermediate. Returns -1 if the board is full.
+func BotMove(level string, b Board, mark Cell) int {
+	switch level {
+	case LevelRandom:
+		return RandomMove(b)
+	case LevelPerfect:
+		return PerfectMove(b, mark)
+	default:
+		return HeuristicMove(b, mark)
+	}
+}
+
+// PerfectMove searches the full game tree with minimax and never loses.
+// Among equally good moves it prefers the quickest win or slowest loss.
+// Returns -1 if the board is full.
+func PerfectMove(b Board, mark Cell) int {
+	best, bestScore := -1, -100
+	for _, i := range EmptyCells(b) {
+		b[i] = mark
+		score := -negamax(b, Opponent(mark), 1)
+		b[i] = Empty
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// negamax scores the board from the point of view of the player to move.
+func negamax(b Board, toMove Cell, depth int) int {
+	if winner, _ := CheckWinner(b); winner != Empty {
+		// The previous mover won; sooner wins score higher for them
+		return depth - 10
+	}
+	if CheckDraw(b) {
+		return 0
+	}
+	best := -100
+	for _, i := range EmptyCells(b) {
+		b[i] = toMove
+		score := -negamax(b, Opponent(toMove), depth+1)
+		b[i] = Empty
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// Losing reports whether mark loses against perfect play from b, with
+// toMove to play next.
+func Losing(b Board, mark, toMove Cell) bool {
+	if winner, _ := CheckWinner(b); winner != Empty {
+		return winner != mark
+	}
+	score := negamax(b, toMove, 1)
+	if toMove == mark {
+		return score < 0
+	}
+	return score > 0
+}
@@ -0,0 +1,96 @@
+package tictactoe
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Cell is one square of the board.
+type Cell uint8
+
+const (
+	Empty Cell = iota
+	X
+	O
+)
+
+// ParseCell reads a mark as stored or displayed ("X", "O"). Anything else
+// is an empty cell.
+func ParseCell(s string) Cell {
+	switch s {
+	case "X", "x":
+		return X
+	case "O", "o":
+		return O
+	}
+	return Empty
+}
+
+// String returns the stored form of the cell: "X", "O" or " ".
+func (c Cell) String() string {
+	switch c {
+	case X:
+		return "X"
+	case O:
+		return "O"
+	}
+	return " "
+}
+
+func (c Cell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON never fails: numbers, nulls and unknown strings left by
+// older clients all decode as Empty.
+func (c *Cell) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		*c = Empty
+		return nil
+	}
+	*c = ParseCell(s)
+	return nil
+}
+
+// Board holds the nine cells in row-major order. The zero value is an
+// empty board.
+type Board [9]Cell
+
+// ParseBoard builds a board from three rows such as "XO ", mostly for
+// writing positions by hand.
+func ParseBoard(rows ...string) Board {
+	var b Board
+	for r, row := range rows {
+		for c, ch := range row {
+			if r < 3 && c < 3 {
+				b[r*3+c] = ParseCell(string(ch))
+			}
+		}
+	}
+	return b
+}
+
+// UnmarshalJSON accepts a list of cells of any length, or the index-keyed
+// object Firebase returns for sparse arrays. Anything else decodes as an
+// empty board rather than an error, so one corrupted room can't break the
+// rooms that are read alongside it.
+func (b *Board) UnmarshalJSON(data []byte) error {
+	*b = Board{}
+
+	var list []Cell
+	if err := json.Unmarshal(data, &list); err == nil {
+		copy(b[:], list)
+		return nil
+	}
+
+	var obj map[string]Cell
+	if err := json.Unmarshal(data, &obj); err == nil {
+		for k, c := range obj {
+			if i, err := strconv.Atoi(k); err == nil && i >= 0 && i < len(b) {
+				b[i] = c
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package tictactoe
+
+// Puzzle is a seeded position where X is to move and at least one move is
+// clearly best. Any index in Solutions counts as solved.
+type Puzzle struct {
+	ID        string
+	Title     string
+	Hint      string
+	Board     Board
+	Solutions []int
+}
+
+var Puzzles = []Puzzle{
+	{
+		ID:    "win-row",
+		Title: "Win in 1",
+		Hint:  "You have two in a row. Finish it.",
+		Board: ParseBoard(
+			"XX ",
+			"OO ",
+			"   ",
+		),
+		Solutions: []int{2},
+	},
+	{
+		ID:    "win-column",
+		Title: "Win in 1: column",
+		Hint:  "Don't get distracted by the diagonal.",
+		Board: ParseBoard(
+			"X  ",
+			" O ",
+			"X O",
+		),
+		Solutions: []int{3},
+	},
+	{
+		ID:    "win-diagonal",
+		Title: "Win in 1: diagonal",
+		Hint:  "Look corner to corner.",
+		Board: ParseBoard(
+			"X O",
+			" XO",
+			"   ",
+		),
+		Solutions: []int{8},
+	},
+	{
+		ID:    "block",
+		Title: "Block",
+		Hint:  "O is one move from winning.",
+		Board: ParseBoard(
+			"OO ",
+			"X  ",
+			" X ",
+		),
+		Solutions: []int{2},
+	},
+	{
+		ID:    "block-the-fork",
+		Title: "Block the fork",
+		Hint:  "A corner looks natural, but it lets O threaten twice.",
+		Board: ParseBoard(
+			"O  ",
+			" X ",
+			"  O",
+		),
+		Solutions: []int{1, 3, 5, 7},
+	},
+	{
+		ID:    "block-and-fork",
+		Title: "Block and fork",
+		Hint:  "Your forced block can threaten twice.",
+		Board: ParseBoard(
+			"X O",
+			" O ",
+			"  X",
+		),
+		Solutions: []int{6},
+	},
+}
+
+// IsSolution reports whether playing idx solves the puzzle.
+func (p Puzzle) IsSolution(idx int) bool {
+	for _, s := range p.Solutions {
+		if s == idx {
+			return true
+		}
+	}
+	return false
+}
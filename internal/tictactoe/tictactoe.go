@@ -1,22 +1,23 @@
 package tictactoe
 
-func CheckWinner(b [9]string) (string, []int) {
+// CheckWinner returns the winning mark and line, or Empty and nil.
+func CheckWinner(b Board) (Cell, []int) {
 	wins := [][]int{
 		{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // Rows
 		{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // Cols
 		{0, 4, 8}, {2, 4, 6}, // Diags
 	}
 	for _, w := range wins {
-		if b[w[0]] != " " && b[w[0]] == b[w[1]] && b[w[1]] == b[w[2]] {
+		if b[w[0]] != Empty && b[w[0]] == b[w[1]] && b[w[1]] == b[w[2]] {
 			return b[w[0]], w
 		}
 	}
-	return "", nil
+	return Empty, nil
 }
 
-func CheckDraw(b [9]string) bool {
+func CheckDraw(b Board) bool {
 	for _, v := range b {
-		if v == " " {
+		if v == Empty {
 			return false
 		}
 	}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LinkState backs linking another SSH key to the player's profile from
+// the settings screen: G makes a code here, E redeems one made elsewhere.
+type LinkState struct {
+	Code     string // made on this machine, for the other one
+	Expires  time.Time
+	Entering bool // typing a code made on another machine
+	Input    textinput.Model
+	Status   string
+}
+
+type linkCodeMsg struct{ code string }
+
+// keyLinkedMsg means this key now plays as account. An unlink is a link
+// back to the key's own ID.
+type keyLinkedMsg struct {
+	account string
+	unlink  bool
+}
+
+func newLinkInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "ABC123"
+	ti.Prompt = "> "
+	ti.CharLimit = 6
+	ti.Width = 8
+	return ti
+}
+
+// updateLinkKeys handles the settings keys for linking. It reports
+// whether it used the key.
+func updateLinkKeys(m Model, msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if m.Link.Entering {
+		switch msg.String() {
+		case "esc":
+			m.Link.Entering = false
+			m.Link.Input.Blur()
+			return m, nil, true
+		case "enter":
+			code := strings.ToUpper(strings.TrimSpace(m.Link.Input.Value()))
+			m.Link.Entering = false
+			m.Link.Input.Blur()
+			if code == "" {
+				return m, nil, true
+			}
+			return m, linkKeyCmd(code, m.KeyID), true
+		}
+		var cmd tea.Cmd
+		m.Link.Input, cmd = m.Link.Input.Update(msg)
+		return m, cmd, true
+	}
+	if !m.HasKey {
+		return m, nil, false
+	}
+	switch msg.String() {
+	case "g":
+		m.Err = nil
+		return m, newLinkCodeCmd(m.SessionID), true
+	case "e", "u":
+		if m.inRoom() || len(m.Tabs) > 0 {
+			// Rooms remember the player by ID; switching under them would strand a seat
+			m = m.fail(fmt.Errorf("leave your rooms before switching accounts"))
+			return m, nil, true
+		}
+		m.Err = nil
+		if msg.String() == "u" {
+			if m.KeyID == m.SessionID {
+				return m, nil, true
+			}
+			return m, unlinkKeyCmd(m.KeyID), true
+		}
+		m.Link.Entering = true
+		m.Link.Input.SetValue("")
+		return m, m.Link.Input.Focus(), true
+	}
+	return m, nil, false
+}
+
+// switchAccount makes the session play as id from now on.
+func (m Model) switchAccount(id string) Model {
+	m.SessionID = id
+	m.Link.Code = ""
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.SessionID = id
+	m.Cleanup.Mu.Unlock()
+	return m
+}
+
+// renderLinking is the settings footer line about linked keys.
+func (m Model) renderLinking() string {
+	switch {
+	case !m.HasKey:
+		return ""
+	case m.Link.Entering:
+		return m.tr("Link code from your other machine") + ": " + m.Link.Input.View()
+	case m.Link.Code != "" && time.Now().Before(m.Link.Expires):
+		left := time.Until(m.Link.Expires).Round(time.Minute)
+		return styles.Special.Render(fmt.Sprintf("%s: %s • %s (%s)", m.tr("Link code"), m.Link.Code, m.tr("press E in Settings on your other machine"), left))
+	}
+	line := m.tr("G: link another machine") + " • " + m.tr("E: enter a link code")
+	if m.KeyID != m.SessionID {
+		line = m.tr("This key is linked to your account") + " • " + m.tr("U: unlink")
+	}
+	if m.Link.Status != "" {
+		return styles.Special.Render(m.Link.Status) + "\n" + styles.Subtle.Render(line)
+	}
+	return styles.Subtle.Render(line)
+}
+
+func newLinkCodeCmd(account string) tea.Cmd {
+	return func() tea.Msg {
+		code, err := db.NewLinkCode(account)
+		if err != nil {
+			return errMsg(fmt.Errorf("could not make a link code: %v", err))
+		}
+		return linkCodeMsg{code}
+	}
+}
+
+func linkKeyCmd(code, keyID string) tea.Cmd {
+	return func() tea.Msg {
+		account, err := db.LinkKey(code, keyID)
+		if err != nil {
+			return errMsg(err)
+		}
+		return keyLinkedMsg{account: account}
+	}
+}
+
+func unlinkKeyCmd(keyID string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.UnlinkKey(keyID); err != nil {
+			return errMsg(fmt.Errorf("could not unlink this key: %v", err))
+		}
+		return keyLinkedMsg{account: keyID, unlink: true}
+	}
+}
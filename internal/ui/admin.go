@@ -0,0 +1,589 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/authlog"
+	"github.com/aminshahid573/termplay/internal/chat"
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// reportTranscript is how many recent chat messages go with a report.
+const reportTranscript = 10
+
+// AdminState backs the admin console's moderation queue.
+type AdminState struct {
+	Reports []db.Report
+	Sel     int
+	Loading bool
+	Status  string // result of the last action
+
+	// Stats view, toggled with "s"
+	ShowStats bool
+	Daily     []db.Summary // newest first
+	Weekly    []db.Summary
+
+	// Event log of the selected report's room, toggled with "e"
+	ShowLog bool
+	LogRoom string
+	Log     []db.RoomEvent
+
+	// Live sessions on this server, toggled with "i"
+	ShowSessions bool
+	Sessions     []SessionInfo
+	SessionSel   int
+	Composing    bool // typing a message to the selected session
+	MessageInput textinput.Model
+}
+
+type reportsFetchedMsg []db.Report
+
+type roomLogFetchedMsg struct {
+	code   string
+	events []db.RoomEvent
+}
+
+type statsFetchedMsg struct {
+	daily  []db.Summary
+	weekly []db.Summary
+}
+
+type tuningSetMsg struct{}
+
+type moderatedMsg struct {
+	action string
+	name   string
+}
+
+var adminActions = map[string]string{
+	"w": "warn",
+	"m": "mute",
+	"b": "ban",
+	"d": "dismiss",
+}
+
+func updateAdmin(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	a := &m.Admin
+	switch msg := msg.(type) {
+	case reportsFetchedMsg:
+		a.Loading = false
+		a.Reports = msg
+		if a.Sel >= len(a.Reports) {
+			a.Sel = max(len(a.Reports)-1, 0)
+		}
+	case statsFetchedMsg:
+		a.Loading = false
+		a.Daily = msg.daily
+		a.Weekly = msg.weekly
+	case roomLogFetchedMsg:
+		if msg.code == a.LogRoom {
+			a.Loading = false
+			a.Log = msg.events
+		}
+	case moderatedMsg:
+		a.Status = fmt.Sprintf("%s: %s", msg.action, msg.name)
+		return m, fetchReportsCmd()
+	case maintenanceSetMsg:
+		m.Maintenance = db.CurrentMaintenance()
+		a.Status = "Maintenance mode off"
+		if msg.on {
+			a.Status = fmt.Sprintf("Maintenance mode on, going down in %s", config.MaintenanceCountdown)
+		}
+	case tuningSetMsg:
+		a.Status = "Polling reset to the configured intervals"
+		if db.CurrentTuning() != nil {
+			iv := db.Intervals()
+			a.Status = fmt.Sprintf("Polling every %s / %s / %s", iv.Sync, iv.Active, iv.Idle)
+		}
+	case tea.KeyMsg:
+		if a.ShowSessions {
+			return updateSessionInspector(m, msg)
+		}
+		switch msg.String() {
+		case "i":
+			a.ShowSessions = true
+			a.ShowStats, a.ShowLog = false, false
+			a.Sessions = ListSessions()
+			return m, nil
+		case "up", "k":
+			if a.Sel > 0 {
+				a.Sel--
+			}
+		case "down", "j":
+			if a.Sel < len(a.Reports)-1 {
+				a.Sel++
+			}
+		case "s":
+			a.ShowStats = !a.ShowStats
+			a.ShowLog = false
+			if a.ShowStats {
+				a.Loading = true
+				return m, fetchStatsCmd()
+			}
+		case "e":
+			if a.ShowLog {
+				a.ShowLog = false
+				return m, nil
+			}
+			if a.ShowStats || len(a.Reports) == 0 || a.Reports[a.Sel].Room == "" {
+				return m, nil
+			}
+			a.ShowLog = true
+			a.LogRoom = a.Reports[a.Sel].Room
+			a.Log = nil
+			a.Loading = true
+			return m, fetchRoomLogCmd(a.LogRoom)
+		case "r":
+			a.Loading = true
+			if a.ShowStats {
+				return m, fetchStatsCmd()
+			}
+			if a.ShowLog {
+				return m, fetchRoomLogCmd(a.LogRoom)
+			}
+			return m, fetchReportsCmd()
+		case "o":
+			return m, toggleMaintenanceCmd(m.SessionID)
+		case "+", "-", "0":
+			if !a.ShowStats {
+				return m, nil
+			}
+			return m, setTuningCmd(m.SessionID, msg.String())
+		case "esc", "q":
+			m.State = StateGameSelect
+			m.MenuIndex = 0
+		default:
+			action, ok := adminActions[msg.String()]
+			if !ok || a.ShowStats || a.ShowLog || a.Loading || len(a.Reports) == 0 {
+				return m, nil
+			}
+			a.Loading = true
+			return m, moderateCmd(m.SessionID, a.Reports[a.Sel], action)
+		}
+	}
+	return m, nil
+}
+
+// updateSessionInspector handles keys on the live sessions screen.
+func updateSessionInspector(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	a := &m.Admin
+	if a.Composing {
+		switch msg.String() {
+		case "esc":
+			a.Composing = false
+			return m, nil
+		case "enter":
+			a.Composing = false
+			text := strings.TrimSpace(a.MessageInput.Value())
+			if text == "" || len(a.Sessions) == 0 {
+				return m, nil
+			}
+			target := a.Sessions[a.SessionSel]
+			a.Status = "Session closed meanwhile"
+			if l := findSession(target.Seq); l != nil {
+				a.Status = "Still showing an earlier message, try again"
+				if l.Message(text) {
+					a.Status = fmt.Sprintf("Sent to #%d", target.Seq)
+					log.Info("Admin message", "admin", m.SessionID, "session", target.Seq, "addr", target.Addr, "text", text)
+				}
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		a.MessageInput, cmd = a.MessageInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if a.SessionSel > 0 {
+			a.SessionSel--
+		}
+	case "down", "j":
+		if a.SessionSel < len(a.Sessions)-1 {
+			a.SessionSel++
+		}
+	case "r":
+		a.Sessions = ListSessions()
+		if a.SessionSel >= len(a.Sessions) {
+			a.SessionSel = max(len(a.Sessions)-1, 0)
+		}
+	case "m":
+		if len(a.Sessions) == 0 {
+			return m, nil
+		}
+		a.Composing = true
+		a.MessageInput = textinput.New()
+		a.MessageInput.Placeholder = "Message to show on their screen..."
+		a.MessageInput.Prompt = "> "
+		a.MessageInput.CharLimit = chat.MaxLen
+		a.MessageInput.Width = 50
+		a.MessageInput.Focus()
+	case "x":
+		if len(a.Sessions) == 0 {
+			return m, nil
+		}
+		target := a.Sessions[a.SessionSel]
+		if m.Live != nil && target.Seq == m.Live.Seq {
+			a.Status = "That is this session"
+			return m, nil
+		}
+		a.Status = "Session closed meanwhile"
+		if l := findSession(target.Seq); l != nil {
+			l.Disconnect()
+			a.Status = fmt.Sprintf("Disconnected #%d", target.Seq)
+			log.Info("Admin disconnect", "admin", m.SessionID, "session", target.Seq, "addr", target.Addr)
+		}
+		a.Sessions = ListSessions()
+		if a.SessionSel >= len(a.Sessions) {
+			a.SessionSel = max(len(a.Sessions)-1, 0)
+		}
+	case "i":
+		a.ShowSessions = false
+	case "o":
+		return m, toggleMaintenanceCmd(m.SessionID)
+	case "esc", "q":
+		m.State = StateGameSelect
+		m.MenuIndex = 0
+	}
+	return m, nil
+}
+
+func renderAdmin(m Model) string {
+	a := m.Admin
+	if a.ShowSessions {
+		return renderSessionInspector(m)
+	}
+	if a.ShowStats {
+		return renderAdminStats(a)
+	}
+	if a.ShowLog {
+		return renderRoomLog(a)
+	}
+	title := styles.Title.Render("MODERATION QUEUE")
+	if a.Loading && len(a.Reports) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Center, title, "Loading...")
+	}
+	if len(a.Reports) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Center, title, styles.Subtle.Render("Nothing to review"), styles.Special.Render(a.Status))
+	}
+
+	var rows []string
+	for i, r := range a.Reports {
+		line := fmt.Sprintf("[%s] %s: %s", r.Kind, taggedName(r.Target, r.TargetName), r.Reason)
+		if i == a.Sel {
+			rows = append(rows, styles.ItemFocused.Render(line))
+		} else {
+			rows = append(rows, styles.ItemBlurred.Render(line))
+		}
+	}
+
+	r := a.Reports[a.Sel]
+	detail := []string{
+		fmt.Sprintf("Room %s • %s", r.Room, time.Unix(r.At, 0).Format("2006-01-02 15:04")),
+		styles.Subtle.Render("Player ID: " + r.Target),
+		"",
+	}
+	if len(r.Transcript) == 0 {
+		detail = append(detail, styles.Subtle.Render("(no chat)"))
+	}
+	for _, c := range r.Transcript {
+		name := styles.Highlight.Render(taggedName(c.From, c.Name) + ": ")
+		if c.From == r.Target {
+			name = styles.Err.Render(taggedName(c.From, c.Name) + ": ")
+		}
+		detail = append(detail, name+c.Text)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+		"",
+		styles.Box.Render(lipgloss.JoinVertical(lipgloss.Left, detail...)),
+	)
+	if a.Status != "" {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, styles.Special.Render(a.Status))
+	}
+	return content
+}
+
+func renderAdminStats(a AdminState) string {
+	title := styles.Title.Render("STATS")
+	if a.Loading {
+		return lipgloss.JoinVertical(lipgloss.Center, title, "Loading...")
+	}
+
+	row := func(s db.Summary) string {
+		return fmt.Sprintf("%-10s %6d %8d %9d %6s", s.Period, s.Games, s.Abandoned, s.UniquePlayers,
+			(time.Duration(s.AvgDuration) * time.Second).String())
+	}
+	header := styles.Subtle.Render(fmt.Sprintf("%-10s %6s %8s %9s %6s", "Period", "Games", "Dropped", "Players", "Avg"))
+
+	rows := []string{header}
+	for _, s := range a.Daily {
+		rows = append(rows, row(s))
+	}
+	rows = append(rows, "")
+	for _, s := range a.Weekly {
+		rows = append(rows, row(s))
+	}
+	if len(a.Weekly) == 0 {
+		rows = append(rows, styles.Subtle.Render("No weekly summaries yet"))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+		"",
+		styles.Subtle.Render(fmt.Sprintf("DB client re-inits since start: %d", metrics.DBReinits.Load())),
+		styles.Subtle.Render(fmt.Sprintf("DB calls: %d • avg round-trip %dms", metrics.DBCalls.Load(), metrics.DBLatency().Milliseconds())),
+		styles.Subtle.Render(renderPolling()),
+	)
+}
+
+// renderPolling describes the poll cadence in force.
+func renderPolling() string {
+	iv := db.Intervals()
+	s := fmt.Sprintf("Polling: your move %s • waiting %s • idle %s", iv.Sync, iv.Active, iv.Idle)
+	if t := db.CurrentTuning(); t != nil {
+		s += fmt.Sprintf(" (tuned by %s)", t.By)
+	}
+	return s
+}
+
+// setTuningCmd slows polling down on every server ("+", to twice the
+// intervals), speeds it up ("-", to half) or goes back to the configured
+// intervals ("0").
+func setTuningCmd(adminID, key string) tea.Cmd {
+	return func() tea.Msg {
+		var t *db.Tuning
+		switch key {
+		case "+":
+			t = db.Intervals().Scaled(2, adminID)
+		case "-":
+			t = db.Intervals().Scaled(0.5, adminID)
+		}
+		if err := db.SetTuning(adminID, t); err != nil {
+			return errMsg(fmt.Errorf("could not change polling: %v", err))
+		}
+		return tuningSetMsg{}
+	}
+}
+
+// roomLogShown is how many of the latest events the room log screen
+// lists; `termplay roomlog` prints them all.
+const roomLogShown = 30
+
+func renderRoomLog(a AdminState) string {
+	title := styles.Title.Render("ROOM " + a.LogRoom)
+	if a.Loading {
+		return lipgloss.JoinVertical(lipgloss.Center, title, "Loading...")
+	}
+	if len(a.Log) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Center, title,
+			styles.Subtle.Render(fmt.Sprintf("Nothing logged in the last %d days", config.RoomLogDays)))
+	}
+
+	events := a.Log
+	rows := []string{}
+	if len(events) > roomLogShown {
+		rows = append(rows, styles.Subtle.Render(fmt.Sprintf("(latest %d of %d)", roomLogShown, len(events))))
+		events = events[len(events)-roomLogShown:]
+	}
+	for _, e := range events {
+		line := fmt.Sprintf("%s seq %-4d %-8s %-12s %s",
+			time.UnixMilli(e.At).Format("01-02 15:04:05.000"), e.Seq, e.Kind, shortID(e.Player), e.Detail)
+		if e.Kind == "reject" {
+			line = styles.Err.Render(line)
+		}
+		rows = append(rows, line)
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, title, lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func renderSessionInspector(m Model) string {
+	a := m.Admin
+	title := styles.Title.Render(fmt.Sprintf("LIVE SESSIONS (%d)", len(a.Sessions)))
+
+	rows := []string{styles.Subtle.Render(fmt.Sprintf("%-4s %-12s %-21s %-13s %-6s %6s %7s %9s",
+		"#", "Name", "Address", "Screen", "Room", "Idle", "Size", "Sent"))}
+	for i, s := range a.Sessions {
+		name := s.Name
+		if name == "" {
+			name = "(" + s.User + ")"
+		}
+		line := fmt.Sprintf("%-4d %-12s %-21s %-13s %-6s %6s %7s %9s",
+			s.Seq, clip(name, 12), clip(s.Addr, 21), s.Screen, s.Room,
+			s.Idle.Truncate(time.Second), fmt.Sprintf("%dx%d", s.Width, s.Height), byteCount(s.Sent))
+		if m.Live != nil && s.Seq == m.Live.Seq {
+			line += " (you)"
+		}
+		if i == a.SessionSel {
+			rows = append(rows, styles.ItemFocused.Render(line))
+		} else {
+			rows = append(rows, styles.ItemBlurred.Render(line))
+		}
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, lipgloss.JoinVertical(lipgloss.Left, rows...))
+	if a.Composing {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, "",
+			fmt.Sprintf("Message to #%d:", a.Sessions[a.SessionSel].Seq), a.MessageInput.View())
+	}
+	if a.Status != "" {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, styles.Special.Render(a.Status))
+	}
+	return content
+}
+
+// clip cuts s to at most n bytes for a table column.
+func clip(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// byteCount formats a number of bytes for the session inspector.
+func byteCount(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// shortID trims a player ID for the narrow columns of the room log.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// reportCmd files a report against the opponent with the recent chat
+// attached.
+func (m Model) reportCmd(kind, reason string) tea.Cmd {
+	target := m.opponentID()
+	name := m.Game.PlayerOName
+	if m.MySide == "O" {
+		name = m.Game.PlayerXName
+	}
+	return fileReportCmd(db.Report{
+		Kind:       kind,
+		Reporter:   m.SessionID,
+		Target:     target,
+		TargetName: name,
+		Room:       m.RoomCode,
+		Reason:     reason,
+		Transcript: lastMessages(m.Chat, reportTranscript),
+	})
+}
+
+// logAbuse puts ban-worthy behavior of the local player in the auth log,
+// where fail2ban and the like can act on their address.
+func (m Model) logAbuse(reason string) {
+	key := ""
+	if m.HasKey {
+		key = m.KeyID
+	}
+	authlog.Log(authlog.Abuse, m.Conn.Addr, m.Conn.User, key, reason)
+}
+
+// flagSelfCmd raises an automatic flag about the local player.
+func (m Model) flagSelfCmd(reason string) tea.Cmd {
+	return fileReportCmd(db.Report{
+		Kind:       "flag",
+		Target:     m.SessionID,
+		TargetName: m.MyName,
+		Room:       m.RoomCode,
+		Reason:     reason,
+		Transcript: lastMessages(m.Chat, reportTranscript),
+	})
+}
+
+func lastMessages(msgs []db.ChatMessage, n int) []db.ChatMessage {
+	if len(msgs) > n {
+		msgs = msgs[len(msgs)-n:]
+	}
+	return append([]db.ChatMessage(nil), msgs...)
+}
+
+func fileReportCmd(r db.Report) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.FileReport(r); err != nil {
+			return errMsg(fmt.Errorf("could not file report: %v", err))
+		}
+		return nil
+	}
+}
+
+func fetchReportsCmd() tea.Cmd {
+	return func() tea.Msg {
+		reports, err := db.GetOpenReports()
+		if err != nil {
+			return errMsg(err)
+		}
+		return reportsFetchedMsg(reports)
+	}
+}
+
+// statsDays is how many daily summaries the stats screen shows.
+const statsDays = 7
+
+func fetchStatsCmd() tea.Cmd {
+	return func() tea.Msg {
+		var msg statsFetchedMsg
+		today := time.Now().UTC()
+		for i := 0; i < statsDays; i++ {
+			s, err := db.GetDailySummary(today.AddDate(0, 0, -i))
+			if err != nil {
+				return errMsg(err)
+			}
+			msg.daily = append(msg.daily, *s)
+		}
+		weekly, err := db.GetWeeklySummaries(4)
+		if err != nil {
+			return errMsg(err)
+		}
+		msg.weekly = weekly
+		return msg
+	}
+}
+
+func fetchRoomLogCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		events, err := db.GetRoomLog(code)
+		if err != nil {
+			return errMsg(err)
+		}
+		return roomLogFetchedMsg{code: code, events: events}
+	}
+}
+
+func moderateCmd(adminID string, r db.Report, action string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.Moderate(adminID, r, action); err != nil {
+			return errMsg(err)
+		}
+		return moderatedMsg{action: action, name: r.TargetName}
+	}
+}
+
+func clearWarningCmd(id string) tea.Cmd {
+	return saveCmd("acknowledging the warning", func() error { return db.ClearWarning(id) })
+}
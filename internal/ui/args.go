@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SessionArgs are the options a player can pass after the host, e.g.
+// `ssh -t -p 2324 host theme=high-contrast`. They last for the session
+// and aren't saved.
+type SessionArgs struct {
+	Theme string // overrides the theme from Settings
+}
+
+// ArgsUsage lists the accepted arguments, for players who got one wrong.
+const ArgsUsage = "theme=" + themeDefault + "|" + themeHighContrast
+
+// ParseArgs reads the command an SSH client sent as SessionArgs.
+func ParseArgs(args []string) (SessionArgs, error) {
+	var a SessionArgs
+	for _, arg := range args {
+		key, val, _ := strings.Cut(arg, "=")
+		switch key {
+		case "theme":
+			if !slices.Contains(themes, val) {
+				return a, fmt.Errorf("unknown theme %q", val)
+			}
+			a.Theme = val
+		default:
+			return a, fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/db"
+)
+
+// badgeGlyphs maps each badge to the glyph shown after a name, and an
+// ASCII stand-in for terminals without the symbols.
+var badgeGlyphs = map[string][2]string{
+	db.BadgeChampion: {"♛", "*"},
+	db.BadgeCentury:  {"✪", "+"},
+	db.BadgeEarly:    {"✦", "~"},
+}
+
+var badgeNames = map[string]string{
+	db.BadgeChampion: "Season champion",
+	db.BadgeCentury:  "100 wins",
+	db.BadgeEarly:    "Early adopter",
+}
+
+// renderBadgeList spells out the badges a player holds, e.g.
+// "♛ Season champion • ✦ Early adopter", or "" if they have none.
+func renderBadgeList(have map[string]bool, ascii bool) string {
+	var parts []string
+	for _, b := range db.BadgeOrder {
+		if !have[b] {
+			continue
+		}
+		g := badgeGlyphs[b][0]
+		if ascii {
+			g = badgeGlyphs[b][1]
+		}
+		parts = append(parts, g+" "+badgeNames[b])
+	}
+	return strings.Join(parts, " • ")
+}
+
+// seatBadges is the glyphs for the badges pid held when they took their
+// seat in r, e.g. "♛✦".
+func seatBadges(r db.Room, pid string, ascii bool) string {
+	glyphs := ""
+	for _, b := range r.Seats[pid].Badges {
+		g, ok := badgeGlyphs[b]
+		if !ok {
+			continue
+		}
+		if ascii {
+			glyphs += g[1]
+		} else {
+			glyphs += g[0]
+		}
+	}
+	return glyphs
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Low-bandwidth mode trims what a session sends over a slow link: the
+// status bar refreshes less often, animations and blinking stop (see
+// reduceMotion) and colors are cut down to the basic sixteen. Players can switch it on
+// or off in Settings; left on auto, it comes on once the link's round-trip
+// or throughput falls past the limits in config.
+
+// leanStatusTick is the status bar's refresh in low-bandwidth mode.
+const leanStatusTick = 5 * time.Second
+
+// lowBandwidth reports whether low-bandwidth mode is in effect.
+func (m Model) lowBandwidth() bool {
+	switch m.Settings.LowBandwidth {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return m.SlowLink
+}
+
+// linkIsSlow reports whether the player's link is past the limits that
+// switch low-bandwidth mode on automatically.
+func (m Model) linkIsSlow() bool {
+	if rtt := m.Latency.Get(); config.LowBandwidthRTT > 0 && rtt >= config.LowBandwidthRTT {
+		return true
+	}
+	rate := m.Throughput.Get()
+	return config.LowBandwidthRate > 0 && rate > 0 && rate < int64(config.LowBandwidthRate)
+}
+
+// updateBandwidth notices a slow link and switches the session in and
+// out of low-bandwidth mode as it or the setting changes. A link found
+// slow stays that way for the session, so the screen doesn't flip back
+// and forth.
+func updateBandwidth(m Model) (Model, tea.Cmd) {
+	if !m.SlowLink && m.Settings.LowBandwidth == "" && m.linkIsSlow() {
+		m.SlowLink = true
+		m = m.toastFor(ToastInfo, "Slow connection: low-bandwidth mode is on (see Settings)", 6*time.Second)
+	}
+	m.Lean = m.lowBandwidth()
+	return m, nil
+}
+
+func (m Model) statusTickCmd() tea.Cmd {
+	if m.lowBandwidth() {
+		return tea.Tick(leanStatusTick, func(time.Time) tea.Msg { return statusTickMsg{} })
+	}
+	return statusTickCmd()
+}
+
+// sgr matches the escape sequences that set colors and text attributes.
+var sgr = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// leanColors rewrites the 256-color and true color sequences in a frame
+// to the nearest of the basic sixteen colors, which take a few bytes
+// instead of up to nineteen each.
+func leanColors(frame string) string {
+	return sgr.ReplaceAllStringFunc(frame, func(seq string) string {
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		out := params[:0:0]
+		for i := 0; i < len(params); i++ {
+			p := params[i]
+			if (p != "38" && p != "48") || i+1 >= len(params) {
+				out = append(out, p)
+				continue
+			}
+			var c termenv.Color
+			switch params[i+1] {
+			case "5":
+				if i+2 < len(params) {
+					n, _ := strconv.Atoi(params[i+2])
+					c = termenv.ANSI.Convert(termenv.ANSI256Color(n))
+				}
+				i += 2
+			case "2":
+				if i+4 < len(params) {
+					r, _ := strconv.Atoi(params[i+2])
+					g, _ := strconv.Atoi(params[i+3])
+					b, _ := strconv.Atoi(params[i+4])
+					c = termenv.ANSI.Color(hexColor(r, g, b))
+				}
+				i += 4
+			default:
+				out = append(out, p)
+				continue
+			}
+			if c != nil {
+				if s := c.Sequence(p == "48"); s != "" {
+					out = append(out, s)
+				}
+			}
+		}
+		if len(out) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(out, ";") + "m"
+	})
+}
+
+func hexColor(r, g, b int) string {
+	return fmt.Sprintf("#%02x%02x%02x", r&255, g&255, b&255)
+}
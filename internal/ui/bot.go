@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// botMoveDelay keeps the bot from answering instantly, which reads as
+// a glitch rather than an opponent.
+const botMoveDelay = 700 * time.Millisecond
+
+type botMovedMsg struct {
+	code string
+	err  error
+}
+
+// canOfferBot reports whether the host has waited long enough in a
+// tic-tac-toe lobby to be offered a bot instead.
+func (m Model) canOfferBot() bool {
+	return m.State == StateLobby &&
+		m.MySide == "X" &&
+		m.Game.GameType != "chess" &&
+		m.Game.PlayerO == "" &&
+		!m.Game.Ranked &&
+		!m.Game.Correspondence &&
+		!m.LobbySince.IsZero() &&
+		time.Since(m.LobbySince) >= config.BotOfferAfter
+}
+
+// botShouldMove reports whether this client owes the room a bot move.
+// Only the host drives the bot, so there is exactly one mover.
+func (m Model) botShouldMove() bool {
+	return !m.BotThinking &&
+		m.RoomCode != "" &&
+		m.MySide == "X" &&
+		m.Game.PlayerO == db.BotID &&
+		m.Game.Status == "playing" &&
+		m.Game.Turn == "O"
+}
+
+// botMoveCmd plays the bot's turn against a fresh copy of the room, so
+// a human who took over the seat in the meantime is never overruled.
+func botMoveCmd(code string) tea.Cmd {
+	return tea.Tick(botMoveDelay, func(time.Time) tea.Msg {
+		r, err := db.GetRoom(code)
+		if err != nil {
+			return botMovedMsg{code, err}
+		}
+		if r == nil || r.PlayerO != db.BotID || r.Status != "playing" || r.Turn != "O" {
+			return botMovedMsg{code: code}
+		}
+		idx := tictactoe.BotMove(r.BotLevel, r.Board, tictactoe.O)
+		if idx < 0 {
+			return botMovedMsg{code: code}
+		}
+		return botMovedMsg{code, db.UpdateMove(code, db.BotID, idx, *r)}
+	})
+}
+
+// cycleBotLevel steps through the difficulty tiers, wrapping at both ends.
+func cycleBotLevel(level string, dir int) string {
+	n := len(tictactoe.Levels)
+	for i, l := range tictactoe.Levels {
+		if l == level {
+			return tictactoe.Levels[(i+dir+n)%n]
+		}
+	}
+	return tictactoe.LevelIntermediate
+}
+
+// cycleHandicap steps to the next of db.Handicaps.
+func cycleHandicap(h string) string {
+	for i, x := range db.Handicaps {
+		if x == h {
+			return db.Handicaps[(i+1)%len(db.Handicaps)]
+		}
+	}
+	return db.HandicapNone
+}
+
+// handicapLabel describes a handicap for the room settings and the room
+// list, where the joiner sees it before taking the seat.
+func handicapLabel(h string) string {
+	switch h {
+	case db.HandicapWeakerStarts:
+		return "Weaker player starts"
+	case db.HandicapNoCenter:
+		return "Stronger player can't open in the center"
+	default:
+		return "None"
+	}
+}
+
+// renderHandicap is the line under the game header naming the room's
+// handicap and who it currently favors, or "" without one.
+func renderHandicap(r db.Room) string {
+	if r.Handicap == db.HandicapNone || r.GameType == "chess" {
+		return ""
+	}
+	weaker := r.PlayerOName
+	if r.Weaker() == "X" {
+		weaker = r.PlayerXName
+	}
+	return styles.Special.Render(fmt.Sprintf("Handicap: %s (favoring %s)", handicapLabel(r.Handicap), displayName(weaker)))
+}
+
+// canSwap reports whether the player may use the pie rule right now.
+func (m Model) canSwap() bool {
+	return m.MySide == "O" && m.Game.CanSwap()
+}
+
+// botLevelLabel is the display name for a difficulty tier.
+func botLevelLabel(level string) string {
+	switch level {
+	case tictactoe.LevelRandom:
+		return "Random"
+	case tictactoe.LevelPerfect:
+		return "Perfect"
+	default:
+		return "Intermediate"
+	}
+}
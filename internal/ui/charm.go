@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/charm"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CharmState backs signing in with a Charm account from the settings
+// screen (C), on servers with config.CharmURL set: every key signed in
+// with the same Charm account plays as one profile.
+type CharmState struct {
+	ID       string // Charm account the profile is signed in with, if any
+	Entering bool   // pasting a token
+	Input    textinput.Model
+}
+
+// charmLinkedMsg means this key now plays as account, the profile of
+// Charm account id.
+type charmLinkedMsg struct{ account, id string }
+
+func newCharmInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "paste the output of: charm jwt " + charm.Audience
+	ti.Prompt = "> "
+	ti.CharLimit = 4096
+	ti.Width = 40
+	return ti
+}
+
+// updateCharmKeys handles the settings keys for Charm sign-in. It
+// reports whether it used the key.
+func updateCharmKeys(m Model, msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if m.Charm.Entering {
+		switch msg.String() {
+		case "esc":
+			m.Charm.Entering = false
+			m.Charm.Input.Blur()
+			return m, nil, true
+		case "enter":
+			token := strings.TrimSpace(m.Charm.Input.Value())
+			m.Charm.Entering = false
+			m.Charm.Input.Blur()
+			m.Charm.Input.SetValue("")
+			if token == "" {
+				return m, nil, true
+			}
+			return m, charmSignInCmd(token, m.KeyID, m.SessionID), true
+		}
+		var cmd tea.Cmd
+		m.Charm.Input, cmd = m.Charm.Input.Update(msg)
+		return m, cmd, true
+	}
+	if !m.HasKey || !charm.Enabled() || msg.String() != "c" {
+		return m, nil, false
+	}
+	if m.inRoom() || len(m.Tabs) > 0 {
+		// Rooms remember the player by ID; switching under them would strand a seat
+		m = m.fail(fmt.Errorf("leave your rooms before switching accounts"))
+		return m, nil, true
+	}
+	m.Err = nil
+	m.Charm.Entering = true
+	m.Charm.Input.SetValue("")
+	return m, m.Charm.Input.Focus(), true
+}
+
+// renderCharm is the settings footer line about Charm sign-in, or "" on
+// servers without it.
+func (m Model) renderCharm() string {
+	switch {
+	case !m.HasKey || !charm.Enabled():
+		return ""
+	case m.Charm.Entering:
+		return m.tr("Charm token") + ": " + m.Charm.Input.View()
+	case m.Charm.ID != "":
+		return styles.Subtle.Render(m.tr("Signed in with Charm") + " • " + m.tr("C: sign in again"))
+	}
+	return styles.Subtle.Render(m.tr("C: sign in with Charm"))
+}
+
+func charmSignInCmd(token, keyID, account string) tea.Cmd {
+	return func() tea.Msg {
+		id, err := charm.Verify(token)
+		if err != nil {
+			return errMsg(err)
+		}
+		account, err := db.LinkCharm(id, keyID, account)
+		if err != nil {
+			return errMsg(fmt.Errorf("could not sign in with Charm: %v", err))
+		}
+		return charmLinkedMsg{account: account, id: id}
+	}
+}
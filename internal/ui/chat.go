@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/chat"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// chatHistory is how many messages are loaded on join and kept in memory.
+const chatHistory = 50
+
+// chatVisible is how many lines of chat fit under or beside the board;
+// older ones are kept in a scrollback, see chatView.
+const chatVisible = 5
+
+// stackedChatWidth is how wide the chat under the board gets at most.
+const stackedChatWidth = 60
+
+type chatEventMsg struct {
+	code string
+	data []byte
+}
+
+type chatLoadedMsg struct {
+	code string
+	msgs []db.ChatMessage
+}
+
+func updateChat(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case chatLoadedMsg:
+		if msg.code == m.RoomCode {
+			m.Chat = msg.msgs
+		}
+		return m, nil
+
+	case chatEventMsg:
+		if msg.code != m.RoomCode {
+			return m, nil
+		}
+		var cm db.ChatMessage
+		if err := json.Unmarshal(msg.data, &cm); err == nil {
+			before := len(chatLines(m, m.chatWidth()))
+			m.Chat = append(m.Chat, cm)
+			if len(m.Chat) > chatHistory {
+				m.Chat = m.Chat[len(m.Chat)-chatHistory:]
+			}
+			if m.ChatScroll > 0 {
+				// Keep what the player scrolled back to in view
+				m = m.scrollChat(len(chatLines(m, m.chatWidth())) - before)
+			}
+		}
+		return m, waitChatEventCmd(msg.code, m.ChatEvents)
+
+	case tea.KeyMsg:
+		if m, ok := m.scrollChatKey(msg); ok {
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			m.ChatOpen = false
+			m.ChatInput.Blur()
+			return m, nil
+		case "enter":
+			text := chat.Clean(m.ChatInput.Value())
+			m.ChatOpen = false
+			m.ChatInput.Blur()
+			m.ChatInput.SetValue("")
+			if text == "" {
+				return m, nil
+			}
+			if m.ChatMuted {
+				m.ChatNotice = "You have been muted by a moderator"
+				return m, nil
+			}
+			if err := chat.Allow(m.SessionID); err != nil {
+				m.ChatNotice = err.Error()
+				m.logAbuse("chat flood")
+				if !m.Flagged {
+					// Raise one flag per session so a flood doesn't flood the queue too
+					m.Flagged = true
+					return m, m.flagSelfCmd("Chat flood: hit the rate limit")
+				}
+				return m, nil
+			}
+			m.ChatNotice = ""
+			m.ChatScroll = 0
+			return m, sendChatCmd(m.RoomCode, db.ChatMessage{
+				From: m.SessionID,
+				Name: m.MyName,
+				Text: text,
+				At:   time.Now().Unix(),
+			})
+		}
+		var cmd tea.Cmd
+		m.ChatInput, cmd = m.ChatInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// opponentID is the other player's session ID, or "" for spectators and
+// bot games.
+func (m Model) opponentID() string {
+	var id string
+	switch m.MySide {
+	case "X":
+		id = m.Game.PlayerO
+	case "O":
+		id = m.Game.PlayerX
+	}
+	if id == db.BotID {
+		return ""
+	}
+	return id
+}
+
+// chatWidth is how wide the chat is in the game screen's layout.
+func (m Model) chatWidth() int {
+	if chooseLayout(m.Width, m.Height) == layoutSideBySide {
+		return sidePanelWidth
+	}
+	return min(m.Width, stackedChatWidth)
+}
+
+// chatLines is the room's chat, oldest first, wrapped to width.
+func chatLines(m Model, width int) []string {
+	wrap := lipgloss.NewStyle().Width(width)
+	var out []string
+	for _, l := range chat.Collapse(m.Chat, m.Muted) {
+		text := l.Text
+		if l.Count > 1 {
+			text = fmt.Sprintf("%s (x%d)", text, l.Count)
+		}
+		line := wrap.Render(styles.Highlight.Render(playerName(m.Game, l.From, l.Name, m.Settings.ASCII)+": ") + text)
+		out = append(out, strings.Split(line, "\n")...)
+	}
+	return out
+}
+
+// chatView is the scrollback of lines, at most chatVisible high and
+// ChatScroll lines back from the latest.
+func (m Model) chatView(lines []string) viewport.Model {
+	vp := viewport.New(m.chatWidth(), min(len(lines), chatVisible))
+	vp.SetContent(strings.Join(lines, "\n"))
+	vp.SetYOffset(len(lines) - vp.Height - m.ChatScroll)
+	return vp
+}
+
+// scrollChat scrolls the chat n lines further back, or forward when n is
+// negative, keeping within the history.
+func (m Model) scrollChat(n int) Model {
+	lines := len(chatLines(m, m.chatWidth()))
+	m.ChatScroll = min(max(m.ChatScroll+n, 0), max(lines-chatVisible, 0))
+	return m
+}
+
+// scrollChatKey scrolls the chat for PgUp and PgDn, reporting whether msg
+// was one of them.
+func (m Model) scrollChatKey(msg tea.KeyMsg) (Model, bool) {
+	switch msg.String() {
+	case "pgup":
+		return m.scrollChat(chatVisible - 1), true
+	case "pgdown":
+		return m.scrollChat(1 - chatVisible), true
+	}
+	return m, false
+}
+
+func renderChat(m Model) string {
+	var rows []string
+	if lines := chatLines(m, m.chatWidth()); len(lines) > 0 {
+		rows = append(rows, m.chatView(lines).View())
+	}
+	if m.ChatScroll > 0 {
+		rows = append(rows, styles.Subtle.Render(fmt.Sprintf("(%d newer lines — PgDn)", m.ChatScroll)))
+	}
+	if m.Muted[m.opponentID()] {
+		rows = append(rows, styles.Subtle.Render("(opponent muted — M to unmute)"))
+	}
+	if m.ChatNotice != "" {
+		rows = append(rows, styles.Err.Render(m.ChatNotice))
+	}
+	if m.ChatOpen {
+		rows = append(rows, m.ChatInput.View())
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func loadChatCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		msgs, err := db.GetChat(code, chatHistory)
+		if err != nil {
+			return nil
+		}
+		return chatLoadedMsg{code: code, msgs: msgs}
+	}
+}
+
+func sendChatCmd(code string, msg db.ChatMessage) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.SendChat(code, msg); err != nil {
+			return errMsg(fmt.Errorf("message not sent: %v", err))
+		}
+		return nil
+	}
+}
+
+func waitChatEventCmd(code string, ch <-chan []byte) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		data, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return chatEventMsg{code: code, data: data}
+	}
+}
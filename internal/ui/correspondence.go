@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paceLabel names a room's pace on the room settings screen.
+func paceLabel(correspondence bool) string {
+	if correspondence {
+		return "Correspondence"
+	}
+	return "Live"
+}
+
+// paceHint explains the pace on the room settings screen.
+func paceHint(correspondence bool) string {
+	if !correspondence {
+		return "Both players stay until the game is over"
+	}
+	return fmt.Sprintf("%s per move • leave and come back any time", strings.TrimSuffix(clockText(config.CorrespondenceMoveTime), "00m"))
+}
+
+type correspondenceMsg struct {
+	games []db.Room
+}
+
+// loadCorrespondenceCmd loads the correspondence games pid sits in, for
+// the notice on the main menu.
+func loadCorrespondenceCmd(pid string) tea.Cmd {
+	if db.IsGuest(pid) {
+		return nil
+	}
+	return func() tea.Msg {
+		games, err := db.CorrespondenceGames(pid)
+		if err != nil {
+			return nil
+		}
+		return correspondenceMsg{games: games}
+	}
+}
+
+// myCorrespondenceMoves is the correspondence games waiting on the
+// player's move.
+func (m Model) myCorrespondenceMoves() []db.Room {
+	var mine []db.Room
+	for _, r := range m.Correspondence {
+		if r.AwaitsMove(m.SessionID) {
+			mine = append(mine, r)
+		}
+	}
+	return mine
+}
+
+// renderCorrespondence is the main menu notice of the correspondence
+// games waiting on the player, e.g. "Your turn in 2 games: K7QP vs bob
+// (20h14m left), ...", or "" if there are none.
+func renderCorrespondence(m Model) string {
+	mine := m.myCorrespondenceMoves()
+	if len(mine) == 0 {
+		if len(m.Correspondence) == 0 {
+			return ""
+		}
+		return styles.Subtle.Render(fmt.Sprintf("%s in correspondence, waiting on your opponents",
+			games(len(m.Correspondence))))
+	}
+	var lines []string
+	for _, r := range mine {
+		opp := r.PlayerOName
+		if r.PlayerO == m.SessionID {
+			opp = r.PlayerXName
+		}
+		left := time.Until(r.MoveDeadline())
+		if left < 0 {
+			left = 0
+		}
+		lines = append(lines, fmt.Sprintf("%s vs %s (%s left)", r.Code, displayName(opp), clockText(left)))
+	}
+	return styles.Highlight.Render("Your turn in "+games(len(mine))+": ") + strings.Join(lines, ", ")
+}
+
+// games counts games, e.g. "1 game" or "2 games".
+func games(n int) string {
+	if n == 1 {
+		return "1 game"
+	}
+	return fmt.Sprintf("%d games", n)
+}
+
+// resumeCorrespondence takes the player back to the correspondence game
+// most in need of them.
+func (m Model) resumeCorrespondence() (Model, tea.Cmd) {
+	if len(m.Correspondence) == 0 || m.Busy {
+		return m, nil
+	}
+	m, spin := m.startLoading(loadingJoin)
+	return m, tea.Batch(joinRoomCmd(m.Correspondence[0].Code, m.SessionID, m.MyName), spin)
+}
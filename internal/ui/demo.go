@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The attract mode: after sitting idle on the main menu for a few seconds,
+// a tiny AI-vs-AI tic-tac-toe game plays itself under the menu. It is
+// purely local and never touches the database.
+
+const (
+	demoIdleAfter = 5 * time.Second
+	demoStep      = 700 * time.Millisecond
+	demoRestAfter = 3 // ticks to linger on a finished board
+)
+
+type demoTickMsg struct{}
+
+type DemoGame struct {
+	Board   tictactoe.Board
+	Turn    tictactoe.Cell
+	Line    []int
+	Done    bool
+	Resting int
+}
+
+func newDemoGame() DemoGame {
+	return DemoGame{Turn: tictactoe.X}
+}
+
+// step plays one move, or counts down and restarts after a finished game.
+func (d DemoGame) step() DemoGame {
+	if d.Done {
+		d.Resting++
+		if d.Resting >= demoRestAfter {
+			return newDemoGame()
+		}
+		return d
+	}
+
+	idx := tictactoe.HeuristicMove(d.Board, d.Turn)
+	if idx < 0 {
+		d.Done = true
+		return d
+	}
+	d.Board[idx] = d.Turn
+	if winner, line := tictactoe.CheckWinner(d.Board); winner != tictactoe.Empty {
+		d.Line = line
+		d.Done = true
+	} else if tictactoe.CheckDraw(d.Board) {
+		d.Done = true
+	}
+	d.Turn = tictactoe.Opponent(d.Turn)
+	return d
+}
+
+func demoTickCmd() tea.Cmd {
+	return tea.Tick(demoStep, func(time.Time) tea.Msg { return demoTickMsg{} })
+}
+
+// updateDemo keeps exactly one demo ticker alive while the menu is shown.
+// The ticker stops itself as soon as the player leaves the menu.
+func updateDemo(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	if _, ok := msg.(demoTickMsg); ok {
+		if m.State != StateMenu {
+			m.DemoTicking = false
+			return m, nil
+		}
+		if m.demoVisible() {
+			m.Demo = m.Demo.step()
+		}
+		return m, demoTickCmd()
+	}
+	if m.State == StateMenu && !m.DemoTicking {
+		m.DemoTicking = true
+		m.Demo = newDemoGame()
+		return m, demoTickCmd()
+	}
+	return m, nil
+}
+
+func (m Model) demoVisible() bool {
+	return m.State == StateMenu && !m.reduceMotion() && time.Since(m.LastInput) >= demoIdleAfter
+}
+
+func renderDemo(d DemoGame, ascii bool) string {
+	empty := "·"
+	if ascii {
+		empty = "."
+	}
+	var rows []string
+	for r := 0; r < 3; r++ {
+		var cells []string
+		for c := 0; c < 3; c++ {
+			idx := r*3 + c
+			cell := styles.Muted.Render(empty)
+			switch d.Board[idx] {
+			case tictactoe.X:
+				cell = styles.XStyle.Render("X")
+			case tictactoe.O:
+				cell = styles.OStyle.Render("O")
+			}
+			for _, w := range d.Line {
+				if w == idx {
+					cell = styles.Win.Render(d.Board[idx].String())
+				}
+			}
+			cells = append(cells, " "+cell+" ")
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center,
+		styles.Muted.Render("— demo —"),
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+	)
+}
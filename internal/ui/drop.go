@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// opponentHeld is when the seat of the player's opponent, whose
+// connection dropped, stops being held for them, or the zero time if it
+// isn't. See db.Disconnect.
+func (m Model) opponentHeld() time.Time {
+	if m.MySide == "Spectator" || m.State != StateGame {
+		return time.Time{}
+	}
+	opp := m.Game.PlayerO
+	if m.MySide == "O" {
+		opp = m.Game.PlayerX
+	}
+	return m.Game.RejoinDeadline(opp)
+}
+
+// renderDropped is the status bar countdown to the forfeit of an
+// opponent who dropped, e.g. "bob dropped • win by forfeit in 1:42".
+func (m Model) renderDropped() string {
+	deadline := m.opponentHeld()
+	if deadline.IsZero() {
+		return ""
+	}
+	name := m.Game.PlayerOName
+	if m.MySide == "O" {
+		name = m.Game.PlayerXName
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return styles.Err.Render(fmt.Sprintf("%s didn't come back • claiming the game", displayName(name)))
+	}
+	return styles.Err.Render(fmt.Sprintf("%s dropped • win by forfeit in %s", displayName(name), clockText(left)))
+}
+
+// shouldClaimForfeit reports whether the opponent's held seat has run
+// out, and no claim is already under way.
+func (m Model) shouldClaimForfeit() bool {
+	deadline := m.opponentHeld()
+	return !deadline.IsZero() && time.Now().After(deadline) && time.Since(m.ClaimedAt) >= claimRetry
+}
+
+func claimForfeitCmd(code, pid string, r db.Room) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.ClaimForfeit(code, pid, r); err != nil {
+			return moveFailedMsg{code: code, err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// otherSession returns the oldest live session, other than self, of the
+// player id, or nil. Guests get a new ID with every connection, so they
+// never have one.
+func otherSession(id string, self *LiveSession) *LiveSession {
+	if self == nil || db.IsGuest(id) {
+		return nil
+	}
+	sessions.Lock()
+	defer sessions.Unlock()
+	var oldest *LiveSession
+	for _, l := range sessions.live {
+		if l == self || l.playerID() != id {
+			continue
+		}
+		if oldest == nil || l.Seq < oldest.Seq {
+			oldest = l
+		}
+	}
+	return oldest
+}
+
+// checkDuplicate offers the choices of PopupDuplicate when the player is
+// already connected in another session.
+func (m Model) checkDuplicate() Model {
+	if other := otherSession(m.SessionID, m.Live); other != nil {
+		m.Duplicate = other
+		m.PopupActive = true
+		m.PopupType = PopupDuplicate
+	}
+	return m
+}
+
+// updateDuplicate handles the keys of PopupDuplicate: take over the other
+// session, watch its game from here, or carry on as a second session.
+func updateDuplicate(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	other := m.Duplicate
+	switch msg.String() {
+	case "t":
+		m.PopupActive = false
+		m.Duplicate = nil
+		name := other.info().Name
+		room := other.handOver()
+		if name == "" {
+			return m, nil
+		}
+		m.MyName = name
+		m.State = StateGameSelect
+		if room == "" {
+			return m, nil
+		}
+		var spin tea.Cmd
+		m, spin = m.startLoading(loadingJoin)
+		return m, tea.Batch(joinRoomCmd(room, m.SessionID, name), spin)
+	case "s":
+		info := other.info()
+		if info.Room == "" {
+			return m, nil
+		}
+		m.PopupActive = false
+		m.Duplicate = nil
+		if info.Name != "" {
+			m.MyName = info.Name
+			m.State = StateGameSelect
+		}
+		var spin tea.Cmd
+		m, spin = m.startLoading(loadingJoin)
+		return m, tea.Batch(watchOwnGameCmd(info.Room), spin)
+	case "esc", "c":
+		m.PopupActive = false
+		m.Duplicate = nil
+	}
+	return m, nil
+}
+
+// renderDuplicate is the PopupDuplicate box.
+func renderDuplicate(m Model) string {
+	info := m.Duplicate.info()
+	where := "on the " + info.Screen + " screen"
+	if info.Room != "" {
+		where = "in room " + info.Room
+	}
+	choices := []string{styles.ItemFocused.Render("[T] Take over")}
+	if info.Room != "" {
+		choices = append(choices, "  ", styles.ItemFocused.Render("[S] Spectate your game"))
+	}
+	return styles.PopupBox.Render(lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render("ALREADY CONNECTED"),
+		"",
+		fmt.Sprintf("You're already connected from %s (%s).", info.Addr, where),
+		"Taking over disconnects the other session and keeps your seat.",
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Center, choices...),
+		"",
+		styles.Subtle.Render("[Esc] Continue here anyway"),
+	))
+}
+
+// watchOwnGameCmd opens the player's own room as a spectator, without
+// joining it: the seat stays with the other session.
+func watchOwnGameCmd(code string) tea.Cmd {
+	return storeCmd("Opening your game", func() tea.Msg {
+		r, err := db.GetRoom(code)
+		if err != nil {
+			return errMsg(err)
+		}
+		return roomJoinedMsg{code: code, side: "Spectator", gameType: r.GameType, watchOnly: true}
+	})
+}
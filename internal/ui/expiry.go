@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// roomTouchEvery is how often a host's key presses in the lobby restart
+// the janitor's clock on their room, at most.
+const roomTouchEvery = time.Minute
+
+// expiryWarnAfter is when the lobby countdown turns red.
+const expiryWarnAfter = 5 * time.Minute
+
+// updateRoomExpiry keeps a waiting room alive while its host is at the
+// keyboard: any key in the lobby counts as activity. A public room is
+// also relisted every config.RelistAfter for as long as the host waits.
+func updateRoomExpiry(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	if m.State != StateLobby || m.MySide != "X" || m.RoomCode == "" {
+		return m, nil
+	}
+	if left, ok := m.relistIn(); ok && left == 0 {
+		m.Game.ListedAt = time.Now().Unix()
+		m.Game.UpdatedAt = m.Game.ListedAt
+		return m, relistRoomCmd(m.RoomCode)
+	}
+	if _, ok := msg.(tea.KeyMsg); !ok || time.Since(time.Unix(m.Game.UpdatedAt, 0)) < roomTouchEvery {
+		return m, nil
+	}
+	m.Game.UpdatedAt = time.Now().Unix()
+	return m, touchRoomCmd(m.RoomCode)
+}
+
+// relistIn is how long until the host's public lobby goes back to the
+// top of the public list, and whether it will at all.
+func (m Model) relistIn() (time.Duration, bool) {
+	if config.RelistAfter <= 0 || !m.Game.IsPublic || m.Game.PlayerO != "" {
+		return 0, false
+	}
+	left := time.Until(time.Unix(m.Game.ListedAt, 0).Add(config.RelistAfter))
+	if left < 0 {
+		left = 0
+	}
+	return left, true
+}
+
+// renderRoomExpiry tells the host how long their empty lobby has left,
+// e.g. "Room expires in 59:12 • any activity resets it".
+func renderRoomExpiry(m Model) string {
+	if m.MySide != "X" || m.Game.UpdatedAt == 0 {
+		return ""
+	}
+	left := time.Until(m.Game.ExpiresAt())
+	if left < 0 {
+		left = 0
+	}
+	text := "Room expires in " + clockText(left) + " • any activity resets it"
+	if relist, ok := m.relistIn(); ok {
+		text += "\nBack at the top of the public list in " + clockText(relist)
+	}
+	if left <= expiryWarnAfter {
+		return styles.Err.Render(text)
+	}
+	return styles.Subtle.Render(text)
+}
+
+func relistRoomCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.RelistRoom(code); err != nil {
+			log.Warn("Could not relist room", "room", code, "err", err)
+		}
+		return nil
+	}
+}
+
+func touchRoomCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.TouchRoom(code); err != nil {
+			log.Warn("Could not keep room alive", "room", code, "err", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Most polls and bus events bring back the room exactly as it is on
+// screen. Those leave the model alone apart from LastSync, and View hands
+// back the frame it drew last instead of rendering the same screen again.
+// The "last sync" reading catches up on the status bar's next tick.
+
+// frameCache holds the last frame View drew.
+type frameCache struct{ frame string }
+
+// unchangedRoom handles a room state for the room on screen that is
+// identical to the one shown, and reports whether msg was one.
+func (m Model) unchangedRoom(msg tea.Msg) (Model, tea.Cmd, bool) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case roomUpdateMsg:
+		if m.RoomCode == "" || msg.Code != m.RoomCode || !reflect.DeepEqual(db.Room(msg), m.Game) {
+			return m, nil, false
+		}
+		cmd = pollCmd(m.RoomCode, m.pollInterval())
+	case roomEventMsg:
+		if m.RoomCode == "" || msg.code != m.RoomCode {
+			return m, nil, false
+		}
+		var r db.Room
+		if err := json.Unmarshal(msg.data, &r); err != nil || !reflect.DeepEqual(r, m.Game) {
+			return m, nil, false
+		}
+		cmd = waitRoomEventCmd(msg.code, m.RoomEvents)
+	default:
+		return m, nil, false
+	}
+	m.LastSync = time.Now()
+	m.Unchanged = true
+	return m, cmd, true
+}
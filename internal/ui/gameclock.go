@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+)
+
+// renderGameClock is the line under the board with how long the game has
+// run and each player's thinking time, e.g. "4:05 played • ann 2:10 •
+// bob 1:55", or "" before the game starts. The times come from the room,
+// so spectators and players coming back see the same clocks.
+func renderGameClock(m Model) string {
+	now := time.Now()
+	elapsed := m.Game.Elapsed(now)
+	if elapsed == 0 {
+		return ""
+	}
+	parts := []string{clockText(elapsed) + " played"}
+	for _, p := range []struct{ id, name string }{
+		{m.Game.PlayerX, m.Game.PlayerXName},
+		{m.Game.PlayerO, m.Game.PlayerOName},
+	} {
+		if p.id == "" || p.id == db.BotID {
+			continue
+		}
+		parts = append(parts, displayName(p.name)+" "+clockText(m.Game.ThinkingTime(p.id, now)))
+	}
+	return styles.Subtle.Render(strings.Join(parts, " • "))
+}
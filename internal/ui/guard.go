@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aminshahid573/termplay/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+)
+
+// Guard keeps one session from wearing down the whole server. Store calls
+// (see storeCmd) each run in a goroutine of their own, so the ones still
+// running stand for both the session's goroutines and its outstanding
+// database calls. Commands that only wait, on a subscription or a tick,
+// cost nothing and aren't counted, so a session in several tabs has the
+// same headroom as one in a single room. A session with too many store
+// calls running, or asking for a screen or frame past the limits in
+// config, is closed.
+type Guard struct {
+	pending atomic.Int64 // store calls running now
+	tripped atomic.Bool
+
+	id    string              // session, for the log
+	close func()              // ends the session
+	abuse func(reason string) // writes the auth log
+}
+
+func newGuard(s ssh.Session, id string, abuse func(string)) *Guard {
+	g := &Guard{id: id, close: func() {}, abuse: abuse}
+	if s != nil {
+		g.close = func() { s.Close() }
+	}
+	return g
+}
+
+// track wraps cmd so the store calls it starts count as pending while
+// they run. Batches are unwrapped, so each of their commands is tracked
+// on its own.
+func (g *Guard) track(cmd tea.Cmd) tea.Cmd {
+	if g == nil || cmd == nil {
+		return cmd
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			for i, c := range msg {
+				msg[i] = g.track(c)
+			}
+			return msg
+		case storeCallMsg:
+			n := g.pending.Add(1)
+			defer g.pending.Add(-1)
+			if config.SessionMaxPending > 0 && n > int64(config.SessionMaxPending) {
+				g.trip(fmt.Sprintf("%d store calls pending", n))
+				return nil
+			}
+			return msg.run()
+		default:
+			return msg
+		}
+	}
+}
+
+// checkSize trips the guard on a terminal too big to draw for.
+func (g *Guard) checkSize(w, h int) {
+	if g == nil || config.SessionMaxCells <= 0 || w*h <= config.SessionMaxCells {
+		return
+	}
+	g.trip(fmt.Sprintf("terminal %dx%d", w, h))
+}
+
+// frame passes on a rendered frame, unless it is over
+// config.SessionMaxFrame bytes or the session is being closed.
+func (g *Guard) frame(s string) string {
+	if g == nil {
+		return s
+	}
+	if g.tripped.Load() {
+		return ""
+	}
+	if config.SessionMaxFrame > 0 && len(s) > config.SessionMaxFrame {
+		g.trip(fmt.Sprintf("frame of %d bytes", len(s)))
+		return ""
+	}
+	return s
+}
+
+// trip closes the session, once, logging why.
+func (g *Guard) trip(reason string) {
+	if !g.tripped.CompareAndSwap(false, true) {
+		return
+	}
+	log.Warn("Closing session over its limits", "id", g.id, "reason", reason)
+	if g.abuse != nil {
+		g.abuse("session limit: " + reason)
+	}
+	g.close()
+}
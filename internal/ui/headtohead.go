@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// HeadToHeadState is the lifetime record against the opponent on screen,
+// see db.HeadToHead.
+type HeadToHeadState struct {
+	With   string         // opponent the record is for, or being loaded for
+	Games  int            // games finished in the room when it was loaded
+	Record *db.HeadToHead // nil until loaded
+}
+
+type headToHeadMsg struct {
+	with   string
+	record db.HeadToHead
+}
+
+// updateHeadToHead loads the record once an opponent sits down, and again
+// after each game they finish, so it is current between games.
+func updateHeadToHead(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	if msg, ok := msg.(headToHeadMsg); ok && msg.with == m.H2H.With {
+		m.H2H.Record = &msg.record
+		return m, nil
+	}
+	opp := m.opponentID()
+	if m.State != StateGame || !db.CountsHeadToHead(m.SessionID, opp) {
+		m.H2H = HeadToHeadState{}
+		return m, nil
+	}
+	if opp == m.H2H.With && m.Game.Games == m.H2H.Games {
+		return m, nil
+	}
+	if opp != m.H2H.With {
+		m.H2H = HeadToHeadState{With: opp, Games: m.Game.Games}
+		return m, loadHeadToHeadCmd(m.SessionID, opp, 0)
+	}
+	// The finished game is recorded just after the room is published
+	m.H2H.Games = m.Game.Games
+	return m, loadHeadToHeadCmd(m.SessionID, opp, h2hRecordDelay)
+}
+
+// h2hRecordDelay is how long to give a finished game to be recorded
+// before reloading the record.
+const h2hRecordDelay = 2 * time.Second
+
+func loadHeadToHeadCmd(me, opp string, after time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(after)
+		h, err := db.GetHeadToHead(me, opp)
+		if err != nil {
+			return nil
+		}
+		return headToHeadMsg{with: opp, record: h}
+	}
+}
+
+// renderHeadToHead is the lifetime record against the opponent, e.g.
+// "You lead bob 7–4 lifetime", or "" before it has loaded.
+func renderHeadToHead(m Model) string {
+	h := m.H2H.Record
+	if h == nil {
+		return ""
+	}
+	name := m.Game.PlayerOName
+	if m.MySide == "O" {
+		name = m.Game.PlayerXName
+	}
+	name = displayName(name)
+
+	won, lost, drawn := h.Of(m.SessionID, m.H2H.With)
+	var line string
+	switch {
+	case won+lost+drawn == 0:
+		line = "First meeting with " + name
+	case won > lost:
+		line = fmt.Sprintf("You lead %s %d–%d lifetime", name, won, lost)
+	case won < lost:
+		line = fmt.Sprintf("%s leads you %d–%d lifetime", name, lost, won)
+	default:
+		line = fmt.Sprintf("You and %s are level %d–%d lifetime", name, won, lost)
+	}
+	if drawn > 0 {
+		line += fmt.Sprintf(", %d drawn", drawn)
+	}
+	return styles.Subtle.Render(line)
+}
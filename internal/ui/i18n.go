@@ -0,0 +1,132 @@
+package ui
+
+// Minimal translations for the navigation screens. Anything missing falls
+// back to English, so new strings never break a locale.
+var translations = map[string]map[string]string{
+	"es": {
+		"MAIN MENU":                             "MENÚ PRINCIPAL",
+		"SELECT GAME":                           "ELIGE UN JUEGO",
+		"SETTINGS":                              "AJUSTES",
+		"Create Room":                           "Crear sala",
+		"Join with Code":                        "Unirse con código",
+		"Public Rooms":                          "Salas públicas",
+		"Watch a Game":                          "Ver una partida",
+		"Lobby Chat":                            "Chat general",
+		"LOBBY CHAT":                            "CHAT GENERAL",
+		"Random":                                "Al azar",
+		"Top rated":                             "Mejor valorada",
+		"Quit":                                  "Salir",
+		"Settings":                              "Ajustes",
+		"How to Play":                           "Cómo jugar",
+		"Puzzles":                               "Acertijos",
+		"Leaderboard":                           "Clasificación",
+		"Server Status":                         "Estado del servidor",
+		"SERVER STATUS":                         "ESTADO DEL SERVIDOR",
+		"LEADERBOARD":                           "CLASIFICACIÓN",
+		"New here? Try How to Play":             "¿Eres nuevo? Prueba Cómo jugar",
+		"Theme":                                 "Tema",
+		"Keybindings":                           "Teclas",
+		"Reduce motion":                         "Reducir movimiento",
+		"Bell on turn":                          "Aviso de turno",
+		"ASCII mode":                            "Modo ASCII",
+		"Mark":                                  "Ficha",
+		"Language":                              "Idioma",
+		"Saved to your SSH key":                 "Guardado con tu clave SSH",
+		"Type an emoji for a custom mark":       "Escribe un emoji para una ficha propia",
+		"Your name is reserved":                 "Tu nombre está reservado",
+		"Shown as":                              "Se muestra como",
+		"R: reserve this name":                  "R: reservar este nombre",
+		"connect with an SSH key to reserve it": "conéctate con una clave SSH para reservarlo",
+		"G: link another machine":               "G: vincular otro equipo",
+		"E: enter a link code":                  "E: introducir un código",
+		"U: unlink":                             "U: desvincular",
+		"This key is linked to your account":    "Esta clave está vinculada a tu cuenta",
+		"Link code":                             "Código",
+		"Link code from your other machine":     "Código de tu otro equipo",
+		"Charm token":                           "Token de Charm",
+		"Signed in with Charm":                  "Sesión iniciada con Charm",
+		"C: sign in again":                      "C: volver a iniciar sesión",
+		"C: sign in with Charm":                 "C: iniciar sesión con Charm",
+		"press E in Settings on your other machine":                                                "pulsa E en Ajustes en tu otro equipo",
+		"Playing as a guest: results aren't saved or ranked. Connect with an SSH key to register.": "Juegas como invitado: tus resultados no se guardan ni puntúan. Conéctate con una clave SSH para registrarte.",
+
+		// Keys and actions of the status line, see statusline.go
+		"Back":        "Volver",
+		"Page":        "Página",
+		"Navigate":    "Navegar",
+		"Select":      "Elegir",
+		"Change":      "Cambiar",
+		"Save & Back": "Guardar y volver",
+		"More":        "Más",
+
+		"still working": "sigue en curso", // see store.go
+	},
+	"fr": {
+		"MAIN MENU":                             "MENU PRINCIPAL",
+		"SELECT GAME":                           "CHOISIR UN JEU",
+		"SETTINGS":                              "PARAMÈTRES",
+		"Create Room":                           "Créer un salon",
+		"Join with Code":                        "Rejoindre par code",
+		"Public Rooms":                          "Salons publics",
+		"Watch a Game":                          "Regarder une partie",
+		"Lobby Chat":                            "Discussion générale",
+		"LOBBY CHAT":                            "DISCUSSION GÉNÉRALE",
+		"Random":                                "Au hasard",
+		"Top rated":                             "Mieux classée",
+		"Quit":                                  "Quitter",
+		"Settings":                              "Paramètres",
+		"How to Play":                           "Comment jouer",
+		"Puzzles":                               "Énigmes",
+		"Leaderboard":                           "Classement",
+		"Server Status":                         "État du serveur",
+		"SERVER STATUS":                         "ÉTAT DU SERVEUR",
+		"LEADERBOARD":                           "CLASSEMENT",
+		"New here? Try How to Play":             "Nouveau ? Essayez Comment jouer",
+		"Theme":                                 "Thème",
+		"Keybindings":                           "Touches",
+		"Reduce motion":                         "Réduire les animations",
+		"Bell on turn":                          "Alerte de tour",
+		"ASCII mode":                            "Mode ASCII",
+		"Mark":                                  "Symbole",
+		"Language":                              "Langue",
+		"Saved to your SSH key":                 "Enregistré avec votre clé SSH",
+		"Type an emoji for a custom mark":       "Tapez un emoji pour un symbole perso",
+		"Your name is reserved":                 "Votre nom est réservé",
+		"Shown as":                              "Affiché comme",
+		"R: reserve this name":                  "R: réserver ce nom",
+		"connect with an SSH key to reserve it": "connectez-vous avec une clé SSH pour le réserver",
+		"G: link another machine":               "G: lier une autre machine",
+		"E: enter a link code":                  "E: saisir un code",
+		"U: unlink":                             "U: délier",
+		"This key is linked to your account":    "Cette clé est liée à votre compte",
+		"Link code":                             "Code",
+		"Link code from your other machine":     "Code de votre autre machine",
+		"Charm token":                           "Jeton Charm",
+		"Signed in with Charm":                  "Connecté avec Charm",
+		"C: sign in again":                      "C: se reconnecter",
+		"C: sign in with Charm":                 "C: se connecter avec Charm",
+		"press E in Settings on your other machine":                                                "appuyez sur E dans Paramètres sur l'autre machine",
+		"Playing as a guest: results aren't saved or ranked. Connect with an SSH key to register.": "Vous jouez en invité : vos résultats ne sont ni enregistrés ni classés. Connectez-vous avec une clé SSH pour vous inscrire.",
+
+		// Keys and actions of the status line, see statusline.go
+		"Back":        "Retour",
+		"Page":        "Page",
+		"Navigate":    "Naviguer",
+		"Select":      "Choisir",
+		"Change":      "Modifier",
+		"Save & Back": "Enregistrer",
+		"More":        "Plus",
+		"Esc":         "Échap",
+		"Enter":       "Entrée",
+
+		"still working": "toujours en cours", // see store.go
+	},
+}
+
+// tr returns s in the player's language, or s itself if untranslated.
+func (m Model) tr(s string) string {
+	if t, ok := translations[m.Settings.Locale][s]; ok {
+		return t
+	}
+	return s
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+)
+
+// Enhanced key reporting. Legacy terminal input can't tell Ctrl+Enter
+// from Enter, or Ctrl+I from Tab. Terminals speaking the kitty keyboard
+// protocol report such combinations unambiguously once asked to, and
+// ignore the request otherwise. Bubble Tea v1 doesn't know those reports,
+// so a KeyReader turns them back into the legacy bytes it does know, and
+// Ctrl+Enter, which has none, into keyCtrlEnter.
+const (
+	// EnhancedKeysOn asks the terminal to disambiguate escape codes.
+	EnhancedKeysOn = "\x1b[>1u"
+	// EnhancedKeysOff puts the terminal back the way it was.
+	EnhancedKeysOff = "\x1b[<u"
+)
+
+// keyCtrlEnter stands in for Ctrl+Enter: a private-use code point that no
+// keyboard types, which Bubble Tea hands over as a rune key.
+const keyCtrlEnter = '\uE000'
+
+// ctrlEnter is what a KeyMsg for Ctrl+Enter reads as.
+const ctrlEnter = string(keyCtrlEnter)
+
+// Modifier bits of a key report, less one as sent.
+const (
+	modShift = 1 << iota
+	modAlt
+	modCtrl
+	modLocks = 64 | 128 // caps and num lock, which don't change the key
+)
+
+// keyboardKey marks a session whose input goes through a KeyReader.
+type keyboardKey struct{}
+
+// NewKeyReader returns the input of s with enhanced key reports made
+// legible to Bubble Tea, and marks s so the program asks for them.
+func NewKeyReader(s ssh.Session) io.Reader {
+	s.Context().SetValue(keyboardKey{}, true)
+	return keyReader{s}
+}
+
+// enhancedKeys reports whether the input of s goes through a KeyReader.
+func enhancedKeys(s ssh.Session) bool {
+	return s != nil && s.Context().Value(keyboardKey{}) == true
+}
+
+type keyReader struct {
+	r io.Reader
+}
+
+// Read translates in place: a translation is never longer than the
+// report it replaces. A report split across two reads is passed on as it
+// is, and Bubble Tea drops it as an unknown sequence.
+func (k keyReader) Read(p []byte) (int, error) {
+	n, err := k.r.Read(p)
+	return copy(p, translateKeys(p[:n])), err
+}
+
+// translateKeys replaces each CSI key report in b, ESC [ code ; mods u,
+// with its legacy form. Reports without one are left alone.
+func translateKeys(b []byte) []byte {
+	if !bytes.Contains(b, []byte("\x1b[")) {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		i := bytes.Index(b, []byte("\x1b["))
+		if i < 0 {
+			break
+		}
+		out = append(out, b[:i]...)
+		b = b[i:]
+		end := 2
+		for end < len(b) && (b[end] >= '0' && b[end] <= '9' || b[end] == ';' || b[end] == ':') {
+			end++
+		}
+		if end < len(b) && b[end] == 'u' {
+			if key, ok := legacyKey(string(b[2:end])); ok {
+				out = append(out, key...)
+				b = b[end+1:]
+				continue
+			}
+		}
+		out = append(out, b[:2]...)
+		b = b[2:]
+	}
+	return append(out, b...)
+}
+
+// legacyKey returns the bytes a legacy terminal sends for the key in the
+// parameters of a report, the code point and modifiers.
+func legacyKey(params string) ([]byte, bool) {
+	fields := strings.Split(params, ";")
+	code, err := strconv.Atoi(strings.Split(fields[0], ":")[0])
+	if err != nil {
+		return nil, false
+	}
+	mods := 0
+	if len(fields) > 1 {
+		if mods, err = strconv.Atoi(strings.Split(fields[1], ":")[0]); err != nil || mods < 1 {
+			return nil, false
+		}
+		mods = (mods - 1) &^ modLocks
+	}
+	if mods&^(modShift|modAlt|modCtrl) != 0 {
+		// Super, hyper and meta have no legacy form
+		return nil, false
+	}
+
+	var key []byte
+	switch {
+	case code == '\r' && mods&modCtrl != 0:
+		key = []byte(ctrlEnter)
+	case code == '\r':
+		key = []byte{'\r'}
+	case code == '\t' && mods&modShift != 0:
+		key = []byte("\x1b[Z")
+	case code == '\t':
+		key = []byte{'\t'}
+	case code == 0x1b:
+		key = []byte{0x1b}
+	case code == 0x7f:
+		key = []byte{0x7f}
+	case mods&modCtrl != 0 && (code == ' ' || code >= '@' && code <= 'z'):
+		key = []byte{byte(code) & 0x1f}
+	case mods&modCtrl == 0 && code >= ' ':
+		key = []byte(string(rune(code)))
+	default:
+		return nil, false
+	}
+	if mods&modAlt != 0 {
+		key = append([]byte{0x1b}, key...)
+	}
+	return key, true
+}
+
+// enhancedKeysCmd asks the player's terminal for enhanced key reports,
+// if their input is translated. The program has switched to the
+// alternate screen by now, which keeps its own keyboard settings, so
+// leaving it when the program ends undoes the request.
+func (m Model) enhancedKeysCmd() tea.Cmd {
+	if !m.EnhancedKeys || m.Out == nil {
+		return nil
+	}
+	out := m.Out
+	return func() tea.Msg {
+		io.WriteString(out, EnhancedKeysOn)
+		return nil
+	}
+}
+
+// jumpCursor answers Shift+arrows on a board of size squares a side by
+// moving the cursor all the way to that edge. flipped mirrors the board,
+// as chess does for Black. ok is false for any other key.
+func (m Model) jumpCursor(key string, size int, flipped bool) (Model, bool) {
+	near, far := 0, size-1
+	if flipped {
+		near, far = far, near
+	}
+	switch key {
+	case "shift+up":
+		m.CursorR = near
+	case "shift+down":
+		m.CursorR = far
+	case "shift+left":
+		m.CursorC = near
+	case "shift+right":
+		m.CursorC = far
+	default:
+		return m, false
+	}
+	return m, true
+}
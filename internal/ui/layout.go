@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The game screen comes in two arrangements. On wide terminals, and on
+// ones too short to stack everything, the move history and chat sit in a
+// panel beside the board; otherwise they are stacked under it. Only the
+// terminal's size decides, so the board doesn't jump about as the chat
+// grows.
+
+type layout int
+
+const (
+	layoutStacked layout = iota
+	layoutSideBySide
+)
+
+const (
+	sidePanelWidth = 32 // columns of the panel beside the board
+	layoutGap      = 4  // columns between the board and the panel
+
+	wideWidth    = 100 // from here on the panel always goes beside the board
+	sideMinWidth = 76  // the narrowest terminal the panel fits beside it
+	tallHeight   = 34  // rows needed to stack the panel under the board
+)
+
+// chooseLayout picks the arrangement for a width x height terminal.
+func chooseLayout(width, height int) layout {
+	switch {
+	case width >= wideWidth:
+		return layoutSideBySide
+	case width >= sideMinWidth && height < tallHeight:
+		// Too short to stack, but there is room beside the board
+		return layoutSideBySide
+	}
+	return layoutStacked
+}
+
+// boardWidth is how many columns the board may take up, leaving room for
+// the side panel if there is one.
+func (m Model) boardWidth() int {
+	if chooseLayout(m.Width, m.Height) == layoutSideBySide {
+		return m.Width - sidePanelWidth - layoutGap
+	}
+	return m.Width
+}
+
+// layoutGame places the move history and chat around game, the rendered
+// board with its header and status.
+func (m Model) layoutGame(game string) string {
+	moves := moveHistory(m.Game)
+	chat := renderChat(m)
+
+	if chooseLayout(m.Width, m.Height) == layoutStacked {
+		rows := []string{game}
+		if line := historyLine(moves, m.Width); line != "" {
+			rows = append(rows, "", line)
+		}
+		if chat != "" {
+			rows = append(rows, "", chat)
+		}
+		return lipgloss.JoinVertical(lipgloss.Center, rows...)
+	}
+
+	panel := lipgloss.NewStyle().Width(sidePanelWidth)
+	chatBlock := ""
+	if chat != "" {
+		chatBlock = lipgloss.JoinVertical(lipgloss.Left, "", styles.Subtle.Render("CHAT"), panel.Render(chat))
+	}
+	// The history gets whatever height the board and chat leave over
+	fit := max(3, lipgloss.Height(game)-lipgloss.Height(chatBlock)-1)
+	if len(moves) > fit {
+		moves = append([]string{"…"}, moves[len(moves)-fit+1:]...)
+	}
+	if len(moves) == 0 {
+		moves = []string{styles.Subtle.Render("no moves yet")}
+	}
+	rows := []string{styles.Subtle.Render("MOVES"), strings.Join(moves, "\n")}
+	if chatBlock != "" {
+		rows = append(rows, chatBlock)
+	}
+
+	side := panel.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	return lipgloss.JoinHorizontal(lipgloss.Top, game, strings.Repeat(" ", layoutGap), side)
+}
+
+// historyLine is the latest moves on one line of at most width columns,
+// for the stacked layout.
+func historyLine(moves []string, width int) string {
+	if len(moves) == 0 {
+		return ""
+	}
+	shown := moves[len(moves)-1:]
+	for i := len(moves) - 2; i >= 0; i-- {
+		next := append([]string{moves[i]}, shown...)
+		if lipgloss.Width("Moves: …  "+strings.Join(next, "  ")) > width {
+			shown = append([]string{"…"}, shown...)
+			break
+		}
+		shown = next
+	}
+	return styles.Subtle.Render("Moves: " + strings.Join(shown, "  "))
+}
+
+// tttCellNames name the tic-tac-toe cells in the move history.
+var tttCellNames = [9]string{
+	"top left", "top", "top right",
+	"left", "center", "right",
+	"bottom left", "bottom", "bottom right",
+}
+
+// moveHistory lists the moves of the game in r, numbered the way each
+// game counts them: every mark in tic-tac-toe, each White and Black pair
+// in chess.
+func moveHistory(r db.Room) []string {
+	var out []string
+	if r.GameType == "chess" {
+		for i := 0; i < len(r.Moves); i += 2 {
+			line := fmt.Sprintf("%d. %s", i/2+1, chessMoveLabel(r.Moves[i]))
+			if i+1 < len(r.Moves) {
+				line += " " + chessMoveLabel(r.Moves[i+1])
+			}
+			out = append(out, line)
+		}
+		return out
+	}
+	for i, mv := range r.Moves {
+		label := mv
+		if mv == "swap" {
+			label = "sides swapped"
+		} else if n, err := strconv.Atoi(mv); err == nil && n >= 0 && n < len(tttCellNames) {
+			label = tttCellNames[n]
+		}
+		out = append(out, fmt.Sprintf("%d. %s", i+1, label))
+	}
+	return out
+}
+
+// chessMoveLabel turns a stored move like "e2e4" into "e2-e4".
+func chessMoveLabel(mv string) string {
+	if len(mv) == 4 {
+		return mv[:2] + "-" + mv[2:]
+	}
+	return mv
+}
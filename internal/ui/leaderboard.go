@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// leaderboardPageSize is how many players are listed per page.
+const leaderboardPageSize = 10
+
+// LeaderboardState is the page of the leaderboard on screen. Cursors is a
+// stack of the cursors that produced each earlier page, for paging back.
+type LeaderboardState struct {
+	Entries []db.LeaderboardEntry
+	Cursor  string
+	Cursors []string
+	Next    string
+	Rank    int // rank of the first entry on this page
+	BuiltAt time.Time
+	Loading bool
+}
+
+type leaderboardPageMsg struct {
+	cursor  string
+	entries []db.LeaderboardEntry
+	next    string
+	rank    int
+	builtAt time.Time
+}
+
+func updateLeaderboard(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	l := &m.Leaderboard
+	switch msg := msg.(type) {
+	case leaderboardPageMsg:
+		l.Loading = false
+		l.Cursor = msg.cursor
+		l.Entries = msg.entries
+		l.Next = msg.next
+		l.Rank = msg.rank
+		l.BuiltAt = msg.builtAt
+	case tea.KeyMsg:
+		if l.Loading && msg.String() != "esc" {
+			return m, nil
+		}
+		switch msg.String() {
+		case "right", "l", "n":
+			if l.Next != "" {
+				l.Cursors = append(l.Cursors, l.Cursor)
+				l.Loading = true
+				return m, leaderboardPageCmd(l.Next)
+			}
+		case "left", "h", "p":
+			if len(l.Cursors) > 0 {
+				prev := l.Cursors[len(l.Cursors)-1]
+				l.Cursors = l.Cursors[:len(l.Cursors)-1]
+				l.Loading = true
+				return m, leaderboardPageCmd(prev)
+			}
+		case "esc", "q":
+			m.State = StateGameSelect
+			m.MenuIndex = gameSelectLeaderboard
+		}
+	}
+	return m, nil
+}
+
+func renderLeaderboard(m Model) string {
+	l := m.Leaderboard
+	title := styles.Title.Render(m.tr("LEADERBOARD"))
+	if l.Loading && l.Entries == nil {
+		return lipgloss.JoinVertical(lipgloss.Center, title, "Loading...")
+	}
+	if len(l.Entries) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Center, title, styles.Subtle.Render("No ranked games yet"))
+	}
+
+	rows := []string{styles.Subtle.Render(fmt.Sprintf("%4s  %s %4s %4s %4s", "#", padWidth("Name", 18), "W", "L", "D"))}
+	for i, e := range l.Entries {
+		line := fmt.Sprintf("%4d  %s %4d %4d %4d", l.Rank+i, padWidth(taggedName(e.ID, fitWidth(e.Name, 12)), 18), e.Wins, e.Losses, e.Draws)
+		if e.ID == m.SessionID {
+			rows = append(rows, styles.ItemFocused.Render(line))
+		} else {
+			rows = append(rows, styles.ItemBlurred.Render(line))
+		}
+	}
+
+	page := fmt.Sprintf("Page %d", len(l.Cursors)+1)
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+		"",
+		styles.Subtle.Render(page+" • Updated "+l.BuiltAt.Format("2006-01-02 15:04")),
+	)
+	if guest := m.guestNotice(); guest != "" {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, guest)
+	}
+	return content
+}
+
+func leaderboardPageCmd(cursor string) tea.Cmd {
+	return func() tea.Msg {
+		entries, next, builtAt, err := db.LeaderboardPage(cursor, leaderboardPageSize)
+		if err != nil {
+			return errMsg(err)
+		}
+		return leaderboardPageMsg{
+			cursor:  cursor,
+			entries: entries,
+			next:    next,
+			rank:    db.LeaderboardRank(cursor),
+			builtAt: builtAt,
+		}
+	}
+}
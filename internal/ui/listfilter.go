@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ListFilter narrows the public list on top of the text search. Each
+// field is one chip; "" lets any room through.
+type ListFilter struct {
+	Game  string // "tictactoe" or "chess"
+	Mode  string // "ranked" or "casual"
+	Clock string // "timed" or "untimed"
+}
+
+// filterChip is one chip above the public list, cycled with its key.
+// Control keys, so typing still goes to the search.
+type filterChip struct {
+	key     string
+	label   string
+	options []string
+	field   func(f *ListFilter) *string
+}
+
+var filterChips = []filterChip{
+	{"ctrl+g", "Game", []string{"", "tictactoe", "chess"}, func(f *ListFilter) *string { return &f.Game }},
+	{"ctrl+o", "Mode", []string{"", "ranked", "casual"}, func(f *ListFilter) *string { return &f.Mode }},
+	{"ctrl+k", "Clock", []string{"", "timed", "untimed"}, func(f *ListFilter) *string { return &f.Clock }},
+}
+
+// matches reports whether r gets through every chip.
+func (f ListFilter) matches(r db.Room) bool {
+	game := r.GameType
+	if game == "" {
+		game = "tictactoe"
+	}
+	mode := "casual"
+	if r.Ranked {
+		mode = "ranked"
+	}
+	clock := "untimed"
+	if r.MoveTime() > 0 {
+		clock = "timed"
+	}
+	return (f.Game == "" || f.Game == game) &&
+		(f.Mode == "" || f.Mode == mode) &&
+		(f.Clock == "" || f.Clock == clock)
+}
+
+// updateListFilter cycles the chip for key, if it has one. It reports
+// whether it used the key.
+func updateListFilter(m Model, msg tea.KeyMsg) (Model, bool) {
+	for _, c := range filterChips {
+		if msg.String() != c.key {
+			continue
+		}
+		v := c.field(&m.ListFilter)
+		next := 0
+		for i, o := range c.options {
+			if o == *v {
+				next = (i + 1) % len(c.options)
+			}
+		}
+		*v = c.options[next]
+		m.ListSelectedRow = 0
+		return m, true
+	}
+	return m, false
+}
+
+// renderFilterChips draws the chips, e.g. "Game: chess  Mode: any  Clock:
+// any", with the ones narrowing the list highlighted.
+func renderFilterChips(m Model) string {
+	return renderChips(m.ListFilter)
+}
+
+// renderChips draws the chips of f.
+func renderChips(f ListFilter) string {
+	var chips []string
+	for _, c := range filterChips {
+		v := *c.field(&f)
+		if v == "" {
+			chips = append(chips, styles.Subtle.Render(c.label+": any"))
+			continue
+		}
+		if v == "tictactoe" {
+			v = "tic-tac-toe"
+		}
+		chips = append(chips, styles.Highlight.Render(c.label+": "+v))
+	}
+	return strings.Join(chips, "  ")
+}
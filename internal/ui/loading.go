@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// What the player is waiting on while Busy, shown next to the spinner.
+const (
+	loadingJoin    = "Joining the room"
+	loadingCreate  = "Creating the room"
+	loadingRooms   = "Loading public rooms"
+	loadingWatch   = "Finding a game to watch"
+	loadingBotGame = "Starting a bot game"
+	loadingName    = "Checking your name"
+)
+
+// startLoading marks the player busy with what until its command answers,
+// so a second Enter doesn't send it again, and starts the spinner.
+func (m Model) startLoading(what string) (Model, tea.Cmd) {
+	m.Busy = true
+	m.Loading = what
+	m.Err = nil
+	return m.spin()
+}
+
+// spin starts the spinner, unless it is already turning. It stops by
+// itself once nothing is loading or slow.
+func (m Model) spin() (Model, tea.Cmd) {
+	if m.Spinning {
+		return m, nil
+	}
+	m.Spinning = true
+	if m.Settings.ASCII {
+		m.Spinner.Spinner = spinner.Line
+	} else {
+		m.Spinner.Spinner = spinner.Dot
+	}
+	return m, m.Spinner.Tick
+}
+
+// updateSpinner turns the spinner while there is something to wait on.
+func updateSpinner(m Model, msg spinner.TickMsg) (Model, tea.Cmd) {
+	if !m.loading() && len(m.Slow) == 0 && m.State != StateRoomAlert {
+		m.Spinning = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.Spinner, cmd = m.Spinner.Update(msg)
+	return m, cmd
+}
+
+// loading reports whether a command the player started is pending.
+func (m Model) loading() bool {
+	return m.Busy && m.Loading != ""
+}
+
+// spinnerFrame is the spinner as it is now; reduced motion holds it still.
+func (m Model) spinnerFrame() string {
+	if m.reduceMotion() {
+		return styles.Highlight.Render(m.Spinner.Spinner.Frames[0])
+	}
+	return styles.Highlight.Render(m.Spinner.View())
+}
+
+// renderLoading is "⣾ Joining the room…" while the player waits on a
+// command, or "".
+func renderLoading(m Model) string {
+	if !m.loading() {
+		return ""
+	}
+	return m.spinnerFrame() + " " + styles.Subtle.Render(m.tr(m.Loading)+"…")
+}
@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/bus"
+	"github.com/aminshahid573/termplay/internal/chat"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lobbyChatVisible is how many lines of the lobby chat fit on screen.
+const lobbyChatVisible = 15
+
+// lobbyChatWidth is where long lobby messages wrap.
+const lobbyChatWidth = 60
+
+type lobbyChatEventMsg struct{ data []byte }
+
+type lobbyChatLoadedMsg struct{ msgs []db.ChatMessage }
+
+// openLobbyChat shows the lobby chat, subscribing to it if needed.
+func (m Model) openLobbyChat() (Model, tea.Cmd) {
+	m.State = StateLobbyChat
+	m.LobbyNotice = ""
+	m.ChatInput.SetValue("")
+	focus := m.ChatInput.Focus()
+	if m.LobbyEvents != nil {
+		return m, tea.Batch(focus, loadLobbyChatCmd())
+	}
+	ch, stop := bus.Default.Subscribe(db.ChatTopic(db.LobbyChannel))
+	m.LobbyEvents = ch
+	m.StopLobbyEvents = stop
+
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.StopLobbyChat = stop
+	m.Cleanup.Mu.Unlock()
+
+	return m, tea.Batch(focus, waitLobbyChatCmd(ch), loadLobbyChatCmd())
+}
+
+// closeLobbyChat stops listening to the lobby chat.
+func (m Model) closeLobbyChat() Model {
+	if m.StopLobbyEvents != nil {
+		m.StopLobbyEvents()
+	}
+	m.LobbyEvents = nil
+	m.StopLobbyEvents = nil
+	m.LobbyChat = nil
+	m.ChatInput.Blur()
+
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.StopLobbyChat = nil
+	m.Cleanup.Mu.Unlock()
+	return m
+}
+
+func updateLobbyChat(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m = m.closeLobbyChat()
+			m.State = StateMenu
+			return m, nil
+		case "ctrl+x":
+			// Hide the latest sender for the rest of the session
+			if last, ok := m.lastLobbySender(); ok {
+				m.Muted[last.From] = true
+				m.LobbyNotice = fmt.Sprintf("Muted %s", taggedName(last.From, last.Name))
+			}
+			return m, nil
+		case "ctrl+r":
+			last, ok := m.lastLobbySender()
+			if !ok {
+				return m, nil
+			}
+			m.LobbyNotice = fmt.Sprintf("Reported %s to the moderators", taggedName(last.From, last.Name))
+			return m, fileReportCmd(db.Report{
+				Kind:       "report",
+				Reporter:   m.SessionID,
+				Target:     last.From,
+				TargetName: last.Name,
+				Room:       db.LobbyChannel,
+				Reason:     "Reported from the lobby chat",
+				Transcript: lastMessages(m.LobbyChat, reportTranscript),
+			})
+		case "enter":
+			text := chat.Clean(m.ChatInput.Value())
+			m.ChatInput.SetValue("")
+			if text == "" {
+				return m, nil
+			}
+			if m.ChatMuted {
+				m.LobbyNotice = "You have been muted by a moderator"
+				return m, nil
+			}
+			if err := chat.Allow(m.SessionID); err != nil {
+				m.LobbyNotice = err.Error()
+				m.logAbuse("chat flood")
+				if !m.Flagged {
+					// Raise one flag per session so a flood doesn't flood the queue too
+					m.Flagged = true
+					return m, fileReportCmd(db.Report{
+						Kind:       "flag",
+						Target:     m.SessionID,
+						TargetName: m.MyName,
+						Room:       db.LobbyChannel,
+						Reason:     "Chat flood: hit the rate limit",
+						Transcript: lastMessages(m.LobbyChat, reportTranscript),
+					})
+				}
+				return m, nil
+			}
+			m.LobbyNotice = ""
+			return m, sendChatCmd(db.LobbyChannel, db.ChatMessage{
+				From: m.SessionID,
+				Name: m.MyName,
+				Text: text,
+				At:   time.Now().Unix(),
+			})
+		}
+		var cmd tea.Cmd
+		m.ChatInput, cmd = m.ChatInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// lastLobbySender is the newest lobby message from someone else that is
+// still shown, the one Ctrl+R and Ctrl+X act on.
+func (m Model) lastLobbySender() (db.ChatMessage, bool) {
+	for i := len(m.LobbyChat) - 1; i >= 0; i-- {
+		if msg := m.LobbyChat[i]; msg.From != m.SessionID && !m.Muted[msg.From] {
+			return msg, true
+		}
+	}
+	return db.ChatMessage{}, false
+}
+
+// updateLobbyChatEvents keeps the lobby history current. It runs whatever
+// the screen, since the subscription outlives a tab switch.
+func updateLobbyChatEvents(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lobbyChatLoadedMsg:
+		if m.LobbyEvents != nil {
+			m.LobbyChat = msg.msgs
+		}
+		return m, nil
+	case lobbyChatEventMsg:
+		if m.LobbyEvents == nil {
+			return m, nil
+		}
+		var cm db.ChatMessage
+		if err := json.Unmarshal(msg.data, &cm); err == nil {
+			m.LobbyChat = append(m.LobbyChat, cm)
+			if len(m.LobbyChat) > chatHistory {
+				m.LobbyChat = m.LobbyChat[len(m.LobbyChat)-chatHistory:]
+			}
+		}
+		return m, waitLobbyChatCmd(m.LobbyEvents)
+	}
+	return m, nil
+}
+
+func renderLobbyChat(m Model) string {
+	lines := chat.Collapse(m.LobbyChat, m.Muted)
+	if len(lines) > lobbyChatVisible {
+		lines = lines[len(lines)-lobbyChatVisible:]
+	}
+
+	wrap := lipgloss.NewStyle().Width(lobbyChatWidth)
+	var rows []string
+	for _, l := range lines {
+		text := l.Text
+		if l.Count > 1 {
+			text = fmt.Sprintf("%s (x%d)", text, l.Count)
+		}
+		rows = append(rows, wrap.Render(styles.Highlight.Render(taggedName(l.From, l.Name)+": ")+text))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, styles.Subtle.Render("No messages yet. Say hi and find an opponent!"))
+	}
+	rows = append(rows, "")
+	if m.LobbyNotice != "" {
+		rows = append(rows, styles.Err.Render(m.LobbyNotice))
+	}
+	rows = append(rows, m.ChatInput.View())
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render(m.tr("LOBBY CHAT")),
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+	)
+}
+
+func loadLobbyChatCmd() tea.Cmd {
+	return func() tea.Msg {
+		msgs, err := db.GetChat(db.LobbyChannel, chatHistory)
+		if err != nil {
+			return errMsg(fmt.Errorf("could not load the lobby chat: %v", err))
+		}
+		return lobbyChatLoadedMsg{msgs}
+	}
+}
+
+func waitLobbyChatCmd(ch <-chan []byte) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		data, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return lobbyChatEventMsg{data}
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maintenanceMsg carries the maintenance flag as the server last read it.
+type maintenanceMsg struct{ mt *db.Maintenance }
+
+// maintenanceSetMsg confirms an admin switched maintenance mode.
+type maintenanceSetMsg struct{ on bool }
+
+// maintenanceCheckCmd reads the flag after the given delay. The check is
+// in memory (see db.RunMaintenanceWatch), so it can run often.
+func maintenanceCheckCmd(after time.Duration) tea.Cmd {
+	return tea.Tick(after, func(time.Time) tea.Msg {
+		return maintenanceMsg{db.CurrentMaintenance()}
+	})
+}
+
+// updateMaintenance keeps Model.Maintenance current: every second while
+// a countdown is showing, every few seconds otherwise. Players still on
+// the name screen when the flag goes up are shown the "back soon" screen;
+// admins are let through to switch it off.
+func updateMaintenance(m Model, msg maintenanceMsg) (Model, tea.Cmd) {
+	m.Maintenance = msg.mt
+	if m.Maintenance == nil {
+		if m.State == StateMaintenance {
+			m.State = StateNameInput
+		}
+		return m, maintenanceCheckCmd(5 * time.Second)
+	}
+	if m.State == StateNameInput && !config.AdminKeys[m.SessionID] {
+		m.State = StateMaintenance
+	}
+	return m, maintenanceCheckCmd(time.Second)
+}
+
+// toggleMaintenanceCmd switches maintenance mode from the admin console.
+func toggleMaintenanceCmd(adminID string) tea.Cmd {
+	return func() tea.Msg {
+		var mt *db.Maintenance
+		if db.CurrentMaintenance() == nil {
+			mt = &db.Maintenance{Start: time.Now().Add(config.MaintenanceCountdown).Unix(), By: adminID}
+		}
+		if err := db.SetMaintenance(adminID, mt); err != nil {
+			return errMsg(fmt.Errorf("could not switch maintenance mode: %v", err))
+		}
+		return maintenanceSetMsg{mt != nil}
+	}
+}
+
+func renderMaintenance(m Model) string {
+	rows := []string{
+		styles.Title.Render("BACK SOON"),
+		"The server is down for maintenance.",
+		styles.Subtle.Render("Please come back in a little while."),
+	}
+	if m.Maintenance != nil && m.Maintenance.Message != "" {
+		rows = append(rows, "", styles.Special.Render(m.Maintenance.Message))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, rows...)
+}
+
+// renderMaintenanceBanner is the countdown shown on every other screen.
+func renderMaintenanceBanner(mt *db.Maintenance) string {
+	if mt == nil {
+		return ""
+	}
+	left := mt.Remaining().Round(time.Second)
+	text := "Maintenance in progress: no new games"
+	if left > 0 {
+		text = fmt.Sprintf("Server maintenance in %d:%02d: finish up, no new games", int(left.Minutes()), int(left.Seconds())%60)
+	}
+	if mt.Message != "" {
+		text += " • " + mt.Message
+	}
+	return styles.Err.Render(text)
+}
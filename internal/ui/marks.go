@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+)
+
+// markPresets are the glyphs offered on the settings screen. Players can
+// also type any emoji for a custom mark.
+var markPresets = []string{"default", "✕", "✖", "●", "★"}
+
+// markSlot is how many columns every mark is drawn in, so a double-width
+// emoji and a single-width letter sit in the same place in a cell.
+const markSlot = 2
+
+// normalizeMark checks a custom mark: one grapheme at most markSlot
+// columns wide. Variation selectors are dropped, since terminals disagree
+// on how wide they make a character.
+func normalizeMark(s string) (string, bool) {
+	s = strings.Map(func(r rune) rune {
+		if r == '\uFE0E' || r == '\uFE0F' {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" || uniseg.GraphemeClusterCount(s) != 1 {
+		return "", false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	if w := lipgloss.Width(s); w < 1 || w > markSlot {
+		return "", false
+	}
+	return s, true
+}
+
+// markColors are the colors offered for a mark on the settings screen,
+// by name. Pink and blue are what X and O are drawn in by default.
+var markColors = []struct{ name, color string }{
+	{"default", ""},
+	{"red", "196"},
+	{"orange", "208"},
+	{"yellow", "220"},
+	{"green", "46"},
+	{"cyan", "51"},
+	{"blue", "39"},
+	{"purple", "135"},
+	{"pink", "205"},
+}
+
+func markColorNames() []string {
+	names := make([]string, len(markColors))
+	for i, c := range markColors {
+		names[i] = c.name
+	}
+	return names
+}
+
+// Default colors of X and O, as in styles.XStyle and styles.OStyle.
+const (
+	defaultXColor = "205"
+	defaultOColor = "39"
+)
+
+// markColor returns the color named name, or def if there is none.
+func markColor(name, def string) string {
+	for _, c := range markColors {
+		if c.name == name && c.color != "" {
+			return c.color
+		}
+	}
+	return def
+}
+
+// markGlyphs are the glyphs a board is drawn with, and their colors ("" for
+// the side's own).
+type markGlyphs struct {
+	X, O           string
+	XColor, OColor string
+}
+
+var defaultMarks = markGlyphs{X: "X", O: "O"}
+
+// roomMarks picks the glyphs for a room from each player's chosen mark
+// and color. If both picked the same glyph, or ASCII mode is on, plain X
+// and O are used so the two sides can always be told apart.
+func roomMarks(r db.Room, ascii bool) markGlyphs {
+	g := defaultMarks
+	g.XColor, g.OColor = roomColors(r)
+	if ascii {
+		return g
+	}
+	if x, ok := normalizeMark(r.Seats[r.PlayerX].Mark); ok {
+		g.X = x
+	}
+	if o, ok := normalizeMark(r.Seats[r.PlayerO].Mark); ok {
+		g.O = o
+	}
+	if g.X == g.O {
+		g.X, g.O = defaultMarks.X, defaultMarks.O
+	}
+	return g
+}
+
+// roomColors picks the colors of a room's marks from each player's
+// choice. The host keeps theirs on a clash: the guest gets O's own color,
+// or X's if the host took that.
+func roomColors(r db.Room) (x, o string) {
+	x = markColor(r.Seats[r.PlayerX].Color, defaultXColor)
+	o = markColor(r.Seats[r.PlayerO].Color, defaultOColor)
+	if o == x {
+		o = defaultOColor
+	}
+	if o == x {
+		o = defaultXColor
+	}
+	return x, o
+}
+
+// glyph returns the plain glyph for a cell, padded to markSlot columns.
+func (g markGlyphs) glyph(c tictactoe.Cell) string {
+	s := " "
+	switch c {
+	case tictactoe.X:
+		s = g.X
+	case tictactoe.O:
+		s = g.O
+	}
+	return lipgloss.PlaceHorizontal(markSlot, lipgloss.Center, s)
+}
+
+// render returns the glyph for a cell in its side's color.
+func (g markGlyphs) render(c tictactoe.Cell) string {
+	switch c {
+	case tictactoe.X:
+		return colored(styles.XStyle, g.XColor).Render(g.glyph(c))
+	case tictactoe.O:
+		return colored(styles.OStyle, g.OColor).Render(g.glyph(c))
+	}
+	return g.glyph(c)
+}
+
+func colored(style lipgloss.Style, color string) lipgloss.Style {
+	if color == "" {
+		return style
+	}
+	return style.Foreground(lipgloss.Color(color))
+}
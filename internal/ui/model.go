@@ -1,12 +1,19 @@
 package ui
 
 import (
-	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/chat"
+	"github.com/aminshahid573/termplay/internal/config"
 	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
 	"github.com/aminshahid573/termplay/internal/snake"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+	"io"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/ssh"
@@ -25,26 +32,67 @@ const (
 	StateGame
 	StateGameSelect
 	StateSnakeGame
+	StateScreensaver
+	StateSettings
+	StateTutorial
+	StatePuzzle
+	StateAdmin
+	StateLeaderboard
+	StateLobbyChat
+	StateServerStatus
+	StateMaintenance
+	StateRoomAlert
 )
 
 const (
 	PopupLeave = iota
 	PopupRestart
+	PopupKick
+	PopupReport
+	PopupWarning
+	PopupDuplicate
+	PopupOpponentLeft
 )
 
 type CleanupState struct {
-	RoomCode  string
-	IsHost    bool
-	SessionID string
-	Mu        sync.Mutex
+	RoomCode   string
+	IsHost     bool
+	SessionID  string
+	StopEvents func()
+	Tabs       []TabCleanup // rooms in background tabs, left as well
+
+	StopLobbyChat func()
+	StopRoomAlert func()
+	Mu            sync.Mutex
+}
+
+// TabCleanup is what CleanupState needs to leave a background room.
+type TabCleanup struct {
+	RoomCode   string
+	IsHost     bool
+	StopEvents func()
 }
 
 type Model struct {
 	Width, Height int
 	SessionID     string
 	Err           error
+	Retry         func(Model) tea.Cmd // remakes the action behind Err, see retry.go
 
 	Cleanup *CleanupState
+	Out     io.Writer // the player's terminal, for bells and escape codes
+	Local   bool      // playing with termplay local rather than over SSH
+
+	// Enhanced key reporting, see keyboard.go
+	EnhancedKeys bool // input goes through a KeyReader, so ask for reports
+	SkipConfirm  bool // the key being handled was Ctrl+Enter
+
+	Settings     db.Settings
+	TutorialDone bool
+	Tutorial     Tutorial
+	Puzzle       PuzzleState
+	PuzzlesDone  map[string]bool
+	Badges       map[string]bool // earned badges, see db.BadgeOrder
 
 	State       SessionState
 	TextInput   textinput.Model
@@ -52,32 +100,211 @@ type Model struct {
 	PopupActive bool
 	PopupType   int
 	Busy        bool
+	Loading     string // what Busy is waiting on, see loading.go
+
+	CodeSubmitted bool // Enter was pressed on the room code, see roomcode.go
 
 	SearchInput     textinput.Model
 	PublicRooms     []db.Room
+	Featured        string // code of the admin-pinned game
 	ListSelectedRow int
+	Preview         RoomPreview // the highlighted room, see preview.go
+	ListFilter      ListFilter  // chips on top of the search, see listfilter.go
 
 	IsPublicCreate bool
+	BotLevel       string   // difficulty used if a bot fills the room
+	Rules          db.Rules // options for rooms created, see db.Rules
 	SelectedGame   string
+	WatchBest      bool // Watch a Game picks the top-rated game, not a random one
 
-	MyName   string
-	MySide   string
-	RoomCode string
+	MyName       string
+	HasKey       bool   // connected with an SSH key, so SessionID is stable
+	KeyID        string // the key itself; SessionID differs once it is linked
+	ReservedName string // name this player owns, see db.ReserveName
+	Link         LinkState
+	Charm        CharmState
+	Notify       NotifyState // turn alerts, see notify.go
 
-	CursorR int
-	CursorC int
+	Latency *metrics.Latency // round-trip to this player, see SessionLatency
+	Conn    ConnInfo         // where the session came from, for the auth log
+	Guard   *Guard           // per-session limits, see guard.go
+	Live    *LiveSession     // this session in the registry, see sessions.go
 
-	// Chess State
-	ChessSelected   bool
-	ChessSelRow     int
-	ChessSelCol     int
-	ChessValidMoves map[chess.Pos]bool
-	UseNerdFont     bool
+	// Low-bandwidth mode, see bandwidth.go
+	Throughput *metrics.Throughput // how fast the player's link takes output
+	SlowLink   bool                // the link was found too slow or laggy
+	Lean       bool                // low-bandwidth mode is in effect
+
+	// Redraw only on change, see frame.go
+	Frame     *frameCache
+	Unchanged bool // the last message left the screen as it was
+
+	// The room on screen; rooms in other tabs wait in Tabs
+	RoomTab
+	Tabs []RoomTab
+
+	UseNerdFont bool
+	StillCursor bool   // text cursors are set not to blink, see motion.go
+	ForcedTheme string // theme asked for with an SSH argument, see args.go
+	Zoom        zoom   // board size picked with +/-, see zoom.go
 
 	// Snake State
 	Snake snake.Model
 
-	Game db.Room
+	// Menu attract mode
+	Demo        DemoGame
+	DemoTicking bool
+	LastInput   time.Time
+
+	// Idle screensaver
+	Saver     Screensaver
+	PrevState SessionState
+
+	StatusTicking bool
+
+	WindowTitle string // last title sent to the terminal
+	MOTD        string // message of the day, shown on the name screen
+
+	// Brief messages shown on every screen, see toast.go
+	Toasts ToastQueue
+
+	AllKeys bool // the status line names every key, see statusline.go
+
+	// Store calls running past their latency budget, oldest first; see
+	// store.go. The spinner shows them, Loading and the wait for an open
+	// room while Spinning.
+	Slow     []*storeOp
+	Spinner  spinner.Model
+	Spinning bool
+
+	Maintenance *db.Maintenance // set while the server is winding down
+
+	// In-room chat
+	ChatInput textinput.Model
+	ChatOpen  bool
+	Muted     map[string]bool // senders hidden for the rest of the session
+	ChatMuted bool            // muted by a moderator
+	Flagged   bool            // already auto-flagged this session
+	Warning   string          // moderator warning awaiting acknowledgement
+
+	// Server-wide chat, opened from the main menu
+	LobbyChat       []db.ChatMessage
+	LobbyEvents     <-chan []byte
+	StopLobbyEvents func()
+	LobbyNotice     string // rate limit warnings, report confirmations etc.
+
+	// Watch for an open public room, see roomalert.go
+	RoomAlert RoomAlert
+
+	// Lifetime record against the opponent, see headtohead.go
+	H2H HeadToHeadState
+
+	// Correspondence games the player sits in, see correspondence.go
+	Correspondence []db.Room
+
+	// Live rooms the player hosts, to go back to, see rejoin.go
+	Hosted []db.Room
+
+	// Ranked games the player stalled, and any lockout, see db.StallRecord
+	Stalls db.StallRecord
+
+	// The player's other session, for PopupDuplicate, see duplicate.go
+	Duplicate *LiveSession
+
+	// Who last left the host's room and when, see opponentleft.go
+	LeftName string
+	LeftAt   time.Time
+
+	// Admin console
+	Admin AdminState
+
+	Leaderboard LeaderboardState
+
+	ServerStatus ServerStatusState
+}
+
+// SessionID identifies a player: by the profile their SSH key was linked
+// to (see db.LinkKey), otherwise by the key itself.
+func SessionID(s ssh.Session) string {
+	id := KeyID(s)
+	if s == nil || s.PublicKey() == nil {
+		return id
+	}
+	if account, err := db.AccountOf(id); err == nil && account != "" {
+		return account
+	}
+	return id
+}
+
+// KeyID identifies a connection by its SSH key fingerprint (or address
+// when there is no key), made safe for use as a database path segment.
+func KeyID(s ssh.Session) string {
+	id := "local"
+	if s != nil {
+		if key := s.PublicKey(); key != nil {
+			id = gossh.FingerprintSHA256(key)
+		} else {
+			id = db.GuestPrefix + s.RemoteAddr().String()
+		}
+	}
+
+	id = strings.ReplaceAll(id, ":", "_")
+	id = strings.ReplaceAll(id, "/", "_")
+	id = strings.ReplaceAll(id, ".", "_")
+	id = strings.ReplaceAll(id, "+", "-")
+	id = strings.ReplaceAll(id, "=", "")
+	id = strings.ReplaceAll(id, "[", "")
+	id = strings.ReplaceAll(id, "]", "")
+	return id
+}
+
+// ConnInfo is what the auth log needs to name a session.
+type ConnInfo struct {
+	Addr string // remote host:port
+	User string // SSH user name
+}
+
+// latencyKey holds a session's round-trip tracker in its context.
+type latencyKey struct{}
+
+// SessionLatency returns the round-trip tracker of s, which the server's
+// keep-alive pings feed. The first call makes it; the keep-alive
+// middleware makes that call before the program starts.
+func SessionLatency(s ssh.Session) *metrics.Latency {
+	if s == nil {
+		return nil
+	}
+	if l, ok := s.Context().Value(latencyKey{}).(*metrics.Latency); ok {
+		return l
+	}
+	l := &metrics.Latency{}
+	s.Context().SetValue(latencyKey{}, l)
+	return l
+}
+
+// throughputKey holds a session's output meter in its context.
+type throughputKey struct{}
+
+// SessionThroughput returns the output meter of s, which the server's
+// throughput middleware feeds, see metrics.Throughput. The first call
+// makes it.
+func SessionThroughput(s ssh.Session) *metrics.Throughput {
+	if s == nil {
+		return nil
+	}
+	if t, ok := s.Context().Value(throughputKey{}).(*metrics.Throughput); ok {
+		return t
+	}
+	t := &metrics.Throughput{}
+	s.Context().SetValue(throughputKey{}, t)
+	return t
+}
+
+func connInfo(s ssh.Session) ConnInfo {
+	if s == nil {
+		return ConnInfo{}
+	}
+	return ConnInfo{Addr: s.RemoteAddr().String(), User: s.User()}
 }
 
 func InitialModel(s ssh.Session, cleanup *CleanupState) Model {
@@ -91,45 +318,67 @@ func InitialModel(s ssh.Session, cleanup *CleanupState) Model {
 
 	// 2. Search Input
 	si := textinput.New()
-	si.Placeholder = "Search rooms..."
+	si.Placeholder = "Press / to search rooms"
 	si.Prompt = "> "
 	si.CharLimit = 20
 	si.Width = 30
 
-	id := "local"
+	// 3. Chat Input
+	ci := textinput.New()
+	ci.Placeholder = "Say something..."
+	ci.Prompt = "> "
+	ci.CharLimit = chat.MaxLen
+	ci.Width = 40
+
+	id := SessionID(s)
+	var out io.Writer = os.Stdout
 	if s != nil {
-		if key := s.PublicKey(); key != nil {
-			id = gossh.FingerprintSHA256(key)
-		} else {
-			id = s.RemoteAddr().String()
-		}
+		out = s
 	}
 
-	id = strings.ReplaceAll(id, ":", "_")
-	id = strings.ReplaceAll(id, "/", "_")
-	id = strings.ReplaceAll(id, ".", "_")
-	id = strings.ReplaceAll(id, "+", "-")
-	id = strings.ReplaceAll(id, "=", "")
-	id = strings.ReplaceAll(id, "[", "")
-	id = strings.ReplaceAll(id, "]", "")
-
 	cleanup.SessionID = id
 
-	return Model{
-		State:           StateNameInput,
-		TextInput:       ti,
-		SearchInput:     si,
-		SessionID:       id,
-		Cleanup:         cleanup,
-		MenuIndex:       0,
-		CursorR:         1,
-		CursorC:         1,
-		ChessValidMoves: make(map[chess.Pos]bool),
-		UseNerdFont:     true,
-		Game:            db.Room{Board: [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "}},
+	var args SessionArgs
+	if s != nil {
+		// The server turned away sessions with arguments that don't parse
+		args, _ = ParseArgs(s.Command())
+	}
+
+	m := Model{
+		State:       StateNameInput,
+		TextInput:   ti,
+		SearchInput: si,
+		ChatInput:   ci,
+		Spinner:     spinner.New(),
+		Muted:       make(map[string]bool),
+		SessionID:   id,
+		HasKey:      s != nil && s.PublicKey() != nil,
+		KeyID:       KeyID(s),
+		Latency:     SessionLatency(s),
+		Conn:        connInfo(s),
+		Throughput:  SessionThroughput(s),
+		Frame:       &frameCache{},
+		ForcedTheme: args.Theme,
+		Link:        LinkState{Input: newLinkInput()},
+		Charm:       CharmState{Input: newCharmInput()},
+		Notify:      NotifyState{Input: newNotifyInput()},
+		Cleanup:     cleanup,
+		Out:         out,
+		Local:       s == nil,
+		Settings:    db.DefaultSettings(),
+		BotLevel:    tictactoe.LevelIntermediate,
+		MenuIndex:   0,
+		RoomTab:     newRoomTab(),
+		UseNerdFont: true,
+		LastInput:   time.Now(),
 	}
+	m.EnhancedKeys = enhancedKeys(s)
+	m.Live = liveSession(s)
+	m.Live.attach(id, cleanup)
+	m.Guard = newGuard(s, id, m.logAbuse)
+	return m.checkDuplicate()
 }
 
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, idleCheckCmd(config.ScreensaverAfter), loadProfileCmd(m.SessionID), loadMOTDCmd(), maintenanceCheckCmd(0), m.enhancedKeysCmd(), waitAdminMessageCmd(m.Live), loadCorrespondenceCmd(m.SessionID), loadHostedRoomsCmd(m.SessionID))
 }
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// The message of the day is cut to motdMaxLines lines of motdWidth
+// columns, so a long announcement can't push the name prompt off screen.
+const (
+	motdMaxLines = 10
+	motdWidth    = 60
+)
+
+var motdColors = map[string]lipgloss.Color{
+	"red":     lipgloss.Color("9"),
+	"green":   lipgloss.Color("10"),
+	"yellow":  lipgloss.Color("11"),
+	"blue":    lipgloss.Color("12"),
+	"magenta": lipgloss.Color("13"),
+	"cyan":    lipgloss.Color("14"),
+	"gray":    lipgloss.Color("8"),
+}
+
+type motdLoadedMsg string
+
+// loadMOTDCmd fetches the message of the day: the one set with
+// `termplay motd` if any, otherwise config.MOTDFile. The file is read on
+// every login, so edits show up without a restart.
+func loadMOTDCmd() tea.Cmd {
+	return func() tea.Msg {
+		if text, err := db.GetMOTD(); err == nil && text != "" {
+			return motdLoadedMsg(text)
+		}
+		if config.MOTDFile == "" {
+			return nil
+		}
+		data, err := os.ReadFile(config.MOTDFile)
+		if err != nil {
+			log.Warn("MOTD file unreadable", "file", config.MOTDFile, "err", err)
+			return nil
+		}
+		return motdLoadedMsg(data)
+	}
+}
+
+// renderMOTD styles the message of the day. Lines starting with "# " are
+// headings; inline, **bold**, *italic* and {color}text{/} are understood.
+func renderMOTD(text string) string {
+	text = strings.Map(func(r rune) rune {
+		// Operators' files may carry stray escape codes; never pass them on
+		if r != '\n' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(text))
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > motdMaxLines {
+		lines = lines[:motdMaxLines]
+	}
+	wrap := lipgloss.NewStyle().Width(motdWidth).Align(lipgloss.Center)
+	var rows []string
+	for _, line := range lines {
+		if h, ok := strings.CutPrefix(line, "# "); ok {
+			rows = append(rows, styles.Title.Render(renderMOTDLine(h)))
+			continue
+		}
+		rows = append(rows, wrap.Render(renderMOTDLine(line)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, rows...)
+}
+
+// renderMOTDLine applies the inline markup of one line. Unknown {tags}
+// and unbalanced markers are left as they are typed.
+func renderMOTDLine(line string) string {
+	var out, buf strings.Builder
+	style := lipgloss.NewStyle()
+	flush := func() {
+		if buf.Len() > 0 {
+			out.WriteString(style.Render(buf.String()))
+			buf.Reset()
+		}
+	}
+	for i := 0; i < len(line); {
+		switch {
+		case strings.HasPrefix(line[i:], "**"):
+			flush()
+			style = style.Bold(!style.GetBold())
+			i += 2
+			continue
+		case line[i] == '*':
+			flush()
+			style = style.Italic(!style.GetItalic())
+			i++
+			continue
+		case line[i] == '{':
+			if end := strings.IndexByte(line[i:], '}'); end > 0 {
+				tag := line[i+1 : i+end]
+				if tag == "/" {
+					flush()
+					style = style.UnsetForeground()
+					i += end + 1
+					continue
+				}
+				if c, ok := motdColor(tag); ok {
+					flush()
+					style = style.Foreground(c)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		buf.WriteByte(line[i])
+		i++
+	}
+	flush()
+	return out.String()
+}
+
+// motdColor resolves a {tag}: a color name or a #rrggbb hex code.
+func motdColor(tag string) (lipgloss.Color, bool) {
+	if c, ok := motdColors[tag]; ok {
+		return c, true
+	}
+	if len(tag) == 7 && tag[0] == '#' && strings.Trim(strings.ToLower(tag[1:]), "0123456789abcdef") == "" {
+		return lipgloss.Color(tag), true
+	}
+	return "", false
+}
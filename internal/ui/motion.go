@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reduceMotion reports whether blinking, animations and flashing are off,
+// by the player's choice in Settings or to save bandwidth. Anything on
+// screen that moves or flashes on its own, rather than in answer to a
+// key or a move, must check it and hold still.
+func (m Model) reduceMotion() bool {
+	return m.Settings.ReduceMotion || m.lowBandwidth()
+}
+
+// updateMotion applies reduceMotion to the parts that animate without
+// being drawn from the model on each frame: the text cursors and the
+// snake game.
+func updateMotion(m Model) (Model, tea.Cmd) {
+	still := m.reduceMotion()
+	m.Snake.ReduceMotion = still
+	if still == m.StillCursor {
+		return m, nil
+	}
+	m.StillCursor = still
+	mode := cursor.CursorBlink
+	if still {
+		mode = cursor.CursorStatic
+	}
+	var cmds []tea.Cmd
+	for _, c := range []*cursor.Model{&m.TextInput.Cursor, &m.SearchInput.Cursor, &m.ChatInput.Cursor, &m.Link.Input.Cursor} {
+		cmds = append(cmds, c.SetMode(mode))
+	}
+	return m, tea.Batch(cmds...)
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// guestSuffix marks a guest's name wherever it is shown.
+const guestSuffix = " (guest)"
+
+// errGuestSuffix turns away a player with a key who picks a name that
+// ends like a guest's.
+var errGuestSuffix = fmt.Errorf("names can't end in %q, it marks guests", strings.TrimSpace(guestSuffix))
+
+// guestName is name marked as a guest's, e.g. "sam (guest)", cut short so
+// the mark is never the part that gets truncated.
+func guestName(name string) string {
+	name = displayName(name)
+	if strings.HasSuffix(name, guestSuffix) {
+		return name
+	}
+	return fitWidth(name, maxNameWidth-runewidth.StringWidth(guestSuffix)) + guestSuffix
+}
+
+// taggedName shows a name with the tag derived from the player's ID
+// ("sam#4f2a"), so two players called sam can be told apart.
+func taggedName(pid, name string) string {
+	if db.IsGuest(pid) {
+		// A guest's tag would change with every connection
+		return guestName(name)
+	}
+	name = displayName(name)
+	if pid == "" || pid == db.BotID {
+		return name
+	}
+	return name + "#" + db.NameTag(pid)
+}
+
+// guestNotice prompts a guest to come back with an SSH key, or is "".
+func (m Model) guestNotice() string {
+	if !db.IsGuest(m.SessionID) {
+		return ""
+	}
+	return styles.Subtle.Render(m.tr("Playing as a guest: results aren't saved or ranked. Connect with an SSH key to register."))
+}
+
+// playerName is how a seated player is shown in headers and lists: their
+// name, tagged unless it is a name they reserved, then their badges,
+// e.g. "alice#4f2a ♛✦".
+func playerName(r db.Room, pid, name string, ascii bool) string {
+	if r.Seats[pid].Reserved {
+		name = displayName(name)
+	} else {
+		name = taggedName(pid, name)
+	}
+	if b := seatBadges(r, pid, ascii); b != "" {
+		return name + " " + b
+	}
+	return name
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/notify"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NotifyState backs setting, from the settings screen, where the player
+// is alerted that a correspondence game is waiting on their move.
+type NotifyState struct {
+	Entering bool
+	Input    textinput.Model
+	Status   string // outcome of the test alert sent on saving
+}
+
+// notifyTestedMsg is the outcome of a test alert.
+type notifyTestedMsg struct{ err error }
+
+func newNotifyInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "ntfy:topic, https://… or you@example.com"
+	ti.Prompt = "> "
+	ti.CharLimit = 200
+	ti.Width = 40
+	return ti
+}
+
+// updateNotifyKeys handles the settings keys for turn alerts. It reports
+// whether it used the key.
+func updateNotifyKeys(m Model, msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if m.Notify.Entering {
+		switch msg.String() {
+		case "esc":
+			m.Notify.Entering = false
+			m.Notify.Input.Blur()
+			return m, nil, true
+		case "enter":
+			endpoint := strings.TrimSpace(m.Notify.Input.Value())
+			if endpoint != "" {
+				if err := notify.Validate(endpoint); err != nil {
+					m = m.fail(err)
+					return m, nil, true
+				}
+			}
+			m.Err = nil
+			m.Notify.Entering = false
+			m.Notify.Input.Blur()
+			m.Settings.TurnNotify = endpoint
+			m.Notify.Status = ""
+			if endpoint == "" {
+				return m, saveSettingsCmd(m.SessionID, m.Settings), true
+			}
+			m.Notify.Status = "Sending a test alert…"
+			return m, tea.Batch(saveSettingsCmd(m.SessionID, m.Settings), testNotifyCmd(endpoint)), true
+		}
+		var cmd tea.Cmd
+		m.Notify.Input, cmd = m.Notify.Input.Update(msg)
+		return m, cmd, true
+	}
+	if !m.HasKey || m.Link.Entering || msg.String() != "n" {
+		return m, nil, false
+	}
+	m.Err = nil
+	m.Notify.Entering = true
+	m.Notify.Input.SetValue(m.Settings.TurnNotify)
+	m.Notify.Input.CursorEnd()
+	return m, m.Notify.Input.Focus(), true
+}
+
+// renderTurnNotify is the settings footer line about turn alerts.
+func (m Model) renderTurnNotify() string {
+	switch {
+	case !m.HasKey:
+		return ""
+	case m.Notify.Entering:
+		return "Alert me at: " + m.Notify.Input.View() + "\n" +
+			styles.Subtle.Render("Enter: Save & test • empty to turn off • Esc: Cancel")
+	}
+	line := "N: alert me when it's my move in a correspondence game"
+	if m.Settings.TurnNotify != "" {
+		line = "Turn alerts: " + m.Settings.TurnNotify + " • N: change"
+	}
+	if m.Notify.Status != "" {
+		return styles.Special.Render(m.Notify.Status) + "\n" + styles.Subtle.Render(line)
+	}
+	return styles.Subtle.Render(line)
+}
+
+func testNotifyCmd(endpoint string) tea.Cmd {
+	return func() tea.Msg {
+		return notifyTestedMsg{notify.Send(endpoint, notify.Turn{Test: true})}
+	}
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type roomOpenedMsg struct{ code string }
+
+// noticeOpponentLeft tells the host who left their room, and when, once
+// the guest's seat goes empty, and asks what to do next. A guest the host
+// kicked, or the bot, isn't worth the question.
+func (m Model) noticeOpponentLeft(prev db.Room) Model {
+	r := m.Game
+	if prev.Code != r.Code {
+		m.LeftName = ""
+		return m
+	}
+	gone := prev.PlayerO
+	if m.MySide != "X" || r.PlayerO != "" || gone == "" || gone == db.BotID || gone == m.SessionID || r.Banned[gone] {
+		return m
+	}
+	m.LeftName = displayName(prev.PlayerOName)
+	m.LeftAt = time.Now()
+	if !m.PopupActive {
+		m.PopupActive = true
+		m.PopupType = PopupOpponentLeft
+	}
+	return m
+}
+
+// waitingLine is the status line of a room waiting for a new opponent.
+func (m Model) waitingLine() string {
+	if m.LeftName == "" {
+		return "Opponent disconnected. Waiting..."
+	}
+	return fmt.Sprintf("%s left at %s. Waiting...", m.LeftName, m.LeftAt.Format("15:04"))
+}
+
+// updateOpponentLeft handles the keys of PopupOpponentLeft: keep waiting,
+// put the room on the public list, or close it.
+func updateOpponentLeft(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "w", "esc":
+		m.PopupActive = false
+	case "p":
+		if m.Game.IsPublic {
+			return m, nil
+		}
+		m.PopupActive = false
+		return m, openRoomCmd(m.RoomCode, m.SessionID)
+	case "c":
+		m.PopupActive = false
+		return m.leaveRoom()
+	}
+	return m, nil
+}
+
+// renderOpponentLeft is the PopupOpponentLeft box.
+func renderOpponentLeft(m Model) string {
+	choices := []string{styles.ItemFocused.Render("[W] Keep waiting")}
+	if !m.Game.IsPublic {
+		choices = append(choices, "  ", styles.ItemFocused.Render("[P] Open to public"))
+	}
+	choices = append(choices, "  ", styles.ItemFocused.Render("[C] Close room"))
+	return styles.PopupBox.Render(lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render("OPPONENT LEFT"),
+		"",
+		fmt.Sprintf("%s left room %s at %s.", m.LeftName, m.RoomCode, m.LeftAt.Format("15:04")),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Center, choices...),
+	))
+}
+
+func openRoomCmd(code, hostID string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.OpenRoom(code, hostID); err != nil {
+			return errMsg(err)
+		}
+		return roomOpenedMsg{code}
+	}
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pasteBreaks turns the line breaks and tabs inside a paste into spaces.
+var pasteBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// cleanPaste readies a bracketed paste for the text inputs. The terminal
+// marks pasted text, so Bubble Tea hands it over as one KeyMsg that is
+// inserted in one go, rather than as keys where a copied trailing newline
+// would press Enter. Surrounding whitespace, like that newline, is
+// dropped, and breaks inside become spaces. ok is false for a paste of
+// nothing but whitespace.
+func cleanPaste(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	text := pasteBreaks.Replace(strings.TrimSpace(string(msg.Runes)))
+	if text == "" {
+		return msg, false
+	}
+	msg.Runes = []rune(text)
+	return msg, true
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A premove is a tic-tac-toe move staged on the opponent's turn. The cell
+// shows as pending, and the move is sent the moment a room state gives
+// the player the move, if the cell is still free by then.
+
+// canPremove reports whether the player may stage a move now: seated in
+// a tic-tac-toe game under way, waiting on the opponent.
+func (m Model) canPremove() bool {
+	return m.State == StateGame && m.Game.GameType != "chess" && m.Game.Status == "playing" &&
+		m.MySide != "Spectator" && m.Game.PlayerO != "" && !m.isMyTurn()
+}
+
+// stagePremove stages the cell under the cursor, or drops the premove if
+// it is that cell already.
+func (m Model) stagePremove() Model {
+	idx := m.CursorR*3 + m.CursorC
+	if m.Premoved && m.PremoveIdx == idx {
+		m.Premoved = false
+		return m
+	}
+	if m.Game.Board[idx] != tictactoe.Empty {
+		return m
+	}
+	m.Premoved, m.PremoveIdx = true, idx
+	return m
+}
+
+// playPremove sends the staged move once the room on screen gives the
+// player the move. A premove whose cell was taken in the meantime, or
+// whose game ended, is dropped.
+func (m Model) playPremove() (Model, tea.Cmd) {
+	if !m.Premoved {
+		return m, nil
+	}
+	if m.State != StateGame || m.Game.Status != "playing" {
+		m.Premoved = false
+		return m, nil
+	}
+	if !m.isMyTurn() {
+		return m, nil
+	}
+	idx := m.PremoveIdx
+	m.Premoved = false
+	if m.Game.Board[idx] != tictactoe.Empty || m.Game.HandicapBlocks(idx) {
+		m.Notice = "Your premove can't be played any more, pick another cell"
+		return m, clearNoticeCmd(m.RoomCode, m.Notice, 3*time.Second)
+	}
+	m.Err, m.Retry = nil, nil
+	return m, placeMarkCmd(m.RoomCode, m.SessionID, idx, m.Game)
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The public list shows the highlighted room's details in a panel beside
+// it. The list already has every room as it was when fetched; the panel
+// fetches the highlighted one again, once the selection rests on it, so
+// the score and seats are current.
+
+// previewDelay is how long the selection rests on a room before it is
+// fetched, so scrolling through the list fetches nothing.
+const previewDelay = 300 * time.Millisecond
+
+// previewWidth is the width of the panel, border included, beside the
+// publicListWidth of the list. Narrower terminals show the list alone.
+const (
+	previewWidth    = 34
+	publicListWidth = 70
+)
+
+// RoomPreview is the room highlighted in the public list.
+type RoomPreview struct {
+	Code string   // room highlighted
+	Room *db.Room // as fetched, nil until then
+}
+
+// previewDueMsg is the selection having rested on code for previewDelay.
+type previewDueMsg struct{ code string }
+
+// previewLoadedMsg is a fetched room for the panel.
+type previewLoadedMsg struct{ room db.Room }
+
+// listedRooms is the public list in the order it is shown.
+func (m Model) listedRooms() []db.Room {
+	featured, open, full := m.publicSections()
+	return append(append(featured, open...), full...)
+}
+
+// selectedRoom is the room highlighted in the public list.
+func (m Model) selectedRoom() (db.Room, bool) {
+	list := m.listedRooms()
+	if m.ListSelectedRow < 0 || m.ListSelectedRow >= len(list) {
+		return db.Room{}, false
+	}
+	return list[m.ListSelectedRow], true
+}
+
+// updatePreview follows the selection in the public list, fetching the
+// room it rests on.
+func updatePreview(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	if m.State != StatePublicList || !m.showPreview() {
+		m.Preview = RoomPreview{}
+		return m, nil
+	}
+	sel, ok := m.selectedRoom()
+	switch msg := msg.(type) {
+	case previewDueMsg:
+		if ok && msg.code == sel.Code && msg.code == m.Preview.Code {
+			return m, fetchPreviewCmd(msg.code)
+		}
+		return m, nil
+	case previewLoadedMsg:
+		if msg.room.Code == m.Preview.Code {
+			m.Preview.Room = &msg.room
+		}
+		return m, nil
+	}
+	if !ok {
+		m.Preview = RoomPreview{}
+		return m, nil
+	}
+	if sel.Code == m.Preview.Code {
+		return m, nil
+	}
+	m.Preview = RoomPreview{Code: sel.Code}
+	code := sel.Code
+	return m, tea.Tick(previewDelay, func(time.Time) tea.Msg { return previewDueMsg{code} })
+}
+
+// fetchPreviewCmd fetches room code for the panel. A room that is gone or
+// can't be read keeps the list's copy.
+func fetchPreviewCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		r, err := db.GetRoom(code)
+		if err != nil || r == nil || r.PlayerX == "" {
+			return nil
+		}
+		return previewLoadedMsg{*r}
+	}
+}
+
+// showPreview reports whether the terminal is wide enough for the panel.
+func (m Model) showPreview() bool {
+	return m.Width >= publicListWidth+previewWidth+2
+}
+
+// renderPreview is the panel of details of the highlighted room, or ""
+// when there is none or no room for it.
+func renderPreview(m Model) string {
+	if !m.showPreview() {
+		return ""
+	}
+	r, ok := m.selectedRoom()
+	if !ok {
+		return ""
+	}
+	if m.Preview.Room != nil && m.Preview.Room.Code == r.Code {
+		r = *m.Preview.Room
+	}
+	ascii := m.Settings.ASCII
+	inner := previewWidth - 4 // border and padding
+
+	row := func(label, value string) string {
+		return styles.Subtle.Render(fmt.Sprintf("%-9s", label)) + fitWidth(value, inner-9)
+	}
+	lines := []string{
+		styles.SectionTitle.Render(" " + r.Code + " "),
+		"",
+		row("Host", playerName(r, r.PlayerX, r.PlayerXName, ascii)),
+	}
+	if r.PlayerO != "" {
+		lines = append(lines, row("Opponent", playerName(r, r.PlayerO, r.PlayerOName, ascii)))
+	} else {
+		lines = append(lines, row("Opponent", styles.Special.Render("open seat")))
+	}
+	lines = append(lines, row("Game", gameTitle(r.GameType)))
+	if r.CreatedAt > 0 {
+		lines = append(lines, row("Created", ago(time.Unix(r.CreatedAt, 0))))
+	}
+	if n := len(r.Spectators); n > 0 {
+		lines = append(lines, row("Watching", fmt.Sprint(n)))
+	}
+
+	lines = append(lines, "", styles.Subtle.Render("Options"))
+	for _, o := range roomOptions(r) {
+		lines = append(lines, "  "+fitWidth(o, inner-2))
+	}
+
+	if r.PlayerO != "" {
+		lines = append(lines, "", styles.Subtle.Render("Score"),
+			fmt.Sprintf("  %d–%d", r.WinsX, r.WinsO))
+		switch r.Status {
+		case "playing":
+			lines = append(lines, "  "+fitWidth(fmt.Sprintf("move %d, %s to move", len(r.Moves), nextToMove(r)), inner-2))
+		case "finished":
+			lines = append(lines, "  game over")
+		}
+	}
+	return styles.ListContainer.Width(previewWidth - 2).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// roomOptions names r's rules, one per line.
+func roomOptions(r db.Room) []string {
+	opts := []string{rankedLabel(r.Ranked)}
+	if r.Correspondence {
+		opts = append(opts, "Correspondence")
+	}
+	if r.GameType != "chess" {
+		if r.Handicap != db.HandicapNone {
+			opts = append(opts, "Handicap: "+strings.ToLower(handicapLabel(r.Handicap)))
+		}
+		if r.PieRule {
+			opts = append(opts, "Swap rule")
+		}
+	}
+	if r.BotLevel != "" && r.PlayerO == db.BotID {
+		opts = append(opts, "Bot: "+botLevelLabel(r.BotLevel))
+	}
+	return opts
+}
+
+// nextToMove is the name of the player whose move it is in r.
+func nextToMove(r db.Room) string {
+	if r.Turn == "O" || r.Turn == "Black" {
+		return displayName(r.PlayerOName)
+	}
+	return displayName(r.PlayerXName)
+}
+
+// gameTitle is the display name of a game type.
+func gameTitle(gameType string) string {
+	if gameType == "chess" {
+		return "Chess"
+	}
+	return "Tic Tac Toe"
+}
+
+// ago is how long before now t was, e.g. "5m ago".
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
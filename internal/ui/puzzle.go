@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PuzzleState tracks the solo puzzle screen. Solved puzzles are stored on
+// the profile; Solved here mirrors it for rendering.
+type PuzzleState struct {
+	Index      int
+	Board      tictactoe.Board
+	CurR, CurC int
+	Result     string // "", "solved", "wrong"
+	Solved     map[string]bool
+}
+
+func newPuzzleState(solved map[string]bool) PuzzleState {
+	if solved == nil {
+		solved = make(map[string]bool)
+	}
+	p := PuzzleState{Solved: solved}
+	// Start at the first puzzle the player hasn't solved yet
+	for i, pz := range tictactoe.Puzzles {
+		if !solved[pz.ID] {
+			p.Index = i
+			break
+		}
+	}
+	return p.load(p.Index)
+}
+
+func (p PuzzleState) load(i int) PuzzleState {
+	p.Index = i
+	p.Board = tictactoe.Puzzles[i].Board
+	p.CurR, p.CurC = 1, 1
+	p.Result = ""
+	return p
+}
+
+func updatePuzzle(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	key = m.remapKey(key)
+	p := m.Puzzle
+	pz := tictactoe.Puzzles[p.Index]
+
+	switch key.String() {
+	case "esc", "q":
+		m.State = StateGameSelect
+		m.MenuIndex = gameSelectPuzzles
+		return m, nil
+	case "up", "k":
+		if p.CurR > 0 {
+			p.CurR--
+		}
+	case "down", "j":
+		if p.CurR < 2 {
+			p.CurR++
+		}
+	case "left", "h":
+		if p.CurC > 0 {
+			p.CurC--
+		}
+	case "right", "l":
+		if p.CurC < 2 {
+			p.CurC++
+		}
+	case "n", "]", "tab":
+		p = p.load((p.Index + 1) % len(tictactoe.Puzzles))
+	case "p", "[", "shift+tab":
+		p = p.load((p.Index + len(tictactoe.Puzzles) - 1) % len(tictactoe.Puzzles))
+	case "r":
+		p = p.load(p.Index)
+	case " ", "enter":
+		if p.Result == "solved" {
+			p = p.load((p.Index + 1) % len(tictactoe.Puzzles))
+			break
+		}
+		idx := p.CurR*3 + p.CurC
+		if pz.Board[idx] != tictactoe.Empty {
+			break
+		}
+		p.Board = pz.Board
+		p.Board[idx] = tictactoe.X
+		if pz.IsSolution(idx) {
+			p.Result = "solved"
+			if !p.Solved[pz.ID] {
+				p.Solved[pz.ID] = true
+				m.Puzzle = p
+				m = m.toast(ToastSuccess, fmt.Sprintf("Puzzle solved, %d of %d", len(p.Solved), len(tictactoe.Puzzles)))
+				return m, puzzleSolvedCmd(m.SessionID, pz.ID)
+			}
+		} else {
+			p.Result = "wrong"
+		}
+	}
+	m.Puzzle = p
+	return m, nil
+}
+
+func renderPuzzle(m Model) string {
+	p := m.Puzzle
+	pz := tictactoe.Puzzles[p.Index]
+
+	title := fmt.Sprintf("%d/%d  %s", p.Index+1, len(tictactoe.Puzzles), pz.Title)
+	if p.Solved[pz.ID] {
+		title += " " + styles.Special.Render("✓")
+	}
+
+	var winLine []int
+	status := styles.Subtle.Render(pz.Hint)
+	switch p.Result {
+	case "solved":
+		if _, line := tictactoe.CheckWinner(p.Board); line != nil {
+			winLine = line
+		}
+		status = styles.Win.Render("Solved! Enter: next puzzle")
+	case "wrong":
+		status = styles.Err.Render("Not quite. R: try again")
+	}
+
+	done := 0
+	for _, pz := range tictactoe.Puzzles {
+		if p.Solved[pz.ID] {
+			done++
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render("PUZZLES"),
+		title,
+		styles.Subtle.Render("You are X. Find the best move."),
+		"",
+		renderTicTacToeBoard(p.Board, winLine, p.CurR, p.CurC, p.Result == "", -1, defaultMarks, zoomLarge),
+		"",
+		status,
+		styles.Muted.Render(fmt.Sprintf("Solved %d of %d", done, len(tictactoe.Puzzles))),
+	)
+}
+
+func puzzleSolvedCmd(id, puzzleID string) tea.Cmd {
+	return saveCmd("saving your puzzle progress", func() error { return db.MarkPuzzleSolved(id, puzzleID) })
+}
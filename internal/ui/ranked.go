@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// rankedLabel names a room's mode.
+func rankedLabel(ranked bool) string {
+	if ranked {
+		return "Ranked"
+	}
+	return "Casual"
+}
+
+// rankedHint explains the mode on the room settings screen.
+func rankedHint(ranked bool) string {
+	if !ranked {
+		return "Just for fun: not counted on the leaderboard"
+	}
+	if config.RankedMoveTime > 0 {
+		return fmt.Sprintf("Counts on the leaderboard • %s per move • no handicap or bot", config.RankedMoveTime)
+	}
+	return "Counts on the leaderboard • no handicap or bot"
+}
+
+// moveTimeLeft is what remains on the ranked clock for the side to move,
+// and whether a clock is running at all.
+func (m Model) moveTimeLeft() (time.Duration, bool) {
+	d := m.Game.MoveDeadline()
+	if d.IsZero() || m.State != StateGame {
+		return 0, false
+	}
+	left := time.Until(d)
+	if left < 0 {
+		left = 0
+	}
+	return left, true
+}
+
+// renderMoveClock is the status bar reading of the ranked or
+// correspondence clock. It turns red for the last ten seconds.
+func (m Model) renderMoveClock() string {
+	left, ok := m.moveTimeLeft()
+	if !ok {
+		return ""
+	}
+	text := "clock " + clockText(left)
+	if left <= 10*time.Second {
+		return styles.Err.Render(text)
+	}
+	return styles.Subtle.Render(text)
+}
+
+// clockText reads a clock as m:ss, or as e.g. 23h05m for the hours of a
+// correspondence move.
+func clockText(left time.Duration) string {
+	s := int(left.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%dh%02dm", s/3600, s%3600/60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// claimRetry is how long a claim on time that didn't land waits before
+// it is made again, e.g. because the opponent's lag grace had grown.
+const claimRetry = 3 * time.Second
+
+// shouldClaimTimeout reports whether the player's opponent has run out of
+// time, lag grace included, and no claim is already under way.
+func (m Model) shouldClaimTimeout() bool {
+	if m.MySide == "Spectator" || m.isMyTurn() || time.Since(m.ClaimedAt) < claimRetry {
+		return false
+	}
+	if _, ok := m.moveTimeLeft(); !ok {
+		return false
+	}
+	return time.Since(m.Game.MoveDeadline()) > m.Game.Grace()
+}
+
+// lagReportStep is how far the player's round-trip has to move before
+// the room hears about it again.
+const lagReportStep = 50 * time.Millisecond
+
+// shouldReportLag reports whether the room's note of the player's
+// round-trip, used for their lag grace, is missing or out of date.
+func (m Model) shouldReportLag() bool {
+	rtt := m.Latency.Get()
+	if rtt <= 0 || !m.Game.Ranked || m.MySide == "Spectator" || m.State != StateGame {
+		return false
+	}
+	diff := rtt - m.LagSent
+	return m.LagSent == 0 || diff >= lagReportStep || diff <= -lagReportStep
+}
+
+// renderFlagged is the result line for a game lost on time.
+func renderFlagged(r db.Room) string {
+	loser := r.PlayerXName
+	if r.Flagged == "O" {
+		loser = r.PlayerOName
+	}
+	return fmt.Sprintf("%s ran out of time. %s WINS!", displayName(loser), r.Winner)
+}
+
+func setLagCmd(code, pid string, rtt time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.SetLag(code, pid, rtt); err != nil {
+			log.Warn("Could not report round-trip", "room", code, "err", err)
+		}
+		return nil
+	}
+}
+
+func claimTimeoutCmd(code, pid string, r db.Room) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.ClaimTimeout(code, pid, r); err != nil {
+			return moveFailedMsg{code: code, err: err}
+		}
+		return nil
+	}
+}
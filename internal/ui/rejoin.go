@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type hostedRoomsMsg struct {
+	rooms []db.Room
+}
+
+// loadHostedRoomsCmd looks up the rooms pid still hosts, for the main
+// menu to offer a way back. A guest's ID changes with every connection,
+// so they never have any.
+func loadHostedRoomsCmd(pid string) tea.Cmd {
+	if db.IsGuest(pid) {
+		return nil
+	}
+	return func() tea.Msg {
+		rooms, err := db.HostedRooms(pid)
+		if err != nil {
+			return nil
+		}
+		return hostedRoomsMsg{rooms: rooms}
+	}
+}
+
+// rejoinCode is the room the player hosts but isn't in on this
+// connection, or "".
+func (m Model) rejoinCode() string {
+	for _, r := range m.Hosted {
+		if r.Code == m.RoomCode || m.hasTab(r.Code) {
+			continue
+		}
+		return r.Code
+	}
+	return ""
+}
+
+func (m Model) hasTab(code string) bool {
+	for _, t := range m.Tabs {
+		if t.RoomCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// renderRejoin is the main menu notice of a room the player can go back
+// to, e.g. "You have an active room: ABCD — press R to return".
+func renderRejoin(m Model) string {
+	code := m.rejoinCode()
+	if code == "" {
+		return ""
+	}
+	return styles.Special.Render("You have an active room: " + code + " — press R to return")
+}
+
+// rejoinRoom takes the player back to the room from renderRejoin. It is
+// dropped from the list either way: the player is back in it, or it is
+// gone.
+func (m Model) rejoinRoom() (Model, tea.Cmd) {
+	code := m.rejoinCode()
+	if code == "" || m.Busy {
+		return m, nil
+	}
+	var rest []db.Room
+	for _, r := range m.Hosted {
+		if r.Code != code {
+			rest = append(rest, r)
+		}
+	}
+	m.Hosted = rest
+	m, spin := m.startLoading(loadingJoin)
+	return m, tea.Batch(joinRoomCmd(code, m.SessionID, m.MyName), spin)
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// retryKey runs the action that last failed again, see Model.Retry.
+const retryKey = "ctrl+r"
+
+// actionFailedMsg is a command whose write didn't land in the store, and
+// how to make it again. Moves have moveFailedMsg, which resyncs the room
+// as well.
+type actionFailedMsg struct {
+	what  string // what was being done, e.g. "restart"
+	err   error
+	retry func(Model) tea.Cmd
+}
+
+// failed shows that what didn't land, and offers to try again with retry.
+// retry builds the command from the model as it is by then, so a move is
+// made against the latest room state; nil means there is nothing left to
+// retry.
+func (m Model) failed(what string, err error, retry func(Model) tea.Cmd) Model {
+	m.Busy = false
+	m.Retry = retry
+	if retry == nil {
+		return m.fail(fmt.Errorf("%s failed: %v", what, err))
+	}
+	return m.fail(fmt.Errorf("%s failed: %v (Ctrl+R to retry)", what, err))
+}
+
+// retryFailed makes the last failed action again, if its error is still
+// on screen. Lobby chat uses Ctrl+R for something else and keeps it.
+func (m Model) retryFailed(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if msg.String() != retryKey || m.Retry == nil || m.Err == nil || m.PopupActive || m.State == StateLobbyChat {
+		return m, nil, false
+	}
+	retry := m.Retry
+	m.Retry = nil
+	m.Err = nil
+	cmd := retry(m)
+	if cmd == nil {
+		m.Notice = "Nothing to retry: the room has moved on"
+		return m, clearNoticeCmd(m.RoomCode, m.Notice, 3*time.Second), true
+	}
+	return m, cmd, true
+}
+
+// placeMarkCmd plays idx in tic-tac-toe room code, as seen in r.
+func placeMarkCmd(code, pid string, idx int, r db.Room) tea.Cmd {
+	retry := func(m Model) tea.Cmd {
+		if m.RoomCode != code || m.Game.Status != "playing" || !m.isMyTurn() || m.Game.Board[idx] != tictactoe.Empty {
+			return nil
+		}
+		return placeMarkCmd(code, pid, idx, m.Game)
+	}
+	return storeCmd("Placing your mark", func() tea.Msg {
+		if err := db.UpdateMove(code, pid, idx, r); err != nil {
+			return moveFailedMsg{code: code, err: err, retry: retry}
+		}
+		return nil
+	})
+}
+
+// chessMoveCmd plays from-to in chess room code, as seen in r.
+func chessMoveCmd(code, pid string, from, to chess.Pos, r db.Room) tea.Cmd {
+	retry := func(m Model) tea.Cmd {
+		if m.RoomCode != code || m.Game.Status != "playing" || !m.isMyTurn() {
+			return nil
+		}
+		return chessMoveCmd(code, pid, from, to, m.Game)
+	}
+	return storeCmd("Making your move", func() tea.Msg {
+		if err := db.UpdateChessMove(code, pid, from, to, "Q", r); err != nil {
+			log.Error("UpdateChessMove failed", "err", err)
+			return moveFailedMsg{code: code, err: err, retry: retry}
+		}
+		return nil
+	})
+}
+
+// restartCmd starts a rematch in room code with next to move.
+func restartCmd(code, next string) tea.Cmd {
+	retry := func(m Model) tea.Cmd {
+		if m.RoomCode != code || m.Game.Status != "finished" {
+			return nil
+		}
+		return restartCmd(code, next)
+	}
+	return storeCmd("Starting a rematch", func() tea.Msg {
+		if err := db.RestartGame(code, next); err != nil {
+			return actionFailedMsg{what: "restart", err: err, retry: retry}
+		}
+		return nil
+	})
+}
+
+// saveCmd runs a background write of the player's profile, such as a
+// solved puzzle, offering a retry if it fails.
+func saveCmd(what string, write func() error) tea.Cmd {
+	return storeCmd(strings.ToUpper(what[:1])+what[1:], func() tea.Msg {
+		if err := write(); err != nil {
+			return actionFailedMsg{what: what, err: err, retry: func(Model) tea.Cmd { return saveCmd(what, write) }}
+		}
+		return nil
+	})
+}
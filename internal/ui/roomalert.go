@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/bus"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A player who finds no room to their taste in the public list can watch
+// for one instead: they wait on a screen of their own until a public room
+// matching the list's filter chips opens, and are told about it, or put
+// straight into it with auto-join on. Rooms arrive over the bus as they
+// open (see db.OpenRoomsTopic), with a slow poll of the public list in
+// case an event is lost.
+
+// RoomAlert is the watch for an open room.
+type RoomAlert struct {
+	Filter   ListFilter      // rooms the player is after
+	AutoJoin bool            // join the first match without asking
+	Found    *db.Room        // a match waiting on the player, without AutoJoin
+	Seen     map[string]bool // rooms already offered or joined, not offered again
+	Since    time.Time
+
+	Events <-chan []byte
+	Stop   func()
+}
+
+// openRoomEventMsg is a room pushed on db.OpenRoomsTopic.
+type openRoomEventMsg struct{ data []byte }
+
+// openRoomsPolledMsg is the public list, polled while watching.
+type openRoomsPolledMsg struct{ rooms []db.Room }
+
+// startRoomAlert starts watching for a room through the list's filter.
+func (m Model) startRoomAlert() (Model, tea.Cmd) {
+	m = m.stopRoomAlert()
+	ch, stop := bus.Default.Subscribe(db.OpenRoomsTopic)
+	m.RoomAlert = RoomAlert{
+		Filter:   m.ListFilter,
+		AutoJoin: m.RoomAlert.AutoJoin, // kept for the session
+		Seen:     make(map[string]bool),
+		Since:    time.Now(),
+		Events:   ch,
+		Stop:     stop,
+	}
+	m.State = StateRoomAlert
+
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.StopRoomAlert = stop
+	m.Cleanup.Mu.Unlock()
+
+	var spin tea.Cmd
+	m, spin = m.spin()
+	// Rooms already open count too, so poll at once
+	return m, tea.Batch(waitOpenRoomCmd(ch), pollOpenRoomsCmd(0), spin)
+}
+
+// stopRoomAlert stops listening for open rooms.
+func (m Model) stopRoomAlert() Model {
+	if m.RoomAlert.Stop == nil {
+		return m
+	}
+	m.RoomAlert.Stop()
+	m.RoomAlert.Events = nil
+	m.RoomAlert.Stop = nil
+	m.RoomAlert.Found = nil
+
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.StopRoomAlert = nil
+	m.Cleanup.Mu.Unlock()
+	return m
+}
+
+func waitOpenRoomCmd(ch <-chan []byte) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		data, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return openRoomEventMsg{data}
+	}
+}
+
+// pollOpenRoomsCmd fetches the public list after d. A failed fetch is
+// left to the next poll.
+func pollOpenRoomsCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		rooms, _ := db.GetPublicRooms()
+		return openRoomsPolledMsg{rooms}
+	})
+}
+
+// updateRoomAlertEvents handles the rooms arriving while watching. They
+// are dropped once the player stopped watching.
+func updateRoomAlertEvents(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	watching := m.State == StateRoomAlert && m.RoomAlert.Stop != nil
+	switch msg := msg.(type) {
+	case openRoomEventMsg:
+		if !watching {
+			return m, nil
+		}
+		var r db.Room
+		if err := json.Unmarshal(msg.data, &r); err != nil {
+			return m, waitOpenRoomCmd(m.RoomAlert.Events)
+		}
+		var cmd tea.Cmd
+		m, cmd = m.offerRoom(r)
+		return m, tea.Batch(cmd, waitOpenRoomCmd(m.RoomAlert.Events))
+
+	case openRoomsPolledMsg:
+		if !watching {
+			return m, nil
+		}
+		poll := pollOpenRoomsCmd(db.Intervals().Idle)
+		for _, r := range msg.rooms {
+			if m.alertMatches(r) {
+				var cmd tea.Cmd
+				m, cmd = m.offerRoom(r)
+				return m, tea.Batch(cmd, poll)
+			}
+		}
+		return m, poll
+	}
+	return m, nil
+}
+
+// alertMatches reports whether r is a room the player is watching for,
+// and not one already offered to them.
+func (m Model) alertMatches(r db.Room) bool {
+	a := m.RoomAlert
+	return r.IsOpen() && r.PlayerX != m.SessionID && !r.Banned[m.SessionID] &&
+		a.Filter.matches(r) && !a.Seen[r.Code] && a.Found == nil && !m.Busy
+}
+
+// offerRoom tells the player r has opened, or joins it with AutoJoin on.
+func (m Model) offerRoom(r db.Room) (Model, tea.Cmd) {
+	if !m.alertMatches(r) {
+		return m, nil
+	}
+	m.RoomAlert.Seen[r.Code] = true
+	if m.RoomAlert.AutoJoin {
+		var spin tea.Cmd
+		m, spin = m.startLoading(loadingJoin)
+		return m, tea.Batch(joinRoomCmd(r.Code, m.SessionID, m.MyName), spin, m.bellCmd())
+	}
+	m.RoomAlert.Found = &r
+	m = m.toast(ToastSuccess, fmt.Sprintf("Room %s is open", r.Code))
+	return m, m.bellCmd()
+}
+
+// updateRoomAlert handles the keys of the waiting screen.
+func updateRoomAlert(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if found := m.RoomAlert.Found; found != nil {
+		switch key.String() {
+		case "enter":
+			if m.Busy {
+				return m, nil
+			}
+			var spin tea.Cmd
+			m, spin = m.startLoading(loadingJoin)
+			return m, tea.Batch(joinRoomCmd(found.Code, m.SessionID, m.MyName), spin)
+		case "n":
+			// Keep watching for another
+			m.RoomAlert.Found = nil
+			return m, nil
+		}
+	}
+	switch key.String() {
+	case "a":
+		m.RoomAlert.AutoJoin = !m.RoomAlert.AutoJoin
+	case "esc":
+		m = m.stopRoomAlert()
+		return m.openPublicList()
+	}
+	return m, nil
+}
+
+// renderRoomAlert is the waiting screen.
+func renderRoomAlert(m Model) string {
+	a := m.RoomAlert
+	lines := []string{
+		styles.Title.Render("WATCHING FOR ROOMS"),
+		renderChips(a.Filter),
+		"",
+	}
+	if a.Found != nil {
+		lines = append(lines,
+			styles.Special.Render("An open room matches!"),
+			styles.ItemFocused.Render(" "+roomTitle(*a.Found, m.Settings.ASCII)+" • "+a.Found.Code+" "),
+			"",
+			styles.Subtle.Render("Enter to join it, N to keep watching"),
+		)
+	} else {
+		waited := time.Since(a.Since).Truncate(time.Second)
+		lines = append(lines,
+			m.spinnerFrame()+" Waiting for a public room to open…",
+			styles.Subtle.Render(fmt.Sprintf("watching for %s", clockText(waited))),
+		)
+	}
+	auto := "off"
+	if a.AutoJoin {
+		auto = "on"
+	}
+	lines = append(lines, "", styles.Subtle.Render("Auto-join: "+auto))
+	return lipgloss.JoinVertical(lipgloss.Center, lines...)
+}
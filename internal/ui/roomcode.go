@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/styles"
+)
+
+// codeAlphabet is what room codes are made of. It leaves out 0/O and 1/I,
+// so a code read out loud or off a screen can't be mistyped as another.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLen is how many characters a room code has.
+const codeLen = 4
+
+// normalizeCode is a typed room code as generateCode writes it: upper
+// case, without the spaces and dashes players put in to read it back.
+func normalizeCode(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+}
+
+// checkCode says what keeps a normalized code from being a room code, or
+// nil. Until the player is done typing, a short code is no mistake yet.
+func checkCode(code string, done bool) error {
+	for _, c := range code {
+		switch {
+		case c == '0' || c == 'O':
+			return fmt.Errorf("Room codes have no 0 or O: check the code")
+		case c == '1' || c == 'I':
+			return fmt.Errorf("Room codes have no 1 or I: check the code")
+		case !strings.ContainsRune(codeAlphabet, c):
+			return fmt.Errorf("%q can't be in a room code", c)
+		}
+	}
+	switch n := len(code); {
+	case n > codeLen:
+		return fmt.Errorf("Room codes have %d characters, this has %d", codeLen, n)
+	case done && n == 0:
+		return fmt.Errorf("Type the %d-character room code", codeLen)
+	case done && n < codeLen:
+		return fmt.Errorf("Room codes have %d characters, this has %d", codeLen, n)
+	}
+	return nil
+}
+
+// renderCodeCheck is the inline error under the room code input: bad
+// characters as they are typed, and a short code once Enter was pressed.
+func renderCodeCheck(m Model) string {
+	err := checkCode(normalizeCode(m.TextInput.Value()), m.CodeSubmitted)
+	if err == nil {
+		return ""
+	}
+	return styles.Err.Render(err.Error())
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// After config.ScreensaverAfter without input on a non-game screen the
+// session switches to a bouncing logo. Any key returns to where the
+// player left off.
+
+const saverStep = 250 * time.Millisecond
+
+type idleCheckMsg struct{}
+type saverTickMsg struct{}
+
+type Screensaver struct {
+	X, Y   int
+	DX, DY int
+}
+
+func idleCheckCmd(after time.Duration) tea.Cmd {
+	if config.ScreensaverAfter <= 0 {
+		return nil
+	}
+	if after < time.Second {
+		after = time.Second
+	}
+	return tea.Tick(after, func(time.Time) tea.Msg { return idleCheckMsg{} })
+}
+
+func saverTickCmd() tea.Cmd {
+	return tea.Tick(saverStep, func(time.Time) tea.Msg { return saverTickMsg{} })
+}
+
+// canScreensave reports whether the current screen is safe to cover.
+// Lobbies and games are excluded: something may happen there at any time.
+func (m Model) canScreensave() bool {
+	if m.PopupActive {
+		return false
+	}
+	switch m.State {
+	case StateNameInput, StateMenu, StateGameSelect, StatePublicList, StateCreateConfig, StateInputCode, StateSettings:
+		return true
+	}
+	return false
+}
+
+func updateScreensaver(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg.(type) {
+	case idleCheckMsg:
+		idle := time.Since(m.LastInput)
+		if m.State != StateScreensaver && m.canScreensave() && idle >= config.ScreensaverAfter {
+			m.PrevState = m.State
+			m.State = StateScreensaver
+			m.Saver = Screensaver{X: 1, Y: 1, DX: 1, DY: 1}
+			return m, tea.Batch(saverTickCmd(), idleCheckCmd(config.ScreensaverAfter))
+		}
+		return m, idleCheckCmd(config.ScreensaverAfter - idle)
+
+	case saverTickMsg:
+		if m.State != StateScreensaver || m.reduceMotion() {
+			return m, nil
+		}
+		m.Saver = m.Saver.step(m.Width, m.Height)
+		return m, saverTickCmd()
+
+	case tea.KeyMsg:
+		m.State = m.PrevState
+		return m, nil
+	}
+	return m, nil
+}
+
+func (s Screensaver) step(w, h int) Screensaver {
+	lw, lh := lipgloss.Size(renderSaverLogo())
+	maxX, maxY := max(0, w-lw), max(0, h-lh)
+
+	s.X += s.DX
+	s.Y += s.DY
+	if s.X <= 0 || s.X >= maxX {
+		s.DX = -s.DX
+		s.X = min(max(s.X, 0), maxX)
+	}
+	if s.Y <= 0 || s.Y >= maxY {
+		s.DY = -s.DY
+		s.Y = min(max(s.Y, 0), maxY)
+	}
+	return s
+}
+
+func renderSaverLogo() string {
+	return styles.Box.Render(lipgloss.JoinVertical(lipgloss.Center,
+		styles.Highlight.Bold(true).Render("TERMPLAY"),
+		styles.Subtle.Render("press any key"),
+	))
+}
+
+func renderScreensaver(m Model) string {
+	if m.reduceMotion() {
+		return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, renderSaverLogo())
+	}
+	return lipgloss.NewStyle().
+		MarginLeft(m.Saver.X).
+		MarginTop(m.Saver.Y).
+		Render(renderSaverLogo())
+}
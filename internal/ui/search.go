@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/fuzzy"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// roomMatch is how a room matched the public list search: its score, and
+// the matched characters of its title (see roomTitle) or of its code,
+// whichever matched better.
+type roomMatch struct {
+	score int
+	title []int
+	code  []int
+}
+
+// matchRoom fuzzy-matches the search against the room's title, which
+// holds the host's name, and its code. With no search, every room
+// matches.
+func (m Model) matchRoom(r db.Room) (roomMatch, bool) {
+	query := strings.TrimSpace(m.SearchInput.Value())
+	if query == "" {
+		return roomMatch{}, true
+	}
+	ts, tp, tok := fuzzy.Match(query, roomTitle(r, m.Settings.ASCII))
+	cs, cp, cok := fuzzy.Match(query, r.Code)
+	switch {
+	case cok && (!tok || cs > ts):
+		return roomMatch{score: cs, code: cp}, true
+	case tok:
+		return roomMatch{score: ts, title: tp}, true
+	}
+	return roomMatch{}, false
+}
+
+// searchRooms keeps the rooms matching the search and the filter chips,
+// best matches first.
+func (m Model) searchRooms(rooms []db.Room) []db.Room {
+	type scored struct {
+		room  db.Room
+		score int
+	}
+	var hits []scored
+	for _, r := range rooms {
+		if !m.ListFilter.matches(r) {
+			continue
+		}
+		if hit, ok := m.matchRoom(r); ok {
+			hits = append(hits, scored{r, hit.score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	out := make([]db.Room, len(hits))
+	for i, h := range hits {
+		out[i] = h.room
+	}
+	return out
+}
+
+// highlightRunes renders s in style, with the runes at pos bold and
+// underlined.
+func highlightRunes(s string, pos []int, style lipgloss.Style) string {
+	if len(pos) == 0 {
+		return style.Render(s)
+	}
+	hl := style.Bold(true).Underline(true)
+	at := map[int]bool{}
+	for _, i := range pos {
+		at[i] = true
+	}
+	var b strings.Builder
+	runes := []rune(s)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && at[end] == at[start] {
+			end++
+		}
+		if at[start] {
+			b.WriteString(hl.Render(string(runes[start:end])))
+		} else {
+			b.WriteString(style.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// serverStatusRefresh is how often the status screen updates while open.
+const serverStatusRefresh = 2 * time.Second
+
+// ServerStatusState is the server status screen.
+type ServerStatusState struct {
+	Loading bool
+	Polling bool // a refresh chain is running
+	Info    db.ServerStatus
+	Err     error
+}
+
+type serverStatusMsg struct {
+	info db.ServerStatus
+	err  error
+}
+
+type serverStatusTickMsg struct{}
+
+// openServerStatus shows the status screen and starts refreshing it,
+// unless a refresh chain from an earlier visit is still running.
+func (m Model) openServerStatus() (Model, tea.Cmd) {
+	m.State = StateServerStatus
+	if m.ServerStatus.Polling {
+		return m, nil
+	}
+	m.ServerStatus.Loading = true
+	m.ServerStatus.Polling = true
+	return m, fetchServerStatusCmd()
+}
+
+// updateServerStatus handles the refresh chain whatever the screen, so
+// it can stop itself once the player has left the status screen.
+func updateServerStatus(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case serverStatusMsg:
+		m.ServerStatus.Loading = false
+		m.ServerStatus.Err = msg.err
+		if msg.err == nil {
+			m.ServerStatus.Info = msg.info
+		}
+		if m.State != StateServerStatus {
+			m.ServerStatus.Polling = false
+			return m, nil
+		}
+		return m, tea.Tick(serverStatusRefresh, func(time.Time) tea.Msg { return serverStatusTickMsg{} })
+	case serverStatusTickMsg:
+		if m.State != StateServerStatus {
+			m.ServerStatus.Polling = false
+			return m, nil
+		}
+		return m, fetchServerStatusCmd()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.State = StateGameSelect
+			m.MenuIndex = gameSelectStatus
+		}
+	}
+	return m, nil
+}
+
+func renderServerStatus(m Model) string {
+	s := m.ServerStatus
+	row := func(label, value string) string {
+		return styles.ItemBlurred.Render(fmt.Sprintf("%s %s", padWidth(label, 18), value))
+	}
+	store := func(n int) string {
+		if s.Loading {
+			return "…"
+		}
+		return fmt.Sprint(n)
+	}
+	rows := []string{
+		row("Uptime", formatUptime(time.Since(metrics.Started))),
+		row("Players online", fmt.Sprint(metrics.Sessions.Load())),
+		row("Active games", store(s.Info.Playing)),
+		row("Open rooms", store(s.Info.Rooms)),
+		row("Games today", store(s.Info.GamesToday)),
+		row("Version", metrics.BuildVersion()),
+		row("Protocol", fmt.Sprint(db.Protocol)),
+	}
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render(m.tr("SERVER STATUS")),
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+	)
+	if s.Err != nil {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, "", styles.Err.Render("Game counts unavailable: "+s.Err.Error()))
+	}
+	return content
+}
+
+// formatUptime shows a duration as "3d 4h 5m", dropping leading zeros.
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+func fetchServerStatusCmd() tea.Cmd {
+	return func() tea.Msg {
+		info, err := db.GetServerStatus()
+		return serverStatusMsg{info, err}
+	}
+}
+
+// renderBuildLine is the footer line naming the server's build. When the
+// database wants a newer protocol it nudges instead: every player once
+// saving is blocked, only admins while it is just a warning.
+func renderBuildLine(m Model) string {
+	c := db.Outdated()
+	switch {
+	case c != nil && c.Block:
+		return styles.Err.Render(fmt.Sprintf("This server is out of date (protocol %d, %d needed): games can't be saved", db.Protocol, c.MinProtocol))
+	case c != nil && config.AdminKeys[m.SessionID]:
+		return styles.Err.Render(fmt.Sprintf("Upgrade this server: it speaks protocol %d, the database asks for %d", db.Protocol, c.MinProtocol))
+	}
+	return styles.Subtle.Render("termplay " + metrics.BuildVersion())
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/metrics"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+)
+
+// LiveSession is one session on this server, as the admin session
+// inspector sees it. The session's program keeps it up to date.
+type LiveSession struct {
+	Seq     int64 // order of arrival, unique on this server
+	Addr    string
+	User    string
+	Started time.Time
+
+	sent  *metrics.Throughput
+	inbox chan string   // messages from admins
+	done  chan struct{} // closed once the session is gone
+	close func() error
+
+	// The player and the rooms to leave, see duplicate.go
+	cleanup *CleanupState
+
+	mu        sync.Mutex
+	id        string
+	state     SessionState
+	name      string
+	room      string
+	lastInput time.Time
+	width     int
+	height    int
+}
+
+// SessionInfo is a copy of what a LiveSession last reported.
+type SessionInfo struct {
+	Seq           int64
+	Addr, User    string
+	Name          string
+	Screen        string
+	Room          string
+	Idle          time.Duration
+	Width, Height int
+	Sent          int64
+	Started       time.Time
+}
+
+// sessions is the registry of live sessions on this server.
+var sessions = struct {
+	sync.Mutex
+	next int64
+	live map[int64]*LiveSession
+}{live: map[int64]*LiveSession{}}
+
+// liveKey holds a session's registry entry in its context.
+type liveKey struct{}
+
+// RegisterSession lists s in the session registry until the returned
+// function is called, once the session has ended.
+func RegisterSession(s ssh.Session) func() {
+	l := &LiveSession{
+		Addr:    s.RemoteAddr().String(),
+		User:    s.User(),
+		Started: time.Now(),
+		sent:    SessionThroughput(s),
+		inbox:   make(chan string, 4),
+		done:    make(chan struct{}),
+		close:   s.Close,
+	}
+	sessions.Lock()
+	sessions.next++
+	l.Seq = sessions.next
+	sessions.live[l.Seq] = l
+	sessions.Unlock()
+	s.Context().SetValue(liveKey{}, l)
+
+	return func() {
+		sessions.Lock()
+		delete(sessions.live, l.Seq)
+		sessions.Unlock()
+		close(l.done)
+	}
+}
+
+// liveSession returns the registry entry of s, or nil.
+func liveSession(s ssh.Session) *LiveSession {
+	if s == nil {
+		return nil
+	}
+	l, _ := s.Context().Value(liveKey{}).(*LiveSession)
+	return l
+}
+
+// ListSessions returns the live sessions, oldest first.
+func ListSessions() []SessionInfo {
+	sessions.Lock()
+	live := make([]*LiveSession, 0, len(sessions.live))
+	for _, l := range sessions.live {
+		live = append(live, l)
+	}
+	sessions.Unlock()
+
+	infos := make([]SessionInfo, 0, len(live))
+	for _, l := range live {
+		infos = append(infos, l.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Seq < infos[j].Seq })
+	return infos
+}
+
+// findSession returns the live session numbered seq, or nil.
+func findSession(seq int64) *LiveSession {
+	sessions.Lock()
+	defer sessions.Unlock()
+	return sessions.live[seq]
+}
+
+func (l *LiveSession) info() SessionInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return SessionInfo{
+		Seq:     l.Seq,
+		Addr:    l.Addr,
+		User:    l.User,
+		Name:    l.name,
+		Screen:  stateNames[l.state],
+		Room:    l.room,
+		Idle:    time.Since(l.lastInput),
+		Width:   l.width,
+		Height:  l.height,
+		Sent:    l.sent.Sent(),
+		Started: l.Started,
+	}
+}
+
+// observe records where m is, after each message.
+func (l *LiveSession) observe(m Model) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.id, l.state, l.name, l.room = m.SessionID, m.State, m.MyName, m.RoomCode
+	l.lastInput, l.width, l.height = m.LastInput, m.Width, m.Height
+}
+
+// attach records the player of the session and the rooms it leaves when
+// it ends.
+func (l *LiveSession) attach(id string, cleanup *CleanupState) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.id, l.cleanup = id, cleanup
+}
+
+func (l *LiveSession) playerID() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.id
+}
+
+// handOver ends the session for another connection of the same player,
+// which takes over its room: ending it doesn't give up the seat. It
+// returns the room, or "".
+func (l *LiveSession) handOver() string {
+	l.mu.Lock()
+	c := l.cleanup
+	l.mu.Unlock()
+	var room string
+	if c != nil {
+		c.Mu.Lock()
+		room, c.RoomCode = c.RoomCode, ""
+		c.Mu.Unlock()
+	}
+	l.Disconnect()
+	return room
+}
+
+// Message shows text on the session's screen. It reports false if the
+// session has messages queued already.
+func (l *LiveSession) Message(text string) bool {
+	select {
+	case l.inbox <- text:
+		return true
+	default:
+		return false
+	}
+}
+
+// Disconnect ends the session.
+func (l *LiveSession) Disconnect() error {
+	return l.close()
+}
+
+// adminMessageMsg is a message an admin sent to this session.
+type adminMessageMsg string
+
+// waitAdminMessageCmd waits for the next message an admin sends to this
+// session, until the session ends.
+func waitAdminMessageCmd(l *LiveSession) tea.Cmd {
+	if l == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case text := <-l.inbox:
+			return adminMessageMsg(text)
+		case <-l.done:
+			return nil
+		}
+	}
+}
+
+// stateNames are the screens as the session inspector lists them.
+var stateNames = map[SessionState]string{
+	StateNameInput:    "name",
+	StateMenu:         "menu",
+	StatePublicList:   "public rooms",
+	StateCreateConfig: "create room",
+	StateInputCode:    "join by code",
+	StateLobby:        "lobby",
+	StateGame:         "game",
+	StateGameSelect:   "game select",
+	StateSnakeGame:    "snake",
+	StateScreensaver:  "screensaver",
+	StateSettings:     "settings",
+	StateTutorial:     "tutorial",
+	StatePuzzle:       "puzzle",
+	StateAdmin:        "admin",
+	StateLeaderboard:  "leaderboard",
+	StateLobbyChat:    "lobby chat",
+	StateServerStatus: "server status",
+	StateMaintenance:  "maintenance",
+	StateRoomAlert:    "watching for rooms",
+}
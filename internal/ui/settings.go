@@ -0,0 +1,376 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type profileLoadedMsg db.Profile
+type settingsSavedMsg struct{}
+
+// nameClaimedMsg means the name entered is free for this player to use.
+// A guest who entered a name someone reserved gets it marked as theirs,
+// and taken is the name they asked for.
+type nameClaimedMsg struct{ name, taken string }
+
+// nameReservedMsg means the player now owns their current name.
+type nameReservedMsg struct{ name string }
+
+// settingRow describes one line of the settings screen. Choice rows cycle
+// through options with left/right; toggle rows flip with enter/space.
+type settingRow struct {
+	label   string
+	options []string
+	get     func(s db.Settings) string
+	set     func(s *db.Settings, v string)
+}
+
+var settingRows = []settingRow{
+	{
+		label:   "Theme",
+		options: themes,
+		get:     func(s db.Settings) string { return s.Theme },
+		set:     func(s *db.Settings, v string) { s.Theme = v },
+	},
+	{
+		label:   "Keybindings",
+		options: []string{"default", "wasd"},
+		get:     func(s db.Settings) string { return s.Keybindings },
+		set:     func(s *db.Settings, v string) { s.Keybindings = v },
+	},
+	{
+		label:   "Reduce motion",
+		options: []string{"off", "on"},
+		get:     func(s db.Settings) string { return onOff(s.ReduceMotion) },
+		set:     func(s *db.Settings, v string) { s.ReduceMotion = v == "on" },
+	},
+	{
+		label:   "Low bandwidth",
+		options: []string{"auto", "on", "off"},
+		get: func(s db.Settings) string {
+			if s.LowBandwidth == "" {
+				return "auto"
+			}
+			return s.LowBandwidth
+		},
+		set: func(s *db.Settings, v string) {
+			if v == "auto" {
+				v = ""
+			}
+			s.LowBandwidth = v
+		},
+	},
+	{
+		label:   "Bell on turn",
+		options: []string{"off", "on"},
+		get:     func(s db.Settings) string { return onOff(s.BellOnTurn) },
+		set:     func(s *db.Settings, v string) { s.BellOnTurn = v == "on" },
+	},
+	{
+		label:   "Confirm moves",
+		options: []string{"off", "on"},
+		get:     func(s db.Settings) string { return onOff(s.ConfirmMove) },
+		set:     func(s *db.Settings, v string) { s.ConfirmMove = v == "on" },
+	},
+	{
+		label:   "Mark",
+		options: markPresets,
+		get: func(s db.Settings) string {
+			if s.Mark == "" {
+				return "default"
+			}
+			return s.Mark
+		},
+		set: func(s *db.Settings, v string) {
+			if v == "default" {
+				v = ""
+			}
+			s.Mark = v
+		},
+	},
+	{
+		label:   "Mark color",
+		options: markColorNames(),
+		get: func(s db.Settings) string {
+			if s.MarkColor == "" {
+				return "default"
+			}
+			return s.MarkColor
+		},
+		set: func(s *db.Settings, v string) {
+			if v == "default" {
+				v = ""
+			}
+			s.MarkColor = v
+		},
+	},
+	{
+		label:   "ASCII mode",
+		options: []string{"off", "on"},
+		get:     func(s db.Settings) string { return onOff(s.ASCII) },
+		set:     func(s *db.Settings, v string) { s.ASCII = v == "on" },
+	},
+	{
+		label:   "Language",
+		options: []string{"en", "es", "fr"},
+		get:     func(s db.Settings) string { return s.Locale },
+		set:     func(s *db.Settings, v string) { s.Locale = v },
+	},
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// cycle moves a setting to the next (dir=1) or previous (dir=-1) option.
+func (r settingRow) cycle(s db.Settings, dir int) db.Settings {
+	cur := 0
+	for i, o := range r.options {
+		if o == r.get(s) {
+			cur = i
+		}
+	}
+	next := (cur + dir + len(r.options)) % len(r.options)
+	r.set(&s, r.options[next])
+	return s
+}
+
+func updateSettings(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m, cmd, ok := updateNotifyKeys(m, msg); ok {
+			return m, cmd
+		}
+		if m, cmd, ok := updateLinkKeys(m, msg); ok {
+			return m, cmd
+		}
+		if m, cmd, ok := updateCharmKeys(m, msg); ok {
+			return m, cmd
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.MenuIndex > 0 {
+				m.MenuIndex--
+			}
+		case "down", "j":
+			if m.MenuIndex < len(settingRows)-1 {
+				m.MenuIndex++
+			}
+		case "right", "l", "enter", " ":
+			m.Settings = settingRows[m.MenuIndex].cycle(m.Settings, 1)
+		case "left", "h":
+			m.Settings = settingRows[m.MenuIndex].cycle(m.Settings, -1)
+		case "r":
+			if !m.HasKey || m.MyName == "" || db.SameName(m.ReservedName, m.MyName) {
+				return m, nil
+			}
+			m.Err = nil
+			return m, reserveNameCmd(m.SessionID, m.MyName)
+		case "esc", "q":
+			m.Err = nil
+			m.State = StateGameSelect
+			m.MenuIndex = gameSelectSettings
+			return m, saveSettingsCmd(m.SessionID, m.Settings)
+		default:
+			// Typing or pasting an emoji on the Mark row sets a custom
+			// mark. Only non-ASCII input counts, so hjkl/q keep working.
+			if settingRows[m.MenuIndex].label == "Mark" && msg.Type == tea.KeyRunes {
+				s := string(msg.Runes)
+				if s[0] >= 0x80 {
+					if mark, ok := normalizeMark(s); ok {
+						m.Settings.Mark = mark
+					}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func renderSettings(m Model) string {
+	var rows []string
+	for i, r := range settingRows {
+		line := fmt.Sprintf("%s ‹ %s ›", padWidth(m.tr(r.label), 16), padWidth(r.get(m.Settings), 8))
+		if i == m.MenuIndex {
+			rows = append(rows, styles.ItemFocused.Render(line))
+		} else {
+			rows = append(rows, styles.ItemBlurred.Render(line))
+		}
+	}
+	saved := styles.Subtle.Render(m.tr("Saved to your SSH key"))
+	if guest := m.guestNotice(); guest != "" {
+		saved = guest
+	}
+	footer := []string{
+		saved,
+		styles.Subtle.Render("ID: " + m.SessionID),
+		"",
+		m.renderNameReservation(),
+		m.renderLinking(),
+		m.renderCharm(),
+		m.renderTurnNotify(),
+	}
+	if settingRows[m.MenuIndex].label == "Mark" {
+		footer = append([]string{styles.Subtle.Render(m.tr("Type an emoji for a custom mark")), ""}, footer...)
+	}
+	if settingRows[m.MenuIndex].label == "Mark color" {
+		glyph := defaultMarks.X
+		if mark, ok := normalizeMark(m.Settings.Mark); ok && !m.Settings.ASCII {
+			glyph = mark
+		}
+		sample := colored(styles.XStyle, markColor(m.Settings.MarkColor, "")).Render(glyph)
+		footer = append([]string{sample + " " + styles.Subtle.Render(m.tr("If both players pick the same color, the host keeps it")), ""}, footer...)
+	}
+	if b := renderBadgeList(m.Badges, m.Settings.ASCII); b != "" {
+		footer = append(footer, styles.Special.Render(b))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center,
+		append([]string{
+			styles.Title.Render(m.tr("SETTINGS")),
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+		}, footer...)...,
+	)
+}
+
+func loadProfileCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		p, err := db.GetProfile(id)
+		if err != nil {
+			// Not fatal: keep defaults for this session
+			return nil
+		}
+		if !p.Badges[db.BadgeEarly] && !db.IsGuest(id) && time.Now().Before(config.EarlyAdopterUntil) {
+			if err := db.AwardBadge(id, db.BadgeEarly); err == nil {
+				if p.Badges == nil {
+					p.Badges = make(map[string]bool)
+				}
+				p.Badges[db.BadgeEarly] = true
+			}
+		}
+		return profileLoadedMsg(*p)
+	}
+}
+
+func saveSettingsCmd(id string, s db.Settings) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.SaveSettings(id, s); err != nil {
+			return errMsg(fmt.Errorf("could not save settings: %v", err))
+		}
+		return settingsSavedMsg{}
+	}
+}
+
+// renderNameReservation shows how others see the player's name and
+// whether they can reserve it.
+func (m Model) renderNameReservation() string {
+	switch {
+	case m.MyName == "":
+		return ""
+	case db.SameName(m.ReservedName, m.MyName):
+		return styles.Special.Render(m.tr("Your name is reserved") + ": " + displayName(m.MyName))
+	case !m.HasKey:
+		return styles.Subtle.Render(m.tr("Shown as") + " " + taggedName(m.SessionID, m.MyName) + " • " + m.tr("connect with an SSH key to reserve it"))
+	}
+	return styles.Subtle.Render(m.tr("Shown as") + " " + taggedName(m.SessionID, m.MyName) + " • " + m.tr("R: reserve this name"))
+}
+
+// claimNameCmd checks that nobody else reserved the name.
+func claimNameCmd(id, name string) tea.Cmd {
+	return func() tea.Msg {
+		if !db.IsGuest(id) && strings.HasSuffix(name, guestSuffix) {
+			return errMsg(errGuestSuffix)
+		}
+		owner, err := db.NameOwner(name)
+		if err != nil {
+			// Don't lock players out over a failed lookup
+			return nameClaimedMsg{name: name}
+		}
+		if owner != "" && owner != id {
+			if db.IsGuest(id) {
+				// A guest can't reserve a name, so isn't turned away;
+				// they just can't pass for its owner
+				return nameClaimedMsg{name: guestName(name), taken: name}
+			}
+			return errMsg(db.ErrNameTaken)
+		}
+		return nameClaimedMsg{name: name}
+	}
+}
+
+func reserveNameCmd(id, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := db.ReserveName(id, name); err != nil {
+			return errMsg(err)
+		}
+		return nameReservedMsg{name}
+	}
+}
+
+func saveNameCmd(id, name string) tea.Cmd {
+	return saveCmd("saving your name", func() error { return db.SaveProfileName(id, name) })
+}
+
+// remapKey translates alternative movement keys into arrow keys so game
+// input handlers only need to know about one layout.
+func (m Model) remapKey(msg tea.KeyMsg) tea.KeyMsg {
+	if m.Settings.Keybindings != "wasd" {
+		return msg
+	}
+	switch msg.String() {
+	case "w":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "a":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "s":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "d":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	}
+	return msg
+}
+
+// bellCmd rings the terminal bell on the player's own terminal.
+func (m Model) bellCmd() tea.Cmd {
+	if m.Out == nil {
+		return nil
+	}
+	out := m.Out
+	return func() tea.Msg {
+		out.Write([]byte("\a"))
+		return nil
+	}
+}
+
+// confirmMove reports whether a move at the cursor may be sent. With
+// confirmation on, the first press only marks the cell as pending and the
+// second press on the same cell commits it. Ctrl+Enter commits at once.
+func (m Model) confirmMove() (Model, bool) {
+	if !m.Settings.ConfirmMove || m.SkipConfirm {
+		m.MovePending = false
+		return m, true
+	}
+	if m.MovePending && m.PendingR == m.CursorR && m.PendingC == m.CursorC {
+		m.MovePending = false
+		return m, true
+	}
+	m.MovePending = true
+	m.PendingR, m.PendingC = m.CursorR, m.CursorC
+	return m, false
+}
+
+// awaitingMyMove reports whether the game is waiting on the local player.
+func (m Model) awaitingMyMove() bool {
+	return m.State == StateGame && m.Game.Status == "playing" && m.MySide != "Spectator" && m.isMyTurn()
+}
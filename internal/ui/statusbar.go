@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/metrics"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// staleSyncAfter is when the "last sync" reading turns into a warning.
+const staleSyncAfter = 5 * time.Second
+
+type statusTickMsg struct{}
+
+func statusTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return statusTickMsg{} })
+}
+
+// inRoom reports whether the player is on a screen that shows the status
+// bar: the lobby or a game, playing or spectating.
+func (m Model) inRoom() bool {
+	return m.RoomCode != "" && (m.State == StateLobby || m.State == StateGame)
+}
+
+// updateStatusBar keeps a once-a-second tick running while in a room, so
+// "last sync" keeps counting up when updates stop arriving.
+func updateStatusBar(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	if _, ok := msg.(statusTickMsg); ok {
+		if !m.inRoom() {
+			m.StatusTicking = false
+			return m, nil
+		}
+		var bell, claim, lag tea.Cmd
+		if m.nudging() && !m.Nudged {
+			m.Nudged = true
+			if m.Settings.BellOnTurn {
+				bell = m.bellCmd()
+			}
+		}
+		if m.shouldClaimTimeout() {
+			m.ClaimedAt = time.Now()
+			claim = claimTimeoutCmd(m.RoomCode, m.SessionID, m.Game)
+		} else if m.shouldClaimForfeit() {
+			m.ClaimedAt = time.Now()
+			claim = claimForfeitCmd(m.RoomCode, m.SessionID, m.Game)
+		}
+		if m.shouldReportLag() {
+			m.LagSent = m.Latency.Get()
+			lag = setLagCmd(m.RoomCode, m.SessionID, m.LagSent)
+		}
+		return m, tea.Batch(m.statusTickCmd(), bell, claim, lag)
+	}
+	if m.inRoom() && !m.StatusTicking {
+		m.StatusTicking = true
+		return m, m.statusTickCmd()
+	}
+	return m, nil
+}
+
+// renderStatusBar draws "side • last sync • ping • rtt" for the footer,
+// above the status line with the room code: ping is the server's
+// database round-trip, rtt the player's own. The sync reading turns red
+// once updates have stalled.
+func renderStatusBar(m Model) string {
+	parts := []string{
+		styles.Subtle.Render(m.sideLabel()),
+	}
+	switch dropped := m.renderDropped(); {
+	case dropped != "":
+		parts = append(parts, dropped)
+	case m.awaitingMyMove():
+		parts = append(parts, m.renderTurnChip())
+	case m.opponentAway():
+		parts = append(parts, styles.Err.Render("opponent seems away"))
+	}
+	if clock := m.renderMoveClock(); clock != "" {
+		parts = append(parts, clock)
+	}
+	switch {
+	case m.Resyncing:
+		parts = append(parts, styles.Special.Render("resyncing…"))
+	case m.LastSync.IsZero():
+		parts = append(parts, styles.Subtle.Render("syncing…"))
+	default:
+		ago := time.Since(m.LastSync)
+		style := styles.Subtle
+		if ago >= staleSyncAfter {
+			style = styles.Err
+		}
+		parts = append(parts, style.Render(fmt.Sprintf("last sync %.1fs ago", ago.Seconds())))
+	}
+	if ping := metrics.DBLatency(); ping > 0 {
+		parts = append(parts, styles.Subtle.Render(fmt.Sprintf("ping %dms", ping.Milliseconds())))
+	}
+	if rtt := m.Latency.Get(); rtt > 0 {
+		parts = append(parts, styles.Subtle.Render(fmt.Sprintf("rtt %dms", rtt.Milliseconds())))
+	}
+	return strings.Join(parts, styles.Subtle.Render(" • "))
+}
+
+// renderTurnChip is the YOUR TURN marker. Once the player has been idle
+// on their move for config.TurnNudgeAfter it turns red and flashes,
+// or just stays red with reduced motion.
+func (m Model) renderTurnChip() string {
+	const chip = " YOUR TURN "
+	if !m.nudging() {
+		return styles.Special.Render(chip)
+	}
+	if !m.reduceMotion() && time.Now().Second()%2 == 0 {
+		return styles.Err.Reverse(true).Render(chip)
+	}
+	return styles.Err.Render(chip)
+}
+
+// turnIdle is how long the game has waited on the local player without a
+// key press from them, or 0 while it isn't their move.
+func (m Model) turnIdle() time.Duration {
+	if !m.awaitingMyMove() || m.TurnSince.IsZero() {
+		return 0
+	}
+	since := m.TurnSince
+	if m.LastInput.After(since) {
+		since = m.LastInput
+	}
+	return time.Since(since)
+}
+
+// nudging reports whether the player should be reminded it is their move.
+func (m Model) nudging() bool {
+	return config.TurnNudgeAfter > 0 && m.turnIdle() >= config.TurnNudgeAfter
+}
+
+// opponentAway reports whether a human opponent has sat on their move for
+// config.AwayNoticeAfter. Only moves are seen, so "away" is a guess.
+func (m Model) opponentAway() bool {
+	if config.AwayNoticeAfter <= 0 || m.State != StateGame || m.Game.Status != "playing" ||
+		m.MySide == "Spectator" || m.isMyTurn() || m.Game.PlayerO == db.BotID || m.TurnSince.IsZero() {
+		return false
+	}
+	return time.Since(m.TurnSince) >= config.AwayNoticeAfter
+}
+
+// sideLabel names the player's seat the way the current game does.
+func (m Model) sideLabel() string {
+	switch {
+	case m.MySide == "Spectator":
+		return "spectating"
+	case m.State == StateLobby:
+		return "host"
+	case m.Game.GameType == "chess" && m.MySide == "X":
+		return "you: White"
+	case m.Game.GameType == "chess":
+		return "you: Black"
+	}
+	return "you: " + m.MySide
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The status line is the last line of every screen: who is playing, the
+// room they are in, and the keys that matter most on the screen. allKeys
+// spells out the rest.
+
+// allKeys shows every key of the screen in the status line, not just the
+// first statusKeys.
+const allKeys = "f1"
+
+// statusKeys is how many keys the status line names before "F1: More".
+const statusKeys = 3
+
+// keyHint is one key of a screen, e.g. {"Esc", "Back"}.
+type keyHint struct {
+	key, action string
+}
+
+// keyHints lists the keys of the screen, the most useful first.
+func (m Model) keyHints() []keyHint {
+	switch m.State {
+	case StateNameInput:
+		return []keyHint{{"Enter", "Confirm"}, {"Ctrl+C", "Quit"}}
+
+	case StateMenu:
+		hints := []keyHint{{"Enter", "Select"}, {"↑/↓", "Navigate"}}
+		if m.rejoinCode() != "" {
+			hints = append([]keyHint{{"R", "Return"}}, hints...)
+		}
+		if len(m.Correspondence) > 0 {
+			hints = append(hints, keyHint{"C", "Resume"})
+		}
+		if m.MenuIndex == 3 {
+			hints = append(hints, keyHint{"←/→", m.tr("Random") + "/" + m.tr("Top rated")})
+		}
+		return hints
+
+	case StateCreateConfig:
+		hints := []keyHint{{"Enter", "Create"}, {"↑/↓", "Visibility"}, {"Esc", "Back"}, {"R", "Ranked/Casual"}, {"C", "Pace"}}
+		if m.SelectedGame == "chess" {
+			return hints
+		}
+		if !m.Rules.Ranked {
+			if !m.Rules.Correspondence {
+				hints = append(hints, keyHint{"←/→", "Bot Difficulty"})
+			}
+			hints = append(hints, keyHint{"Tab", "Handicap"})
+		}
+		return append(hints, keyHint{"S", "Swap Rule"})
+
+	case StateInputCode:
+		return []keyHint{{"Enter", "Join"}, {"Esc", "Back"}}
+
+	case StatePublicList:
+		var hints []keyHint
+		if m.SearchInput.Focused() {
+			hints = []keyHint{{"Enter/Esc/↓", "Back to List"}, {"Ctrl+G/O/K", "Game/Mode/Clock"}}
+		} else {
+			hints = []keyHint{{"Enter", "Join"}, {"/", "Search"}, {"Esc", "Back"}, {"↑/↓ j/k", "Navigate"},
+				{"PgUp/PgDn/Home/End", "Scroll"}, {"Ctrl+G/O/K", "Game/Mode/Clock"}, {"W", "Watch for Rooms"}}
+		}
+		if config.AdminKeys[m.SessionID] {
+			hints = append(hints, keyHint{"Ctrl+F", "Feature"})
+		}
+		return hints
+
+	case StateLobby:
+		hints := []keyHint{{"P", "Browse"}, {"V", "Bot Game"}, {"Esc", "Leave Room"}}
+		if m.canOfferBot() {
+			hints = append([]keyHint{{"B", "Play vs Bot"}}, hints...)
+		}
+		return hints
+
+	case StateMaintenance:
+		return []keyHint{{"Q", "Quit"}}
+
+	case StateServerStatus:
+		return []keyHint{{"Esc", "Back"}}
+
+	case StateLobbyChat:
+		return []keyHint{{"Enter", "Send"}, {"Esc", "Back"}, {"Ctrl+R", "Report Last"}, {"Ctrl+X", "Mute Last"}}
+
+	case StateGameSelect:
+		return []keyHint{{"Enter", "Select"}, {"↑/↓", "Navigate"}}
+
+	case StateTutorial:
+		return []keyHint{{"Ctrl+C", "Quit"}}
+
+	case StatePuzzle:
+		return []keyHint{{"Space", "Play"}, {"N/P", "Next/Prev"}, {"Esc", "Back"}, {"Arrows", "Move"}, {"R", "Reset"}}
+
+	case StateSettings:
+		if m.Link.Entering {
+			return []keyHint{{"Enter", "Link"}, {"Esc", "Cancel"}}
+		}
+		return []keyHint{{"←/→", "Change"}, {"↑/↓", "Select"}, {"Esc", "Save & Back"}}
+
+	case StateLeaderboard:
+		return []keyHint{{"←/→", "Page"}, {"Esc", "Back"}}
+
+	case StateRoomAlert:
+		if m.RoomAlert.Found != nil {
+			return []keyHint{{"Enter", "Join"}, {"N", "Keep Watching"}, {"Esc", "Stop Watching"}, {"A", "Auto-join"}}
+		}
+		return []keyHint{{"A", "Auto-join"}, {"Esc", "Stop Watching"}}
+
+	case StateAdmin:
+		return m.adminKeyHints()
+
+	case StateGame:
+		return m.gameKeyHints()
+	}
+	return nil
+}
+
+// adminKeyHints are the keys of the admin screen's current view.
+func (m Model) adminKeyHints() []keyHint {
+	switch {
+	case m.Admin.Composing:
+		return []keyHint{{"Enter", "Send"}, {"Esc", "Cancel"}}
+	case m.Admin.ShowSessions:
+		return []keyHint{{"M", "Message"}, {"X", "Disconnect"}, {"Esc", "Back"}, {"↑/↓", "Select"},
+			{"I", "Queue"}, {"O", "Maintenance"}, {"R", "Refresh"}}
+	case m.Admin.ShowStats:
+		return []keyHint{{"+/-", "Slower/Faster Polling"}, {"0", "Reset Polling"}, {"Esc", "Back"},
+			{"S", "Queue"}, {"O", "Maintenance"}, {"R", "Refresh"}}
+	case m.Admin.ShowLog:
+		return []keyHint{{"E", "Queue"}, {"S", "Stats"}, {"Esc", "Back"}, {"O", "Maintenance"}, {"R", "Refresh"}}
+	}
+	return []keyHint{{"W", "Warn"}, {"M", "Mute"}, {"B", "Ban"}, {"↑/↓", "Select"}, {"D", "Dismiss"},
+		{"E", "Room Log"}, {"S", "Stats"}, {"I", "Sessions"}, {"O", "Maintenance"}, {"R", "Refresh"}, {"Esc", "Back"}}
+}
+
+// gameKeyHints are the keys of the game screen, for the game played and
+// what the player may do in it.
+func (m Model) gameKeyHints() []keyHint {
+	if m.ChatOpen {
+		return []keyHint{{"Enter", "Send"}, {"Esc", "Cancel"}}
+	}
+	var hints []keyHint
+	if m.Game.GameType == "chess" {
+		hints = []keyHint{{"Enter/Space", "Select"}, {"Q", "Quit"}, {"T", "Chat"}, {"Arrows/hjkl", "Move"},
+			{"Esc", "Deselect"}, {"F", "Font"}}
+	} else if m.Game.Status == "finished" {
+		// Restart is what a finished game is waiting on
+		hints = []keyHint{{"R", "Restart"}, {"Q", "Quit"}, {"T", "Chat"}}
+	} else if m.canPremove() {
+		hints = []keyHint{{"Space", "Premove"}, {"Q", "Quit"}, {"T", "Chat"}, {"Arrows", "Move"}}
+	} else {
+		hints = []keyHint{{"Space", "Place"}, {"Q", "Quit"}, {"T", "Chat"}, {"Arrows", "Move"}, {"R", "Restart"}}
+	}
+	if m.canKick() {
+		hints = append(hints, keyHint{"X", "Kick"})
+	}
+	if m.canSwap() {
+		hints = append(hints, keyHint{"S", "Swap Sides"})
+	}
+	hints = append(hints, keyHint{"+/-", "Zoom"})
+	if m.EnhancedKeys && m.Settings.ConfirmMove {
+		hints = append(hints, keyHint{"Ctrl+Enter", "Place Now"})
+	}
+	if len(chatLines(m, m.chatWidth())) > chatVisible {
+		hints = append(hints, keyHint{"PgUp/PgDn", "Scroll Chat"})
+	}
+	if m.opponentID() != "" {
+		hints = append(hints, keyHint{"M", "Mute"}, keyHint{"!", "Report"})
+	}
+	return hints
+}
+
+// renderStatusLine is "name • CODE • keys" on one line, or on as many as
+// it takes to name every key once the player asked for them all.
+func renderStatusLine(m Model) string {
+	var parts []string
+	if m.MyName != "" {
+		parts = append(parts, styles.Highlight.Render(m.MyName))
+	}
+	if m.RoomCode != "" {
+		parts = append(parts, styles.Subtle.Render(m.RoomCode))
+	}
+	hints := m.keyHints()
+	more := !m.AllKeys && len(hints) > statusKeys
+	if more {
+		hints = hints[:statusKeys]
+	}
+	for _, h := range hints {
+		parts = append(parts, styles.Subtle.Render(m.tr(h.key)+": "+m.tr(h.action)))
+	}
+	if more {
+		parts = append(parts, styles.Subtle.Render("F1: "+m.tr("More")))
+	}
+	line := strings.Join(parts, styles.Subtle.Render(" • "))
+	if m.Width <= 0 {
+		return line
+	}
+	if m.AllKeys {
+		return lipgloss.NewStyle().Width(m.Width).Align(lipgloss.Center).Render(line)
+	}
+	return lipgloss.NewStyle().MaxWidth(m.Width).Render(line)
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Store calls run off the update loop, so a slow database never freezes
+// the screen; but a player who pressed a key and sees nothing happen will
+// press it again. storeCmd puts a spinner at the foot of the screen once
+// a call runs past config.StoreSlowAfter, and gives up on it with an
+// error past config.StoreTimeout.
+
+// storeOp is one store call in flight.
+type storeOp struct {
+	what    string // e.g. "Placing your mark"
+	started time.Time
+	done    atomic.Bool
+}
+
+// storeDoneMsg is the message of a store call, once it returned or was
+// given up on.
+type storeDoneMsg struct {
+	op  *storeOp
+	msg tea.Msg
+}
+
+// storeSlowMsg is a store call that ran past config.StoreSlowAfter.
+type storeSlowMsg struct{ op *storeOp }
+
+// storeCallMsg is a store call about to run. The session's Guard runs it
+// in place, counting it as pending; without one it reaches updateStore.
+type storeCallMsg struct{ run tea.Cmd }
+
+// storeCmd runs cmd under the store latency budget. what says what it
+// does, for the spinner and the timeout error.
+func storeCmd(what string, cmd tea.Cmd) tea.Cmd {
+	op := &storeOp{what: what, started: time.Now()}
+	run := func() tea.Msg {
+		if config.StoreTimeout <= 0 {
+			msg := cmd()
+			op.done.Store(true)
+			return storeDoneMsg{op, msg}
+		}
+		result := make(chan tea.Msg, 1)
+		go func() { result <- cmd() }()
+		var msg tea.Msg
+		select {
+		case msg = <-result:
+		case <-time.After(config.StoreTimeout):
+			// The call may still land later; the next poll will show it
+			msg = errMsg(fmt.Errorf("%s timed out after %s: the server is not responding, try again", what, config.StoreTimeout))
+		}
+		op.done.Store(true)
+		return storeDoneMsg{op, msg}
+	}
+	call := func() tea.Msg { return storeCallMsg{run} }
+	if config.StoreSlowAfter <= 0 {
+		return call
+	}
+	slow := tea.Tick(config.StoreSlowAfter, func(time.Time) tea.Msg {
+		if op.done.Load() {
+			return nil
+		}
+		return storeSlowMsg{op}
+	})
+	return tea.Batch(call, slow)
+}
+
+// updateStore tracks the slow store calls, and hands the message of a
+// finished one on as if its command had returned it directly.
+func updateStore(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case storeCallMsg:
+		return m, msg.run
+
+	case storeDoneMsg:
+		m.Slow = m.dropSlow(msg.op)
+		if msg.msg == nil {
+			return m, nil
+		}
+		return m.dispatch(msg.msg)
+
+	case storeSlowMsg:
+		if msg.op.done.Load() {
+			return m, nil
+		}
+		m.Slow = append(m.Slow, msg.op)
+		return m.spin()
+	}
+	return m, nil
+}
+
+// dropSlow is m.Slow without op.
+func (m Model) dropSlow(op *storeOp) []*storeOp {
+	var slow []*storeOp
+	for _, o := range m.Slow {
+		if o != op {
+			slow = append(slow, o)
+		}
+	}
+	return slow
+}
+
+// renderStoreLine is "⣾ Placing your mark… still working (3s)" for the
+// oldest slow store call, else what the player is waiting on, if anything.
+func renderStoreLine(m Model) string {
+	if len(m.Slow) == 0 {
+		return renderLoading(m)
+	}
+	op := m.Slow[0]
+	text := fmt.Sprintf("%s… %s (%ds)", op.what, m.tr("still working"), int(time.Since(op.started).Seconds()))
+	if more := len(m.Slow) - 1; more > 0 {
+		text += fmt.Sprintf(" +%d", more)
+	}
+	return m.spinnerFrame() + " " + styles.Subtle.Render(text)
+}
@@ -0,0 +1,20 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// suspend answers Ctrl+Z. Played locally, the game stops and the player
+// is back at their shell until they bring it back with fg. Over SSH a
+// suspend would stop the whole server, so the player is told how to
+// suspend their ssh client instead, and how to redraw the screen once
+// they are back.
+func (m Model) suspend() (Model, tea.Cmd) {
+	if m.Local {
+		return m, tea.Suspend
+	}
+	m = m.toastFor(ToastInfo, "To suspend ssh: Enter, then ~ then Ctrl+Z. Back with fg? Ctrl+L redraws.", 8*time.Second)
+	return m, nil
+}
@@ -0,0 +1,369 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/config"
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RoomTab is everything tied to one room the player is in. The room on
+// screen is embedded in Model; the rest wait in Model.Tabs, still
+// subscribed and polled, until Ctrl+N/Ctrl+P brings them back.
+type RoomTab struct {
+	RoomCode string
+	MySide   string
+	Game     db.Room
+
+	CursorR int
+	CursorC int
+
+	// Chess State
+	ChessSelected   bool
+	ChessSelRow     int
+	ChessSelCol     int
+	ChessValidMoves map[chess.Pos]bool
+
+	// Move awaiting a second Enter when Settings.ConfirmMove is on
+	MovePending        bool
+	PendingR, PendingC int
+
+	// Move staged on the opponent's turn, see premove.go
+	Premoved   bool
+	PremoveIdx int
+
+	WatchOnly bool      // the player's own room, watched from a second session
+	Notice    string    // one-off message shown in the lobby/game
+	Resyncing bool      // a refetch after a rejected room state is in flight
+	LastSync  time.Time // when a room state last arrived, for the status bar
+
+	LobbySince  time.Time // when the host started waiting alone
+	TurnSince   time.Time // when the current turn started, as seen here
+	Nudged      bool      // the turn reminder already rang for this turn
+	BotThinking bool      // a bot move is in flight
+
+	// The ranked clock, see ranked.go
+	ClaimedAt time.Time     // last claim of a win on time or by forfeit
+	LagSent   time.Duration // round-trip last reported to the room
+
+	// Pushed room updates (see internal/bus)
+	RoomEvents     <-chan []byte
+	ChatEvents     <-chan []byte
+	StopRoomEvents func()
+
+	Chat       []db.ChatMessage
+	ChatNotice string // rate limit warnings etc.
+	ChatScroll int    // lines scrolled back from the latest message, see chatView
+}
+
+func newRoomTab() RoomTab {
+	return RoomTab{
+		CursorR:         1, // Middle of 3x3
+		CursorC:         1,
+		ChessValidMoves: make(map[chess.Pos]bool),
+	}
+}
+
+// state is the screen a tab's room is shown on.
+func (t RoomTab) state() SessionState {
+	if t.MySide == "X" && t.Game.PlayerO == "" {
+		return StateLobby
+	}
+	return StateGame
+}
+
+// isMyTurn reports whether the local player is the one expected to move.
+// Chess rooms track turns as White/Black, everything else as X/O.
+func (t RoomTab) isMyTurn() bool {
+	if t.Game.GameType == "chess" {
+		return (t.MySide == "X" && t.Game.Turn == "White") || (t.MySide == "O" && t.Game.Turn == "Black")
+	}
+	return t.Game.Turn == t.MySide
+}
+
+// yourMove reports whether the room is waiting on the local player.
+func (t RoomTab) yourMove() bool {
+	return t.state() == StateGame && t.Game.Status == "playing" && t.MySide != "Spectator" && t.isMyTurn()
+}
+
+// roomOf returns the room a message belongs to, for messages tied to one.
+func roomOf(msg tea.Msg) (string, bool) {
+	switch msg := msg.(type) {
+	case roomUpdateMsg:
+		return msg.Code, true
+	case roomEventMsg:
+		return msg.code, true
+	case roomResyncedMsg:
+		return msg.code, true
+	case pollErrorMsg:
+		return msg.code, true
+	case moveFailedMsg:
+		return msg.code, true
+	case botMovedMsg:
+		return msg.code, true
+	case noticeExpiredMsg:
+		return msg.code, true
+	case chatEventMsg:
+		return msg.code, true
+	case chatLoadedMsg:
+		return msg.code, true
+	}
+	return "", false
+}
+
+// updateTab handles a message for a room in a background tab by briefly
+// putting that room on screen. Messages for rooms the player is no longer
+// in are dropped, which also ends their poll chains.
+func (m Model) updateTab(code string, msg tea.Msg) (Model, tea.Cmd) {
+	i := -1
+	for j, t := range m.Tabs {
+		if t.RoomCode == code {
+			i = j
+		}
+	}
+	if i < 0 {
+		return m, nil
+	}
+
+	state, active := m.State, m.RoomTab
+	m.RoomTab, m.State = m.Tabs[i], m.Tabs[i].state()
+	wasMyMove := m.awaitingMyMove()
+	wasLobby := m.State == StateLobby
+
+	m, cmd := m.update(msg)
+
+	var botCmd tea.Cmd
+	if m.botShouldMove() {
+		m.BotThinking = true
+		botCmd = botMoveCmd(m.RoomCode)
+	}
+	var bell tea.Cmd
+	if m.Settings.BellOnTurn && !wasMyMove && m.awaitingMyMove() {
+		bell = m.bellCmd()
+	}
+
+	joined := wasLobby && m.State == StateGame && m.RoomCode != ""
+
+	tab := m.RoomTab
+	m.RoomTab, m.State = active, state
+	tabs := append([]RoomTab(nil), m.Tabs[:i]...)
+	if tab.RoomCode != "" {
+		tabs = append(tabs, tab)
+	}
+	m.Tabs = append(tabs, m.Tabs[i+1:]...)
+	m.syncCleanup()
+
+	// Someone joined a lobby the host left waiting: say so, and take the
+	// host back there unless they are busy with something else
+	if joined {
+		m = m.toast(ToastInfo, fmt.Sprintf("%s joined room %s", displayName(tab.Game.PlayerOName), tab.RoomCode))
+		if m.canInterrupt() {
+			m = m.focusTab(i)
+		}
+	}
+	return m, tea.Batch(cmd, botCmd, bell)
+}
+
+// updateTabKeys handles Ctrl+T (park the room and open another) and
+// Ctrl+N/Ctrl+P (next/previous room). It reports whether it used the key.
+func (m Model) updateTabKeys(msg tea.KeyMsg) (Model, bool) {
+	if m.PopupActive || m.State == StateNameInput || m.State == StateScreensaver {
+		return m, false
+	}
+	switch msg.String() {
+	case "ctrl+t":
+		if !m.inRoom() {
+			return m, false
+		}
+		var ok bool
+		if m, ok = m.parkRoom(); ok {
+			m.State = StateGameSelect
+			m.MenuIndex = 0
+		}
+		return m, true
+	case "ctrl+n":
+		return m.switchTab(1), len(m.Tabs) > 0
+	case "ctrl+p":
+		return m.switchTab(-1), len(m.Tabs) > 0
+	}
+	return m, false
+}
+
+// parkRoom moves the room on screen into a background tab, leaving the
+// caller to pick the screen that replaces it. It fails, with a Notice,
+// once the player is in config.MaxTabs rooms.
+func (m Model) parkRoom() (Model, bool) {
+	if len(m.Tabs)+1 >= config.MaxTabs {
+		m.Notice = fmt.Sprintf("You can be in at most %d rooms at once", config.MaxTabs)
+		return m, false
+	}
+	m.ChatOpen = false
+	m.ChatInput.Blur()
+	m.Tabs = append(append([]RoomTab(nil), m.Tabs...), m.RoomTab)
+	m.RoomTab = newRoomTab()
+	m.Err = nil
+	m.syncCleanup()
+	return m, true
+}
+
+// switchTab brings the next (dir=1) or previous (dir=-1) room on screen.
+// The room being left keeps running in its tab; a screen that isn't a
+// room, like the menu, is simply left.
+func (m Model) switchTab(dir int) Model {
+	if len(m.Tabs) == 0 {
+		return m
+	}
+	tabs := append([]RoomTab(nil), m.Tabs...)
+	var next RoomTab
+	if dir > 0 {
+		next, tabs = tabs[0], tabs[1:]
+		if m.inRoom() {
+			tabs = append(tabs, m.RoomTab)
+		}
+	} else {
+		next, tabs = tabs[len(tabs)-1], tabs[:len(tabs)-1]
+		if m.inRoom() {
+			tabs = append([]RoomTab{m.RoomTab}, tabs...)
+		}
+	}
+	m.ChatOpen = false
+	m.ChatInput.Blur()
+	m.Tabs = tabs
+	m.RoomTab = next
+	m.State = next.state()
+	m.Err = nil
+	m.syncCleanup()
+	return m
+}
+
+// focusTab brings Tabs[i] on screen. The tabs after it move ahead of
+// the ones before, so Ctrl+N keeps visiting rooms in the same order.
+func (m Model) focusTab(i int) Model {
+	tabs := append([]RoomTab(nil), m.Tabs[i+1:]...)
+	tabs = append(tabs, m.Tabs[:i]...)
+	if m.inRoom() {
+		tabs = append(tabs, m.RoomTab)
+	}
+	next := m.Tabs[i]
+	m.ChatOpen = false
+	m.ChatInput.Blur()
+	m.Tabs = tabs
+	m.RoomTab = next
+	m.State = next.state()
+	m.Err = nil
+	m.syncCleanup()
+	return m
+}
+
+// canInterrupt reports whether the screen may be swapped for another room
+// without asking: not while typing, answering a popup or playing a game
+// that can't be put down, i.e. against another person or Snake.
+func (m Model) canInterrupt() bool {
+	if m.PopupActive || m.ChatOpen {
+		return false
+	}
+	switch m.State {
+	case StateNameInput, StateScreensaver, StateSnakeGame, StateLobbyChat:
+		return false
+	case StateGame:
+		return m.Game.Status != "playing" || m.Game.PlayerO == db.BotID || m.MySide == "Spectator"
+	}
+	return true
+}
+
+// quickBotCmd starts a private tic-tac-toe room against the bot, for a
+// host to play while their own lobby waits in another tab.
+func quickBotCmd(pid, name, level string) tea.Cmd {
+	return storeCmd("Starting a bot game", func() tea.Msg {
+		code, err := createWithFreshCode(func(code string) error {
+			return db.CreateRoom(code, pid, name, false, "tictactoe", level, db.Rules{})
+		})
+		if err != nil {
+			return errMsg(err)
+		}
+		if err := db.AddBot(code, pid); err != nil {
+			return errMsg(err)
+		}
+		return roomCreatedMsg{code: code, gameType: "tictactoe"}
+	})
+}
+
+// dropTab forgets the background tab for code, if there is one, e.g.
+// when the player joins that room again from the menu.
+func (m Model) dropTab(code string) Model {
+	for i, t := range m.Tabs {
+		if t.RoomCode != code {
+			continue
+		}
+		if t.StopRoomEvents != nil {
+			t.StopRoomEvents()
+		}
+		m.Tabs = append(append([]RoomTab(nil), m.Tabs[:i]...), m.Tabs[i+1:]...)
+		m.syncCleanup()
+		break
+	}
+	return m
+}
+
+// syncCleanup records every room the session is in, so a dropped
+// connection leaves all of them and not just the one on screen.
+func (m Model) syncCleanup() {
+	c := m.Cleanup
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	c.RoomCode = leaveCode(m.RoomTab)
+	c.IsHost = m.MySide == "X"
+	c.StopEvents = m.StopRoomEvents
+	c.Tabs = nil
+	for _, t := range m.Tabs {
+		c.Tabs = append(c.Tabs, TabCleanup{RoomCode: leaveCode(t), IsHost: t.MySide == "X", StopEvents: t.StopRoomEvents})
+	}
+}
+
+// leaveCode is the room to leave when the session ends: none for a room
+// only watched from here, whose seat is another session's.
+func leaveCode(t RoomTab) string {
+	if t.WatchOnly {
+		return ""
+	}
+	return t.RoomCode
+}
+
+// renderTabBar lists the player's rooms, the one on screen first, and
+// marks those waiting on the player's move.
+func renderTabBar(m Model) string {
+	if len(m.Tabs) == 0 {
+		if m.inRoom() {
+			return styles.Subtle.Render("Ctrl+T: Open Another Room")
+		}
+		return ""
+	}
+	marker := "●"
+	if m.Settings.ASCII {
+		marker = "*"
+	}
+	var parts []string
+	if m.inRoom() {
+		parts = append(parts, styles.Special.Render("["+m.RoomCode+"]"))
+	} else {
+		parts = append(parts, styles.Special.Render("[Menu]"))
+	}
+	for _, t := range m.Tabs {
+		label := t.RoomCode
+		if t.yourMove() {
+			label += marker
+		}
+		parts = append(parts, styles.Subtle.Render(label))
+	}
+	help := "Ctrl+N/Ctrl+P: Switch"
+	if m.inRoom() {
+		help += " • Ctrl+T: New Tab"
+	}
+	return strings.Join(parts, " ") + styles.Subtle.Render("   "+help)
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// Themes a player can pick in Settings, or for one session with the
+// theme= argument, see ParseArgs.
+const (
+	themeDefault      = "default"
+	themeHighContrast = "high-contrast"
+)
+
+var themes = []string{themeDefault, themeHighContrast}
+
+// theme is the theme the session is drawn in.
+func (m Model) theme() string {
+	if m.ForcedTheme != "" {
+		return m.ForcedTheme
+	}
+	return m.Settings.Theme
+}
+
+// highContrast redraws a frame in black, white and yellow for low-vision
+// players. Screens are styled for the default theme, so rather than keep
+// a second set of styles it rewrites the colors in the finished frame:
+// saturated text turns bright yellow and the rest bright white, and any
+// colored background, which marks a cursor, a selection or a square,
+// becomes white or yellow by its lightness with black text on it. Faint
+// text is drawn at full strength.
+func highContrast(frame string) string {
+	onBg := false // a background is set, so text must stay black
+	return sgr.ReplaceAllStringFunc(frame, func(seq string) string {
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		var out []string
+		for i := 0; i < len(params); i++ {
+			n, err := strconv.Atoi(params[i])
+			switch {
+			case params[i] == "" || (err == nil && n == 0):
+				onBg = false
+				out = append(out, "0")
+			case n == 2: // faint
+			case n == 39 && onBg:
+				out = append(out, "30")
+			case n == 39:
+				out = append(out, "39")
+			case n == 49:
+				onBg = false
+				out = append(out, "49", "39")
+			default:
+				c, bg, used := sgrColor(params[i:])
+				if used == 0 {
+					out = append(out, params[i])
+					continue
+				}
+				i += used - 1
+				if c == nil {
+					continue
+				}
+				rgb := termenv.ConvertToRGB(c)
+				_, sat, light := rgb.Hsl()
+				switch {
+				case bg && light >= 0.6:
+					onBg = true
+					out = append(out, "107", "30")
+				case bg:
+					onBg = true
+					out = append(out, "103", "30")
+				case onBg:
+				case sat > 0.3 && light > 0.15:
+					out = append(out, "93")
+				default:
+					out = append(out, "97")
+				}
+			}
+		}
+		if len(out) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(out, ";") + "m"
+	})
+}
+
+// sgrColor reads the color set by the SGR parameters at the start of
+// params: whether it is a background, and how many parameters it took.
+// used is 0 if params don't start with a color.
+func sgrColor(params []string) (c termenv.Color, bg bool, used int) {
+	n, err := strconv.Atoi(params[0])
+	if err != nil {
+		return nil, false, 0
+	}
+	switch {
+	case n >= 30 && n <= 37:
+		return termenv.ANSIColor(n - 30), false, 1
+	case n >= 40 && n <= 47:
+		return termenv.ANSIColor(n - 40), true, 1
+	case n >= 90 && n <= 97:
+		return termenv.ANSIColor(n - 90 + 8), false, 1
+	case n >= 100 && n <= 107:
+		return termenv.ANSIColor(n - 100 + 8), true, 1
+	case n != 38 && n != 48:
+		return nil, false, 0
+	}
+	bg = n == 48
+	if len(params) >= 3 && params[1] == "5" {
+		i, _ := strconv.Atoi(params[2])
+		return termenv.ANSI256Color(i), bg, 3
+	}
+	if len(params) >= 5 && params[1] == "2" {
+		r, _ := strconv.Atoi(params[2])
+		g, _ := strconv.Atoi(params[3])
+		b, _ := strconv.Atoi(params[4])
+		return termenv.RGBColor(hexColor(r, g, b)), bg, 5
+	}
+	return nil, bg, len(params)
+}
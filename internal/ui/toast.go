@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/styles"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ToastLevel sets how a toast looks and how long it stays up.
+type ToastLevel int
+
+const (
+	ToastInfo ToastLevel = iota
+	ToastSuccess
+	ToastError
+)
+
+// Toast is a brief message shown above the footer on every screen: a
+// player joining a room in another tab, a save that failed, a message
+// from the admins.
+type Toast struct {
+	Level ToastLevel
+	Text  string
+	For   time.Duration // how long it stays up once shown
+	id    int
+}
+
+// ToastQueue shows one toast at a time, each for its For, and queues the
+// rest. Code anywhere in Update pushes to it; Update arms the expiry of
+// the toast shown, see armToast.
+type ToastQueue struct {
+	Shown   Toast // zero when there is none
+	Armed   bool  // Shown's expiry is scheduled
+	Pending []Toast
+	seq     int
+}
+
+// toastQueueMax is how many toasts may wait their turn; older ones are
+// dropped first, as they are the likeliest to be out of date.
+const toastQueueMax = 4
+
+// toastFor is how long a toast of level stays up by default: errors
+// longer, to give time to read them.
+var toastFor = map[ToastLevel]time.Duration{
+	ToastInfo:    4 * time.Second,
+	ToastSuccess: 4 * time.Second,
+	ToastError:   8 * time.Second,
+}
+
+// toastExpiredMsg takes down toast id, if it is still the one shown.
+type toastExpiredMsg struct{ id int }
+
+// push queues text, unless the same text is already up or waiting.
+func (q *ToastQueue) push(level ToastLevel, text string, d time.Duration) {
+	if text == q.Shown.Text {
+		return
+	}
+	for _, t := range q.Pending {
+		if t.Text == text {
+			return
+		}
+	}
+	q.seq++
+	t := Toast{Level: level, Text: text, For: d, id: q.seq}
+	if q.Shown.Text == "" {
+		q.Shown, q.Armed = t, false
+		return
+	}
+	q.Pending = append(q.Pending, t)
+	if len(q.Pending) > toastQueueMax {
+		q.Pending = q.Pending[len(q.Pending)-toastQueueMax:]
+	}
+}
+
+// expire takes down toast id and brings up the next one.
+func (q *ToastQueue) expire(id int) {
+	if q.Shown.id != id {
+		return
+	}
+	q.Shown, q.Armed = Toast{}, false
+	if len(q.Pending) > 0 {
+		q.Shown = q.Pending[0]
+		q.Pending = q.Pending[1:]
+	}
+}
+
+// toast shows text at level for the level's usual time.
+func (m Model) toast(level ToastLevel, text string) Model {
+	return m.toastFor(level, text, toastFor[level])
+}
+
+// toastFor shows text at level for d.
+func (m Model) toastFor(level ToastLevel, text string, d time.Duration) Model {
+	m.Toasts.push(level, text, d)
+	return m
+}
+
+// fail records err as the outcome of the player's last action and shows
+// it as an error toast.
+func (m Model) fail(err error) Model {
+	m.Err = err
+	return m.toast(ToastError, err.Error())
+}
+
+// armToast schedules the expiry of a toast just brought up.
+func (m Model) armToast() (Model, tea.Cmd) {
+	t := m.Toasts.Shown
+	if t.Text == "" || m.Toasts.Armed {
+		return m, nil
+	}
+	m.Toasts.Armed = true
+	return m, tea.Tick(t.For, func(time.Time) tea.Msg { return toastExpiredMsg{t.id} })
+}
+
+// renderToast is the toast shown, with a count of those waiting, or "".
+func renderToast(m Model) string {
+	t := m.Toasts.Shown
+	if t.Text == "" {
+		return ""
+	}
+	style := styles.Special
+	switch t.Level {
+	case ToastSuccess:
+		style = styles.Win
+	case ToastError:
+		style = styles.Err
+	}
+	text := style.Render(t.Text)
+	if n := len(m.Toasts.Pending); n > 0 {
+		text += styles.Subtle.Render(fmt.Sprintf(" (+%d)", n))
+	}
+	return text
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"github.com/aminshahid573/termplay/internal/db"
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The tutorial is a scripted, purely local tic-tac-toe game. Each step
+// shows a callout and waits for the player to do the thing it describes.
+
+const (
+	tutMove = iota
+	tutPlace
+	tutFinish
+	tutLeave
+	tutStay
+	tutDone
+)
+
+var tutorialCallouts = map[int]string{
+	tutMove:   "Use the arrow keys (or h/j/k/l) to move the cursor.\nMove it to the top-left corner.",
+	tutPlace:  "Press Space or Enter to place your X there.",
+	tutFinish: "Your opponent plays O. Get three in a row!\nThe bot isn't very good. Yet.",
+	tutLeave:  "Nice! Press Q or Esc to open the leave popup.\nIn a real game, it asks before you walk out.",
+	tutStay:   "This is the leave popup.\nPress N to stay (Y would take you back to the menu).",
+	tutDone:   "That's everything! Create a room, share the code,\nand play a friend. Press Enter to finish.",
+}
+
+type Tutorial struct {
+	Step       int
+	Board      tictactoe.Board
+	CurR, CurC int
+	Line       []int
+	Popup      bool
+}
+
+func newTutorial() Tutorial {
+	return Tutorial{
+		CurR: 1,
+		CurC: 1,
+	}
+}
+
+func updateTutorial(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	key = m.remapKey(key)
+	t := m.Tutorial
+
+	if t.Popup {
+		switch key.String() {
+		case "y", "enter":
+			// Walked out mid-tutorial; finishing it is optional
+			m.State = StateGameSelect
+			m.MenuIndex = gameSelectTutorial
+			return m, nil
+		case "n", "esc":
+			t.Popup = false
+			if t.Step == tutStay {
+				t.Step = tutDone
+			}
+		}
+		m.Tutorial = t
+		return m, nil
+	}
+
+	switch key.String() {
+	case "q", "esc":
+		t.Popup = true
+		if t.Step == tutLeave {
+			t.Step = tutStay
+		}
+	case "up", "k":
+		if t.CurR > 0 {
+			t.CurR--
+		}
+	case "down", "j":
+		if t.CurR < 2 {
+			t.CurR++
+		}
+	case "left", "h":
+		if t.CurC > 0 {
+			t.CurC--
+		}
+	case "right", "l":
+		if t.CurC < 2 {
+			t.CurC++
+		}
+	case " ", "enter":
+		if t.Step == tutDone {
+			m.State = StateGameSelect
+			m.MenuIndex = 0
+			m = m.toast(ToastSuccess, "Tutorial complete, pick a game to play")
+			return m, tutorialDoneCmd(m.SessionID)
+		}
+		if t.Step == tutPlace || t.Step == tutFinish {
+			t = t.place()
+		}
+	}
+
+	if t.Step == tutMove && t.CurR == 0 && t.CurC == 0 {
+		t.Step = tutPlace
+	}
+	m.Tutorial = t
+	return m, nil
+}
+
+// place puts the player's X under the cursor and lets the bot answer.
+func (t Tutorial) place() Tutorial {
+	idx := t.CurR*3 + t.CurC
+	if t.Board[idx] != tictactoe.Empty {
+		return t
+	}
+	t.Board[idx] = tictactoe.X
+	t.Step = tutFinish
+
+	if t.over() {
+		return t
+	}
+	// A deliberately weak opponent so newcomers get to win
+	if o := tictactoe.RandomMove(t.Board); o >= 0 {
+		t.Board[o] = tictactoe.O
+	}
+	t.over()
+	return t
+}
+
+// over checks for a finished game and advances the script. A lost or
+// drawn game just restarts; the tutorial is about the controls.
+func (t *Tutorial) over() bool {
+	winner, line := tictactoe.CheckWinner(t.Board)
+	switch {
+	case winner == tictactoe.X:
+		t.Line = line
+		t.Step = tutLeave
+		return true
+	case winner == tictactoe.O || tictactoe.CheckDraw(t.Board):
+		t.Board = newTutorial().Board
+		return true
+	}
+	return false
+}
+
+func renderTutorial(m Model) string {
+	t := m.Tutorial
+	callout := styles.Box.Render(styles.Highlight.Render(tutorialCallouts[t.Step]))
+	board := renderTicTacToeBoard(t.Board, t.Line, t.CurR, t.CurC, t.Step < tutLeave, -1, defaultMarks, zoomLarge)
+
+	if t.Popup {
+		board = styles.PopupBox.Render("Are you sure you want to leave?\n\n[Y] Yes    [N] No")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render("HOW TO PLAY"),
+		callout,
+		"",
+		board,
+	)
+}
+
+func tutorialDoneCmd(id string) tea.Cmd {
+	return saveCmd("saving your tutorial progress", func() error { return db.MarkTutorialDone(id) })
+}
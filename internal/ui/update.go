@@ -1,31 +1,65 @@
 package ui
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"strings"
 	"time"
 
+	"github.com/aminshahid573/termplay/internal/bus"
 	"github.com/aminshahid573/termplay/internal/chess"
+	"github.com/aminshahid573/termplay/internal/config"
 	"github.com/aminshahid573/termplay/internal/db"
 	"github.com/aminshahid573/termplay/internal/snake"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
-	"os"
 )
 
-func init() {
-	f, _ := os.OpenFile("debug.log", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-	log.SetOutput(f)
-}
-
 // Messages
 type roomUpdateMsg db.Room
-type roomsFetchedMsg []db.Room
+type roomsFetchedMsg struct {
+	rooms    []db.Room
+	featured string
+}
 type errMsg error
-type pollErrorMsg error
+
+// pollErrorMsg is a failed poll of a room; polling carries on regardless.
+type pollErrorMsg struct {
+	code string
+	err  error
+}
+
+// roomEventMsg carries a room state pushed over the bus.
+type roomEventMsg struct {
+	code string
+	data []byte
+}
+
+type opponentKickedMsg struct{}
+
+// roomResyncedMsg is the answer to a forced refetch after the local room
+// state was found to disagree with the store.
+type roomResyncedMsg struct {
+	code string
+	room *db.Room
+	err  error
+}
+
+// moveFailedMsg reports a move the store refused, usually because the room
+// changed underneath it.
+type moveFailedMsg struct {
+	code  string
+	err   error
+	retry func(Model) tea.Cmd // see actionFailedMsg, nil for no retry
+}
+
+// noticeExpiredMsg clears a brief Notice, unless it was replaced meanwhile.
+type noticeExpiredMsg struct{ code, text string }
 
 type roomCreatedMsg struct {
 	code     string
@@ -35,42 +69,239 @@ type roomJoinedMsg struct {
 	code     string
 	side     string
 	gameType string
+
+	watchOnly bool // see watchOwnGameCmd
+}
+
+// roomLeftMsg is a room the player stepped away from, once the store
+// has it.
+type roomLeftMsg struct {
+	code string
+	err  error
 }
 
+// Update handles msg under the session's Guard. Room states that change
+// nothing on screen are let through without a redraw, see frame.go.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.Guard.checkSize(size.Width, size.Height)
+	}
+	m.Unchanged = false
+	if next, cmd, ok := m.unchangedRoom(msg); ok {
+		return next, m.Guard.track(cmd)
+	}
+	next, cmd := m.dispatch(msg)
+	if nm, ok := next.(Model); ok {
+		var arm tea.Cmd
+		next, arm = nm.armToast()
+		cmd = tea.Batch(cmd, arm)
+	}
+	return next, m.Guard.track(cmd)
+}
+
+func (m Model) dispatch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case demoTickMsg:
+		return updateDemo(m, msg)
+	case idleCheckMsg, saverTickMsg:
+		return updateScreensaver(m, msg)
+	case statusTickMsg:
+		return updateStatusBar(m, msg)
+	case storeCallMsg, storeDoneMsg, storeSlowMsg:
+		return updateStore(m, msg)
+	case spinner.TickMsg:
+		return updateSpinner(m, msg.(spinner.TickMsg))
+	case previewDueMsg, previewLoadedMsg:
+		return updatePreview(m, msg)
+	case openRoomEventMsg, openRoomsPolledMsg:
+		return updateRoomAlertEvents(m, msg)
+	}
+
+	// Updates for rooms in other tabs are handled in place, off screen
+	if code, ok := roomOf(msg); ok && code != m.RoomCode {
+		return m.updateTab(code, msg)
+	}
+
+	wasMyMove := m.awaitingMyMove()
+	m, cmd := m.update(msg)
+
+	// Cross-cutting concerns that must run after every message,
+	// whichever branch of update handled it.
+	var demoCmd tea.Cmd
+	m, demoCmd = updateDemo(m, msg)
+
+	var statusCmd tea.Cmd
+	m, statusCmd = updateStatusBar(m, msg)
+
+	var leanCmd, motionCmd tea.Cmd
+	m, leanCmd = updateBandwidth(m)
+	m, motionCmd = updateMotion(m)
+	m.Live.observe(m)
+
+	var h2hCmd tea.Cmd
+	m, h2hCmd = updateHeadToHead(m, msg)
+
+	var expiryCmd tea.Cmd
+	m, expiryCmd = updateRoomExpiry(m, msg)
+
+	var previewCmd tea.Cmd
+	m, previewCmd = updatePreview(m, msg)
+
+	if m.State != StateRoomAlert {
+		// Joined the room found, or went elsewhere
+		m = m.stopRoomAlert()
+	}
+
+	var botCmd tea.Cmd
+	if m.botShouldMove() {
+		m.BotThinking = true
+		botCmd = botMoveCmd(m.RoomCode)
+	}
+
+	var bell tea.Cmd
+	if m.Settings.BellOnTurn && !wasMyMove && m.awaitingMyMove() {
+		bell = m.bellCmd()
+	}
+	var title tea.Cmd
+	if t := m.windowTitle(); t != m.WindowTitle {
+		m.WindowTitle = t
+		title = tea.SetWindowTitle(t)
+	}
+	return m, tea.Batch(cmd, demoCmd, statusCmd, leanCmd, motionCmd, h2hCmd, expiryCmd, previewCmd, botCmd, bell, title)
+}
+
+// windowTitle is what the terminal title bar should show, so players who
+// switched to another window can tell when it is their move.
+func (m Model) windowTitle() string {
+	switch {
+	case m.State == StateLobby:
+		return "termplay — waiting for opponent…"
+	case m.State == StateRoomAlert && m.RoomAlert.Found != nil:
+		return "termplay — ROOM OPEN"
+	case m.State == StateRoomAlert:
+		return "termplay — watching for rooms…"
+	case m.State != StateGame:
+		return "termplay"
+	case m.MySide == "Spectator":
+		return "termplay — spectating"
+	case m.Game.Status == "finished":
+		return "termplay — game over"
+	case m.awaitingMyMove():
+		return "termplay — YOUR TURN"
+	}
+	return "termplay — waiting…"
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if key, ok := msg.(tea.KeyMsg); ok && key.Paste {
+		if key, ok = cleanPaste(key); !ok {
+			return m, nil
+		}
+		msg = key
+	}
+	m.SkipConfirm = false
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == ctrlEnter {
+		// Enter that places a move without asking to confirm it
+		m.SkipConfirm = true
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	}
+
 	// 1. Handle background polling (Highest Priority, Non-Blocking)
 	if roomMsg, ok := msg.(roomUpdateMsg); ok {
-		m.Game = db.Room(roomMsg)
-		// Auto-transition from Lobby to Game
-		if m.State == StateLobby && m.Game.PlayerO != "" {
-			m.State = StateGame
-		}
-		// Room deleted?
-		if m.Game.PlayerX == "" {
-			m.Err = fmt.Errorf("Room closed by host")
-			m.State = StateMenu
-			m.RoomCode = ""
-			m.Busy = false
+		m, ok, cmd = m.vetRoom(db.Room(roomMsg))
+		var premove tea.Cmd
+		if ok {
+			var open bool
+			if m, open = applyRoom(m, db.Room(roomMsg)); !open {
+				return m, nil
+			}
+			m, premove = m.playPremove()
+		}
+		return m, tea.Batch(cmd, premove, pollCmd(m.RoomCode, m.pollInterval()))
+	}
+
+	// 1b. Handle room states pushed over the bus (the poll keeps running
+	// as a fallback, so a dropped event only costs one poll interval)
+	if ev, ok := msg.(roomEventMsg); ok {
+		if ev.code != m.RoomCode {
 			return m, nil
 		}
-		return m, pollCmd(m.RoomCode)
+		var r db.Room
+		var premove tea.Cmd
+		if err := json.Unmarshal(ev.data, &r); err == nil {
+			var ok bool
+			if m, ok, cmd = m.vetRoom(r); ok {
+				var open bool
+				if m, open = applyRoom(m, r); !open {
+					return m, nil
+				}
+				m, premove = m.playPremove()
+			}
+		}
+		return m, tea.Batch(cmd, premove, waitRoomEventCmd(ev.code, m.RoomEvents))
+	}
+
+	// 1c. Divergence handling: a forced refetch coming back, or a move
+	// the store refused
+	switch msg := msg.(type) {
+	case roomResyncedMsg:
+		return m.finishResync(msg)
+	case moveFailedMsg:
+		// A stale move only means the room moved on; the resync shows how
+		if msg.err != db.ErrStaleMove {
+			m = m.failed("move", msg.err, msg.retry)
+		}
+		m.MovePending = false
+		if m.Resyncing || m.RoomCode == "" {
+			return m, nil
+		}
+		m.Resyncing = true
+		return m, resyncCmd(m.RoomCode)
+	case noticeExpiredMsg:
+		if m.Notice == msg.text {
+			m.Notice = ""
+		}
+		return m, nil
+	case toastExpiredMsg:
+		m.Toasts.expire(msg.id)
+		return m, nil
+	case roomOpenedMsg:
+		m = m.toast(ToastSuccess, fmt.Sprintf("Room %s is on the public list now", msg.code))
+		return m, nil
+	case adminMessageMsg:
+		m = m.toastFor(ToastInfo, "Message from the admins: "+string(msg), 20*time.Second)
+		return m, waitAdminMessageCmd(m.Live)
+	}
+
+	// 1d. Chat arrives the same way, on its own topic
+	switch msg := msg.(type) {
+	case chatEventMsg, chatLoadedMsg:
+		return updateChat(m, msg)
+	case lobbyChatEventMsg, lobbyChatLoadedMsg:
+		return updateLobbyChatEvents(m, msg)
+	case serverStatusMsg, serverStatusTickMsg:
+		return updateServerStatus(m, msg)
+	case maintenanceMsg:
+		return updateMaintenance(m, msg)
 	}
 
 	// 2. Handle Polling Errors
-	if err, ok := msg.(pollErrorMsg); ok {
-		m.Err = err
+	if msg, ok := msg.(pollErrorMsg); ok {
+		m = m.fail(msg.err)
 		// Retry polling after delay
-		return m, pollCmd(m.RoomCode)
+		return m, pollCmd(msg.code, m.pollInterval())
 	}
 
 	// 3. Handle Async DB Results
 	switch msg := msg.(type) {
 	case roomCreatedMsg:
 		m.Busy = false
+		m = m.dropTab(msg.code)
 		m.RoomCode = msg.code
 		m.MySide = "X"
+		m.WatchOnly = false
 
 		m.Cleanup.Mu.Lock()
 		m.Cleanup.RoomCode = msg.code
@@ -86,15 +317,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.State = StateLobby
-		return m, pollCmd(msg.code)
+		m.LobbySince = time.Now()
+		var sub tea.Cmd
+		m, sub = m.subscribeRoom(msg.code)
+		return m, tea.Batch(pollCmd(msg.code, db.Intervals().Idle), sub)
 
 	case roomJoinedMsg:
 		m.Busy = false
+		m = m.dropTab(msg.code)
 		m.RoomCode = msg.code
 		m.MySide = msg.side
+		m.WatchOnly = msg.watchOnly
 
 		m.Cleanup.Mu.Lock()
-		m.Cleanup.RoomCode = msg.code
+		m.Cleanup.RoomCode = leaveCode(m.RoomTab)
 		m.Cleanup.IsHost = (msg.side == "X")
 		m.Cleanup.Mu.Unlock()
 
@@ -114,13 +350,110 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.State = StateGame
-		return m, pollCmd(msg.code)
+		var sub tea.Cmd
+		m, sub = m.subscribeRoom(msg.code)
+		return m, tea.Batch(pollCmd(msg.code, db.Intervals().Active), sub)
+
+	case actionFailedMsg:
+		m = m.failed(msg.what, msg.err, msg.retry)
+		return m, nil
+
+	case roomLeftMsg:
+		if msg.err != nil {
+			log.Error("StepAway failed", "room", msg.code, "err", msg.err)
+		}
+		return m, m.loadMenuRooms()
 
 	case errMsg:
 		m.Busy = false
-		m.Err = msg
+		m.Admin.Loading = false
+		m.Leaderboard.Loading = false
+		m = m.fail(msg)
 		// Stay in current state, allow retry
 		return m, nil
+
+	case correspondenceMsg:
+		m.Correspondence = msg.games
+		return m, nil
+
+	case hostedRoomsMsg:
+		m.Hosted = msg.rooms
+		return m, nil
+
+	case profileLoadedMsg:
+		m.Settings = msg.Settings
+		m.TutorialDone = msg.TutorialDone
+		m.PuzzlesDone = msg.Puzzles
+		m.Badges = msg.Badges
+		m.ReservedName = msg.ReservedName
+		m.Charm.ID = msg.Charm
+		m.ChatMuted = msg.ChatMuted
+		m.Stalls = msg.Stalls
+		if m.Stalls.Lockout() != nil {
+			m.Rules.Ranked = false
+		}
+		if msg.Warning != "" && !m.PopupActive {
+			m.Warning = msg.Warning
+			m.PopupActive = true
+			m.PopupType = PopupWarning
+		}
+		if msg.Name != "" && m.State == StateNameInput && m.TextInput.Value() == "" {
+			m.TextInput.SetValue(msg.Name)
+			m.TextInput.CursorEnd()
+		}
+		return m, nil
+
+	case settingsSavedMsg:
+		return m, nil
+
+	case motdLoadedMsg:
+		m.MOTD = string(msg)
+		return m, nil
+
+	case nameReservedMsg:
+		m.ReservedName = msg.name
+		return m, nil
+
+	case linkCodeMsg:
+		m.Link.Code = msg.code
+		m.Link.Expires = time.Now().Add(db.LinkCodeTTL)
+		m.Link.Status = ""
+		return m, nil
+
+	case notifyTestedMsg:
+		m.Notify.Status = "Test alert sent: check it arrived"
+		if msg.err != nil {
+			m.Notify.Status = "Test alert failed: " + msg.err.Error()
+		}
+		return m, nil
+
+	case keyLinkedMsg:
+		m = m.switchAccount(msg.account)
+		m.Link.Status = "Linked: this key now shares your stats and settings"
+		if msg.unlink {
+			m.Link.Status = "Unlinked: this key plays on its own again"
+		}
+		return m, loadProfileCmd(m.SessionID)
+
+	case charmLinkedMsg:
+		m = m.switchAccount(msg.account)
+		m.Charm.ID = msg.id
+		m.Link.Status = "Signed in with Charm: this key plays as your Charm profile"
+		return m, loadProfileCmd(m.SessionID)
+
+	case opponentKickedMsg:
+		// Back to waiting for someone new
+		m.State = StateLobby
+		m.LobbySince = time.Now()
+		return m, nil
+
+	case botMovedMsg:
+		m.BotThinking = false
+		// A stale bot move is simply retried against the next state
+		if msg.err != nil && msg.err != db.ErrStaleMove {
+			m = m.fail(msg.err)
+		}
+		return m, nil
 	}
 
 	switch msg := msg.(type) {
@@ -133,6 +466,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+		switch msg.String() {
+		case "ctrl+z":
+			return m.suspend()
+		case "ctrl+l":
+			return m, tea.ClearScreen
+		case allKeys:
+			m.AllKeys = !m.AllKeys
+			return m, nil
+		}
+		m.LastInput = time.Now()
+		var used bool
+		if m, used = m.updateTabKeys(msg); used {
+			return m, nil
+		}
+		if m, cmd, used = m.retryFailed(msg); used {
+			return m, cmd
+		}
+	case tea.ResumeMsg:
+		return m, tea.ClearScreen
 	}
 
 	// Handle snake game ticks and input
@@ -164,7 +516,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.PopupActive {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			if m.PopupType == PopupRestart {
+			if m.PopupType == PopupDuplicate {
+				return updateDuplicate(m, msg)
+			} else if m.PopupType == PopupOpponentLeft {
+				return updateOpponentLeft(m, msg)
+			} else if m.PopupType == PopupWarning {
+				if msg.String() == "enter" || msg.String() == "y" {
+					m.PopupActive = false
+					m.Warning = ""
+					return m, clearWarningCmd(m.SessionID)
+				}
+				return m, nil
+			} else if m.PopupType == PopupReport {
+				switch msg.String() {
+				case "y", "enter":
+					m.PopupActive = false
+					return m, m.reportCmd("report", "Reported by opponent")
+				case "n", "esc":
+					m.PopupActive = false
+				}
+			} else if m.PopupType == PopupKick {
+				switch msg.String() {
+				case "y", "enter":
+					m.PopupActive = false
+					return m, kickCmd(m.RoomCode, m.SessionID)
+				case "n", "esc":
+					m.PopupActive = false
+				}
+			} else if m.PopupType == PopupRestart {
 				switch msg.String() {
 				case "1":
 					// Random
@@ -180,10 +559,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 					m.PopupActive = false
-					return m, func() tea.Msg {
-						db.RestartGame(m.RoomCode, next)
-						return nil
-					}
+					return m, restartCmd(m.RoomCode, next)
 				case "2":
 					// Winner
 					next := m.Game.Winner
@@ -211,10 +587,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 					m.PopupActive = false
-					return m, func() tea.Msg {
-						db.RestartGame(m.RoomCode, next)
-						return nil
-					}
+					return m, restartCmd(m.RoomCode, next)
 				case "esc":
 					m.PopupActive = false
 				}
@@ -222,16 +595,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Leave Popup
 				switch msg.String() {
 				case "y", "enter":
-					// Confirm Leave
-					isHost := (m.MySide == "X")
-					if m.RoomCode != "" {
-						db.LeaveRoom(m.RoomCode, m.SessionID, isHost)
-					}
 					m.PopupActive = false
-					m.State = StateMenu
-					m.Err = nil
-					m.RoomCode = "" // Clear room code on exit
-					return m, nil
+					return m.leaveRoom()
 				case "n", "esc":
 					m.PopupActive = false
 				}
@@ -258,6 +623,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m, cmd = updateGame(m, msg)
 	case StateSnakeGame:
 		// Handled above before popup handler
+	case StateScreensaver:
+		m, cmd = updateScreensaver(m, msg)
+	case StateSettings:
+		m, cmd = updateSettings(m, msg)
+	case StateTutorial:
+		m, cmd = updateTutorial(m, msg)
+	case StatePuzzle:
+		m, cmd = updatePuzzle(m, msg)
+	case StateAdmin:
+		m, cmd = updateAdmin(m, msg)
+	case StateLeaderboard:
+		m, cmd = updateLeaderboard(m, msg)
+	case StateLobbyChat:
+		m, cmd = updateLobbyChat(m, msg)
+	case StateServerStatus:
+		m, cmd = updateServerStatus(m, msg)
+	case StateMaintenance:
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "q" {
+			return m, tea.Quit
+		}
+	case StateRoomAlert:
+		m, cmd = updateRoomAlert(m, msg)
 	}
 
 	return m, cmd
@@ -270,19 +657,43 @@ func updateName(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyEnter {
 			val := strings.TrimSpace(m.TextInput.Value())
-			if len(val) > 0 {
-				m.MyName = val
-				m.State = StateGameSelect // Transition to Game Select
-				m.MenuIndex = 0           // Reset index
-				return m, nil
+			if len(val) > 0 && !m.Busy {
+				var spin tea.Cmd
+				m, spin = m.startLoading(loadingName)
+				return m, tea.Batch(claimNameCmd(m.SessionID, val), spin)
 			}
 		}
+	case nameClaimedMsg:
+		m.Busy = false
+		m.MyName = msg.name
+		m.State = StateGameSelect // Transition to Game Select
+		m.MenuIndex = 0           // Reset index
+		if !m.TutorialDone {
+			m.MenuIndex = gameSelectTutorial // Point newcomers at How to Play
+		}
+		if msg.taken != "" {
+			m = m.toast(ToastInfo, fmt.Sprintf("%s is a reserved name: you play as %s", msg.taken, msg.name))
+		}
+		return m, saveNameCmd(m.SessionID, msg.name)
 	}
 	m.TextInput, cmd = m.TextInput.Update(msg)
 	return m, cmd
 }
 
 // --- 1.5 Game Selection Logic ---
+
+// Entries on the game select screen, in display order
+const (
+	gameSelectTicTacToe = iota
+	gameSelectChess
+	gameSelectSnake
+	gameSelectPuzzles
+	gameSelectTutorial
+	gameSelectLeaderboard
+	gameSelectStatus
+	gameSelectSettings
+)
+
 func updateGameSelect(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -292,34 +703,67 @@ func updateGameSelect(m Model, msg tea.Msg) (Model, tea.Cmd) {
 				m.MenuIndex--
 			}
 		case "down", "j":
-			if m.MenuIndex < 2 { // 0: TicTacToe, 1: Chess, 2: Snake
+			if m.MenuIndex < gameSelectSettings {
 				m.MenuIndex++
 			}
 		case "enter":
 			switch m.MenuIndex {
-			case 0:
+			case gameSelectTicTacToe:
 				m.SelectedGame = "tictactoe"
 				m.State = StateMenu
 				m.MenuIndex = 0
-			case 1:
+			case gameSelectChess:
 				m.SelectedGame = "chess"
 				m.State = StateMenu
 				m.MenuIndex = 0
-			case 2:
+			case gameSelectSnake:
 				// Snake is single-player — go directly to snake game
 				m.Snake = snake.InitialModel()
+				m.Snake.ReduceMotion = m.reduceMotion()
 				m.Snake.TermW = m.Width
 				m.Snake.TermH = m.Height
 				m.State = StateSnakeGame
 				return m, snake.TickCmd()
+			case gameSelectPuzzles:
+				m.Puzzle = newPuzzleState(m.PuzzlesDone)
+				m.PuzzlesDone = m.Puzzle.Solved
+				m.State = StatePuzzle
+			case gameSelectTutorial:
+				m.Tutorial = newTutorial()
+				m.State = StateTutorial
+			case gameSelectLeaderboard:
+				m.State = StateLeaderboard
+				m.Leaderboard = LeaderboardState{Loading: true}
+				return m, leaderboardPageCmd("")
+			case gameSelectStatus:
+				return m.openServerStatus()
+			case gameSelectSettings:
+				m.State = StateSettings
+				m.MenuIndex = 0
 			}
 			return m, nil
+		case "A":
+			if config.AdminKeys[m.SessionID] {
+				m.State = StateAdmin
+				m.Admin = AdminState{Loading: true}
+				return m, fetchReportsCmd()
+			}
 		}
 	}
 	return m, nil
 }
 
 // --- 2. Main Menu Logic ---
+// menuItems are the main menu entries, in order. Lobby Chat is only
+// there when the server has it turned on.
+func menuItems() []string {
+	items := []string{"Create Room", "Join with Code", "Public Rooms", "Watch a Game"}
+	if config.LobbyChat {
+		items = append(items, "Lobby Chat")
+	}
+	return append(items, "Quit")
+}
+
 func updateMenu(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -329,24 +773,40 @@ func updateMenu(m Model, msg tea.Msg) (Model, tea.Cmd) {
 				m.MenuIndex--
 			}
 		case "down", "j":
-			if m.MenuIndex < 3 {
+			if m.MenuIndex < len(menuItems())-1 {
 				m.MenuIndex++
 			}
+		case "left", "right", "h", "l":
+			if m.MenuIndex == 3 {
+				m.WatchBest = !m.WatchBest
+			}
+		case "c":
+			return m.resumeCorrespondence()
+		case "r":
+			return m.rejoinRoom()
 		case "enter":
 			if m.MenuIndex == 0 { // Create Room
 				m.State = StateCreateConfig
 				m.IsPublicCreate = false // default to private
 			} else if m.MenuIndex == 1 { // Join via Code
 				m.State = StateInputCode
-				m.TextInput.Placeholder = "4-Digit Code"
+				m.TextInput.Placeholder = "4-Character Code"
 				m.TextInput.SetValue("")
+				m.CodeSubmitted = false
 				m.TextInput.Focus()
 				return m, textinput.Blink
 			} else if m.MenuIndex == 2 { // Public Rooms List
-				m.State = StatePublicList
-				m.SearchInput.Focus()
-				m.ListSelectedRow = 0 // Reset selection to top
-				return m, fetchPublicRoomsCmd()
+				return m.openPublicList()
+			} else if m.MenuIndex == 3 { // Watch a Game
+				if m.Busy {
+					return m, nil
+				}
+				var spin tea.Cmd
+				m, spin = m.startLoading(loadingWatch)
+				return m, tea.Batch(watchGameCmd(m.SessionID, m.MyName, m.WatchBest), spin)
+			} else if menuItems()[m.MenuIndex] == "Lobby Chat" {
+				m.Err = nil
+				return m.openLobbyChat()
 			} else { // Quit
 				return m, tea.Quit
 			}
@@ -362,18 +822,56 @@ func updateCreateConfig(m Model, msg tea.Msg) (Model, tea.Cmd) {
 		switch msg.String() {
 		case "up", "down", "k", "j":
 			m.IsPublicCreate = !m.IsPublicCreate
+		case "left", "h":
+			if m.SelectedGame != "chess" {
+				m.BotLevel = cycleBotLevel(m.BotLevel, -1)
+			}
+		case "right", "l":
+			if m.SelectedGame != "chess" {
+				m.BotLevel = cycleBotLevel(m.BotLevel, 1)
+			}
+		case "tab":
+			if m.SelectedGame != "chess" && !m.Rules.Ranked {
+				m.Rules.Handicap = cycleHandicap(m.Rules.Handicap)
+			}
+		case "r":
+			if err := m.Stalls.Lockout(); err != nil && !m.Rules.Ranked {
+				m = m.fail(err)
+				return m, nil
+			}
+			// Ranked games are played straight, so no handicap
+			m.Rules.Ranked = !m.Rules.Ranked
+			if m.Rules.Ranked {
+				m.Rules.Handicap = db.HandicapNone
+			}
+		case "s":
+			if m.SelectedGame != "chess" {
+				m.Rules.PieRule = !m.Rules.PieRule
+			}
+		case "c":
+			// Only a player with a key can come back to their seat
+			if db.IsGuest(m.SessionID) {
+				m = m.fail(fmt.Errorf("connect with an SSH key to play correspondence games"))
+				return m, nil
+			}
+			m.Rules.Correspondence = !m.Rules.Correspondence
+			m.Err = nil
 		case "enter":
 			if m.Busy {
 				return m, nil
 			}
-			m.Busy = true
-			code := generateCode()
+			var spin tea.Cmd
+			m, spin = m.startLoading(loadingCreate)
 			// Use SelectedGame
 			gameType := m.SelectedGame
 			if gameType == "" {
 				gameType = "tictactoe"
 			} // Fallback
-			return m, createRoomCmd(code, m.SessionID, m.MyName, m.IsPublicCreate, gameType)
+			botLevel := m.BotLevel
+			if m.Rules.Correspondence {
+				botLevel = ""
+			}
+			return m, tea.Batch(createRoomCmd(m.SessionID, m.MyName, m.IsPublicCreate, gameType, botLevel, m.Rules), spin)
 		case "esc":
 			m.State = StateMenu
 		}
@@ -390,10 +888,17 @@ func updateCodeInput(m Model, msg tea.Msg) (Model, tea.Cmd) {
 			if m.Busy {
 				return m, nil
 			}
-			m.Busy = true
-			code := strings.ToUpper(m.TextInput.Value())
-			return m, joinRoomCmd(code, m.SessionID, m.MyName)
+			// Caught here, a typo never costs a round-trip
+			code := normalizeCode(m.TextInput.Value())
+			m.CodeSubmitted = true
+			if checkCode(code, true) != nil {
+				return m, nil
+			}
+			var spin tea.Cmd
+			m, spin = m.startLoading(loadingJoin)
+			return m, tea.Batch(joinRoomCmd(code, m.SessionID, m.MyName), spin)
 		}
+		m.CodeSubmitted = false
 		if msg.Type == tea.KeyEsc {
 			m.State = StateMenu
 			m.Err = nil
@@ -407,43 +912,75 @@ func updateCodeInput(m Model, msg tea.Msg) (Model, tea.Cmd) {
 func updatePublicList(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
-	getSortedList := func() []db.Room {
-		var open, full []db.Room
-		filter := strings.ToUpper(m.SearchInput.Value())
-
-		for _, r := range m.PublicRooms {
-			// Show all if filter empty, otherwise match
-			if filter == "" || strings.Contains(r.Code, filter) || strings.Contains(strings.ToUpper(r.PlayerXName), filter) {
-				if r.PlayerO == "" {
-					open = append(open, r)
-				} else {
-					full = append(full, r)
-				}
-			}
-		}
-		return append(open, full...)
-	}
+	getSortedList := func() []db.Room { return m.listedRooms() }
 
 	switch msg := msg.(type) {
 	case roomsFetchedMsg:
-		m.PublicRooms = []db.Room(msg)
+		m.Busy = false
+		m.PublicRooms = msg.rooms
+		m.Featured = msg.featured
 		if m.Err != nil {
 			m.Err = nil
 		}
 
 	case tea.KeyMsg:
+		if m, ok := updateListFilter(m, msg); ok {
+			return m, nil
+		}
+		if m.SearchInput.Focused() {
+			// Typing goes to the search until Esc, Enter or ↓ hands the
+			// keys back to the list
+			switch msg.String() {
+			case "esc", "enter", "down", "tab":
+				m.SearchInput.Blur()
+				m.ListSelectedRow = 0
+				return m, nil
+			}
+			m.SearchInput, cmd = m.SearchInput.Update(msg)
+			m.ListSelectedRow = 0
+			return m, cmd
+		}
+		n := len(getSortedList())
 		switch msg.String() {
 		case "esc":
+			if m.SearchInput.Value() != "" {
+				// First Esc clears the search, the second leaves
+				m.SearchInput.SetValue("")
+				m.ListSelectedRow = 0
+				return m, nil
+			}
 			m.State = StateMenu
-		case "up", "shift+tab":
+		case "/":
+			return m, m.SearchInput.Focus()
+		case "w":
+			return m.startRoomAlert()
+		case "ctrl+f":
+			// Admins pin or unpin the selected game
+			list := getSortedList()
+			if !config.AdminKeys[m.SessionID] || m.ListSelectedRow >= len(list) {
+				return m, nil
+			}
+			code := list[m.ListSelectedRow].Code
+			if code == m.Featured {
+				code = ""
+			}
+			return m, featureCmd(m.SessionID, code)
+		case "up", "k", "shift+tab":
 			if m.ListSelectedRow > 0 {
 				m.ListSelectedRow--
 			}
-		case "down", "tab":
-			list := getSortedList()
-			if m.ListSelectedRow < len(list)-1 {
+		case "down", "j", "tab":
+			if m.ListSelectedRow < n-1 {
 				m.ListSelectedRow++
 			}
+		case "home", "g":
+			m.ListSelectedRow = 0
+		case "end", "G":
+			m.ListSelectedRow = max(0, n-1)
+		case "pgup", "ctrl+u":
+			m.ListSelectedRow = max(0, m.ListSelectedRow-listPage)
+		case "pgdown", "ctrl+d":
+			m.ListSelectedRow = max(0, min(n-1, m.ListSelectedRow+listPage))
 		case "enter":
 			list := getSortedList()
 			if len(list) > 0 && m.ListSelectedRow < len(list) {
@@ -451,24 +988,83 @@ func updatePublicList(m Model, msg tea.Msg) (Model, tea.Cmd) {
 					return m, nil
 				}
 				sel := list[m.ListSelectedRow]
-				m.Busy = true
-				return m, joinRoomCmd(sel.Code, m.SessionID, m.MyName)
+				if !sel.HasRoom(sel.PlayerO != "") {
+					m = m.fail(db.ErrRoomFull)
+					return m, nil
+				}
+				var spin tea.Cmd
+				m, spin = m.startLoading(loadingJoin)
+				return m, tea.Batch(joinRoomCmd(sel.Code, m.SessionID, m.MyName), spin)
 			}
 		}
+		return m, nil
 	}
 	m.SearchInput, cmd = m.SearchInput.Update(msg)
 	return m, cmd
 }
 
+// listPage is how far PgUp/PgDn move the selection in the public list.
+const listPage = 10
+
+// openPublicList shows the public rooms with the list, not the search,
+// taking the keys; / moves them to the search.
+func (m Model) openPublicList() (Model, tea.Cmd) {
+	m.State = StatePublicList
+	m.SearchInput.Blur()
+	m.ListSelectedRow = 0 // Reset selection to top
+	if m.Busy {
+		return m, nil
+	}
+	var spin tea.Cmd
+	m, spin = m.startLoading(loadingRooms)
+	return m, tea.Batch(fetchPublicRoomsCmd(), spin)
+}
+
 func updateGame(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.ChatOpen {
+			return updateChat(m, msg)
+		}
+		msg = m.remapKey(msg)
+		if msg.String() == "t" && m.State == StateGame {
+			m.ChatOpen = true
+			m.ChatNotice = ""
+			return m, m.ChatInput.Focus()
+		}
+		if msg.String() == "!" && m.opponentID() != "" {
+			m.PopupActive = true
+			m.PopupType = PopupReport
+			return m, nil
+		}
+		if msg.String() == "m" && m.opponentID() != "" {
+			m.Muted[m.opponentID()] = !m.Muted[m.opponentID()]
+			return m.scrollChat(0), nil
+		}
+		if m, ok := m.scrollChatKey(msg); ok {
+			return m, nil
+		}
+		if m.State == StateGame {
+			var cmd tea.Cmd
+			var ok bool
+			if m, cmd, ok = updateZoom(m, msg); ok {
+				return m, cmd
+			}
+		}
 		if msg.String() == "q" {
 			m.PopupActive = true
 			m.PopupType = PopupLeave
 			return m, nil
 		}
 		if msg.String() == "esc" {
+			if m.MovePending {
+				m.MovePending = false
+				return m, nil
+			}
+			if m.Premoved {
+				m.Premoved = false
+				return m, nil
+			}
 			if m.Game.GameType == "chess" && m.ChessSelected {
 				m.ChessSelected = false
 				m.ChessValidMoves = make(map[chess.Pos]bool)
@@ -478,11 +1074,39 @@ func updateGame(m Model, msg tea.Msg) (Model, tea.Cmd) {
 			m.PopupType = PopupLeave
 			return m, nil
 		}
+		if msg.String() == "x" && m.canKick() {
+			m.PopupActive = true
+			m.PopupType = PopupKick
+			return m, nil
+		}
+		if msg.String() == "b" && m.canOfferBot() {
+			return m, addBotCmd(m.RoomCode, m.SessionID)
+		}
+		// While the lobby waits: browse public rooms or play the bot in
+		// another tab, coming back here when someone joins
+		if m.State == StateLobby && (msg.String() == "p" || msg.String() == "v") {
+			var ok bool
+			if m, ok = m.parkRoom(); !ok {
+				return m, nil
+			}
+			if msg.String() == "p" {
+				return m.openPublicList()
+			}
+			var spin tea.Cmd
+			m, spin = m.startLoading(loadingBotGame)
+			m.State = StateMenu
+			m.SelectedGame = "tictactoe"
+			return m, tea.Batch(quickBotCmd(m.SessionID, m.MyName, m.BotLevel), spin)
+		}
 		if m.Game.Status == "finished" {
 			if msg.String() == "r" {
 				if m.MySide == "Spectator" {
 					return m, nil
 				}
+				if m.Game.Forfeit != "" {
+					m.Notice = "Your opponent left the game, there's no one to play again"
+					return m, clearNoticeCmd(m.RoomCode, m.Notice, 3*time.Second)
+				}
 				m.PopupActive = true
 				m.PopupType = PopupRestart
 				return m, nil
@@ -492,12 +1116,19 @@ func updateGame(m Model, msg tea.Msg) (Model, tea.Cmd) {
 		if m.Game.Status == "waiting" {
 			return m, nil
 		}
+		if msg.String() == "s" && m.canSwap() {
+			m.MovePending = false
+			return m, swapSidesCmd(m.RoomCode, m.SessionID, m.Game)
+		}
 
 		if m.Game.GameType == "chess" {
 			// Handle Chess Input
 			return updateChessInput(m, msg)
 		} else {
 			// Handle TicTacToe Input
+			if jumped, ok := m.jumpCursor(msg.String(), 3, false); ok {
+				return jumped, nil
+			}
 			switch msg.String() {
 			case "up", "k":
 				if m.CursorR > 0 {
@@ -519,12 +1150,21 @@ func updateGame(m Model, msg tea.Msg) (Model, tea.Cmd) {
 				if m.MySide == "Spectator" {
 					return m, nil
 				}
+				if m.canPremove() {
+					return m.stagePremove(), nil
+				}
 				idx := m.CursorR*3 + m.CursorC
-				if m.Game.Turn == m.MySide && m.Game.Board[idx] == " " {
-					return m, func() tea.Msg {
-						db.UpdateMove(m.RoomCode, m.SessionID, idx, m.Game)
-						return nil
+				if m.Game.Turn == m.MySide && m.Game.Board[idx] == tictactoe.Empty {
+					if m.Game.HandicapBlocks(idx) {
+						m.Notice = db.ErrHandicap.Error()
+						return m, clearNoticeCmd(m.RoomCode, m.Notice, 3*time.Second)
+					}
+					var ok bool
+					if m, ok = m.confirmMove(); !ok {
+						return m, nil
 					}
+					m.Err, m.Retry = nil, nil
+					return m, placeMarkCmd(m.RoomCode, m.SessionID, idx, m.Game)
 				}
 			}
 		}
@@ -556,6 +1196,9 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	}
 
 	isFlipped := (m.MySide == "O")
+	if jumped, ok := m.jumpCursor(msg.String(), 8, isFlipped); ok {
+		return jumped, nil
+	}
 
 	switch msg.String() {
 	case "up", "k":
@@ -613,6 +1256,7 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 		if m.ChessSelected {
 			// If clicking same piece -> deselect
 			if m.CursorR == m.ChessSelRow && m.CursorC == m.ChessSelCol {
+				m.MovePending = false
 				m.ChessSelected = false
 				m.ChessValidMoves = make(map[chess.Pos]bool)
 				return m, nil
@@ -620,22 +1264,21 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 
 			// If valid move
 			if m.ChessValidMoves[chess.Pos{Row: m.CursorR, Col: m.CursorC}] {
+				var ok bool
+				if m, ok = m.confirmMove(); !ok {
+					return m, nil
+				}
 				log.Info("Executing move", "from", m.ChessSelRow, m.ChessSelCol, "to", m.CursorR, m.CursorC)
-				// Execute Move
-				newState := chess.ApplyMove(m.Game.ChessState, chess.Pos{Row: m.ChessSelRow, Col: m.ChessSelCol}, chess.Pos{Row: m.CursorR, Col: m.CursorC}, "Q")
+				// Execute Move: the server plays it out and decides the result
+				from := chess.Pos{Row: m.ChessSelRow, Col: m.ChessSelCol}
+				to := chess.Pos{Row: m.CursorR, Col: m.CursorC}
 
 				// Clear selection
 				m.ChessSelected = false
 				m.ChessValidMoves = make(map[chess.Pos]bool)
 
-				return m, func() tea.Msg {
-					err := db.UpdateChessState(m.RoomCode, newState)
-					if err != nil {
-						log.Error("UpdateChessState failed", "err", err)
-						return errMsg(fmt.Errorf("move failed: %v", err))
-					}
-					return nil
-				}
+				m.Err, m.Retry = nil, nil
+				return m, chessMoveCmd(m.RoomCode, m.SessionID, from, to, m.Game)
 			} else {
 				log.Info("Invalid move attempted", "target", m.CursorR, m.CursorC)
 			}
@@ -648,6 +1291,7 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 				myColorWhite := (m.MySide == "X")
 				if isWhite == myColorWhite {
 					log.Info("Switching selection", "to", m.CursorR, m.CursorC)
+					m.MovePending = false
 					// Select this one
 					m.ChessSelected = true
 					m.ChessSelRow = m.CursorR
@@ -660,6 +1304,7 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 			}
 
 			// Clicked empty/invalid -> deselect
+			m.MovePending = false
 			m.ChessSelected = false
 			m.ChessValidMoves = make(map[chess.Pos]bool)
 
@@ -688,17 +1333,266 @@ func updateChessInput(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	return m, nil
 }
 
-func pollCmd(code string) tea.Cmd {
-	return tea.Tick(time.Millisecond*500, func(t time.Time) tea.Msg {
+// applyRoom stores a fresh room state from either the poll or the bus.
+// It returns false when the room is gone and the player was sent back to
+// the menu.
+func applyRoom(m Model, r db.Room) (Model, bool) {
+	prev := m.Game
+	if r.Turn != m.Game.Turn || len(r.Moves) != len(m.Game.Moves) || r.Status != m.Game.Status {
+		m.TurnSince = time.Now()
+		m.Nudged = false
+	}
+	m.Game = r
+	m.LastSync = time.Now()
+	// A pending move only makes sense while it is still ours to play
+	if m.Game.Status != "playing" || !m.isMyTurn() {
+		m.MovePending = false
+	}
+	// Seats traded under the pie rule? The host's seat goes with X.
+	if m.MySide == "X" && m.Game.PlayerO == m.SessionID {
+		m.MySide = "O"
+		m.Notice = "Your opponent took your opening. You play O now."
+		m.Cleanup.Mu.Lock()
+		m.Cleanup.IsHost = false
+		m.Cleanup.Mu.Unlock()
+	} else if m.MySide == "O" && m.Game.PlayerX == m.SessionID && m.Game.PlayerO != "" {
+		m.MySide = "X"
+		m.Notice = "You took the opening. You play X and host the room now."
+		m.Cleanup.Mu.Lock()
+		m.Cleanup.IsHost = true
+		m.Cleanup.Mu.Unlock()
+	}
+	// Promoted to host after the previous host left?
+	if m.MySide == "O" && m.Game.PlayerX == m.SessionID {
+		m.MySide = "X"
+		m.State = StateLobby
+		m.LobbySince = time.Now()
+		m.Notice = "The host left. You're the host now."
+		m.ChessSelected = false
+		m.ChessValidMoves = make(map[chess.Pos]bool)
+		if m.Game.GameType == "chess" {
+			m.CursorR, m.CursorC = 7, 4
+		}
+
+		m.Cleanup.Mu.Lock()
+		m.Cleanup.IsHost = true
+		m.Cleanup.Mu.Unlock()
+	}
+	m = m.noticeOpponentLeft(prev)
+	// Auto-transition from Lobby to Game
+	if m.State == StateLobby && m.Game.PlayerO != "" {
+		m.State = StateGame
+		m.Notice = ""
+	}
+	// Room deleted?
+	if m.Game.PlayerX == "" {
+		m = m.fail(fmt.Errorf("Room closed by host"))
+		m.State = StateMenu
+		m.RoomCode = ""
+		m.Busy = false
+		m = m.unsubscribeRoom()
+		return m, false
+	}
+	// Kicked by the host?
+	if m.MySide == "O" && m.Game.PlayerO != m.SessionID {
+		m = m.fail(fmt.Errorf("You were removed from the room by the host"))
+		m.State = StateMenu
+		m.RoomCode = ""
+		m.Busy = false
+		m = m.unsubscribeRoom()
+
+		m.Cleanup.Mu.Lock()
+		m.Cleanup.RoomCode = ""
+		m.Cleanup.Mu.Unlock()
+		return m, false
+	}
+	return m, true
+}
+
+// leaveRoom leaves the room on screen for the menu.
+func (m Model) leaveRoom() (Model, tea.Cmd) {
+	isHost := (m.MySide == "X")
+	code := leaveCode(m.RoomTab)
+	m.State = StateMenu
+	m.Err = nil
+	m.RoomCode = "" // Clear room code on exit
+	m = m.unsubscribeRoom()
+	// Carry on in the next open room, if any
+	m = m.switchTab(1)
+	if code == "" {
+		return m, m.loadMenuRooms()
+	}
+	// The menu's rooms are loaded once the store has the room left
+	return m, stepAwayCmd(code, m.SessionID, isHost)
+}
+
+// loadMenuRooms loads the rooms the main menu offers a way back to.
+func (m Model) loadMenuRooms() tea.Cmd {
+	return tea.Batch(loadCorrespondenceCmd(m.SessionID), loadHostedRoomsCmd(m.SessionID))
+}
+
+// stepAwayCmd leaves room code, or keeps the seat in a correspondence
+// game, see db.StepAway.
+func stepAwayCmd(code, pid string, isHost bool) tea.Cmd {
+	return storeCmd("Leaving the room", func() tea.Msg {
+		return roomLeftMsg{code: code, err: db.StepAway(code, pid, isHost)}
+	})
+}
+
+// vetRoom decides whether a received room state may replace the one on
+// screen. States older than the one shown are dropped; states that fail
+// db.Room.Verify are dropped too and trigger a refetch from the store, so
+// an impossible board is never drawn.
+func (m Model) vetRoom(r db.Room) (Model, bool, tea.Cmd) {
+	if r.PlayerX == "" {
+		// Room gone; nothing to check
+		return m, true, nil
+	}
+	if r.Code == m.Game.Code && r.Seq < m.Game.Seq {
+		return m, false, nil
+	}
+	if err := r.Verify(); err != nil {
+		log.Warn("Rejected room state", "code", r.Code, "seq", r.Seq, "err", err)
+		logged := logRoomEventCmd(r.Code, db.RoomEvent{Kind: "reject", Player: m.SessionID, Detail: "received state: " + err.Error(), Seq: r.Seq})
+		if m.Resyncing || m.RoomCode == "" {
+			return m, false, logged
+		}
+		m.Resyncing = true
+		return m, false, tea.Batch(logged, resyncCmd(m.RoomCode))
+	}
+	return m, true, nil
+}
+
+// finishResync applies the room as the store has it. The store is the
+// source of truth, so its state is taken even if it is older than ours;
+// only a state that still fails verification is held back until the
+// next update.
+func (m Model) finishResync(msg roomResyncedMsg) (Model, tea.Cmd) {
+	m.Resyncing = false
+	if msg.code != m.RoomCode {
+		return m, nil
+	}
+	if msg.err != nil {
+		if msg.err.Error() == "room does not exist" {
+			m, _ = applyRoom(m, db.Room{})
+			return m, nil
+		}
+		m = m.fail(msg.err)
+		return m, nil
+	}
+	if err := msg.room.Verify(); err != nil {
+		log.Error("Room state still inconsistent after resync", "code", msg.code, "err", err)
+		return m, nil
+	}
+	var open bool
+	if m, open = applyRoom(m, *msg.room); !open {
+		return m, nil
+	}
+	m.ChessSelected = false
+	m.ChessValidMoves = make(map[chess.Pos]bool)
+	m.Notice = "State resynced"
+	m, premove := m.playPremove()
+	return m, tea.Batch(premove, clearNoticeCmd(m.RoomCode, "State resynced", 3*time.Second))
+}
+
+func resyncCmd(code string) tea.Cmd {
+	return func() tea.Msg {
+		r, err := db.GetRoom(code)
+		return roomResyncedMsg{code: code, room: r, err: err}
+	}
+}
+
+func clearNoticeCmd(code, text string, after time.Duration) tea.Cmd {
+	return tea.Tick(after, func(time.Time) tea.Msg {
+		return noticeExpiredMsg{code, text}
+	})
+}
+
+// canKick reports whether the host may remove the opponent right now:
+// never mid-game, only in the lobby or once a game has finished.
+func (m Model) canKick() bool {
+	return m.MySide == "X" && m.Game.PlayerO != "" && m.Game.PlayerO != db.BotID && m.Game.Status != "playing"
+}
+
+// subscribeRoom starts listening for pushed updates and chat for the given
+// room, replacing any previous subscription.
+func (m Model) subscribeRoom(code string) (Model, tea.Cmd) {
+	m = m.unsubscribeRoom()
+	ch, stopRoom := bus.Default.Subscribe(code)
+	chatCh, stopChat := bus.Default.Subscribe(db.ChatTopic(code))
+	stop := func() {
+		stopRoom()
+		stopChat()
+	}
+	m.RoomEvents = ch
+	m.ChatEvents = chatCh
+	m.StopRoomEvents = stop
+
+	m.Cleanup.Mu.Lock()
+	m.Cleanup.StopEvents = stop
+	m.Cleanup.Mu.Unlock()
+
+	return m, tea.Batch(waitRoomEventCmd(code, ch), waitChatEventCmd(code, chatCh), loadChatCmd(code))
+}
+
+func (m Model) unsubscribeRoom() Model {
+	if m.StopRoomEvents != nil {
+		m.StopRoomEvents()
+	}
+	m.RoomEvents = nil
+	m.ChatEvents = nil
+	m.StopRoomEvents = nil
+	m.Chat = nil
+	m.ChatOpen = false
+	m.ChatNotice = ""
+	m.ChatScroll = 0
+	m.LastSync = time.Time{}
+	return m
+}
+
+func waitRoomEventCmd(code string, ch <-chan []byte) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		data, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return roomEventMsg{code: code, data: data}
+	}
+}
+
+// pollInterval picks how often to re-fetch the room. Only an active game
+// where someone else is about to move needs the fast cadence; lobbies and
+// finished games change rarely and are polled slowly to save DB reads.
+// With a bus that reaches every instance, moves arrive as events and the
+// poll is only a fallback, so it stays slow throughout.
+func (m Model) pollInterval() time.Duration {
+	iv := db.Intervals()
+	if m.State == StateLobby || m.Game.Status != "playing" {
+		return iv.Idle
+	}
+	if bus.Fanout() && m.RoomEvents != nil {
+		return iv.Idle
+	}
+	if m.MySide != "Spectator" && m.isMyTurn() {
+		return iv.Sync
+	}
+	return iv.Active
+}
+
+func pollCmd(code string, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
 		r, err := db.GetRoom(code)
 		if err != nil {
 			if err.Error() == "room does not exist" {
-				return roomUpdateMsg{}
+				return roomUpdateMsg{Code: code}
 			}
-			return pollErrorMsg(err)
+			return pollErrorMsg{code, err}
 		}
 		if r == nil {
-			return roomUpdateMsg{}
+			return roomUpdateMsg{Code: code}
 		}
 		return roomUpdateMsg(*r)
 	})
@@ -706,26 +1600,116 @@ func pollCmd(code string) tea.Cmd {
 
 // Updated Fetch Command
 func fetchPublicRoomsCmd() tea.Cmd {
-	return func() tea.Msg {
-		rooms, err := db.GetPublicRooms()
+	return storeCmd("Loading public rooms", fetchPublicRooms)
+}
+
+func fetchPublicRooms() tea.Msg {
+	rooms, err := db.GetPublicRooms()
+	if err != nil {
+		return errMsg(err)
+	}
+	featured, _ := db.GetFeatured()
+	return roomsFetchedMsg{rooms: rooms, featured: featured}
+}
+
+// publicSections splits the public rooms matching the search into the
+// featured game, rooms with an open seat, and full rooms, best matches
+// first.
+func (m Model) publicSections() (featured, open, full []db.Room) {
+	for _, r := range m.searchRooms(m.PublicRooms) {
+		switch {
+		case r.Code == m.Featured:
+			featured = append(featured, r)
+		case r.PlayerO == "":
+			open = append(open, r)
+		default:
+			full = append(full, r)
+		}
+	}
+	return featured, open, full
+}
+
+func featureCmd(adminID, code string) tea.Cmd {
+	return storeCmd("Featuring the room", func() tea.Msg {
+		if err := db.SetFeatured(adminID, code); err != nil {
+			return errMsg(err)
+		}
+		return fetchPublicRooms()
+	})
+}
+
+// watchGameCmd drops the player into a live public game as a spectator.
+func watchGameCmd(pid, name string, best bool) tea.Cmd {
+	return storeCmd("Finding a game to watch", func() tea.Msg {
+		r, err := db.PickLiveGame(pid, best)
 		if err != nil {
 			return errMsg(err)
 		}
-		return roomsFetchedMsg(rooms)
-	}
+		if r == nil {
+			return errMsg(fmt.Errorf("No live games to watch right now"))
+		}
+		if err := db.Spectate(r.Code, pid, name); err != nil {
+			return errMsg(err)
+		}
+		return roomJoinedMsg{code: r.Code, side: "Spectator", gameType: r.GameType}
+	})
 }
 
-func createRoomCmd(code, pid, name string, public bool, gameType string) tea.Cmd {
-	return func() tea.Msg {
-		if err := db.CreateRoom(code, pid, name, public, gameType); err != nil {
+func createRoomCmd(pid, name string, public bool, gameType, botLevel string, rules db.Rules) tea.Cmd {
+	return storeCmd("Creating the room", func() tea.Msg {
+		code, err := createWithFreshCode(func(code string) error {
+			return db.CreateRoom(code, pid, name, public, gameType, botLevel, rules)
+		})
+		if err != nil {
 			return errMsg(err)
 		}
 		return roomCreatedMsg{code: code, gameType: gameType}
+	})
+}
+
+func addBotCmd(code, hostID string) tea.Cmd {
+	return storeCmd("Adding the bot", func() tea.Msg {
+		if err := db.AddBot(code, hostID); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	})
+}
+
+// logRoomEventCmd adds to a room's event log what only the client saw.
+func logRoomEventCmd(code string, e db.RoomEvent) tea.Cmd {
+	return func() tea.Msg {
+		db.LogRoomEvent(code, e)
+		return nil
 	}
 }
 
+func swapSidesCmd(code, pid string, r db.Room) tea.Cmd {
+	retry := func(m Model) tea.Cmd {
+		if m.RoomCode != code || !m.canSwap() {
+			return nil
+		}
+		return swapSidesCmd(code, pid, m.Game)
+	}
+	return storeCmd("Swapping sides", func() tea.Msg {
+		if err := db.SwapSides(code, pid, r); err != nil {
+			return moveFailedMsg{code: code, err: err, retry: retry}
+		}
+		return nil
+	})
+}
+
+func kickCmd(code, hostID string) tea.Cmd {
+	return storeCmd("Kicking the player", func() tea.Msg {
+		if err := db.KickPlayer(code, hostID); err != nil {
+			return errMsg(err)
+		}
+		return opponentKickedMsg{}
+	})
+}
+
 func joinRoomCmd(code, pid, name string) tea.Cmd {
-	return func() tea.Msg {
+	return storeCmd("Joining the room", func() tea.Msg {
 		if err := db.JoinRoom(code, pid, name); err != nil {
 			return errMsg(err)
 		}
@@ -744,14 +1728,32 @@ func joinRoomCmd(code, pid, name string) tea.Cmd {
 			}
 		}
 		return roomJoinedMsg{code: code, side: side, gameType: gameType}
+	})
+}
+
+// createAttempts is how many codes createWithFreshCode draws before
+// giving up. With 32^4 codes, even a busy server rarely needs a second.
+const createAttempts = 5
+
+// createWithFreshCode runs create with new codes until one is free,
+// returning the code that was used.
+func createWithFreshCode(create func(code string) error) (string, error) {
+	for i := 0; ; i++ {
+		code := generateCode()
+		err := create(code)
+		if err != db.ErrCodeTaken {
+			return code, err
+		}
+		if i == createAttempts-1 {
+			return "", fmt.Errorf("couldn't find a free room code, please try again")
+		}
 	}
 }
 
 func generateCode() string {
-	chars := "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
-	b := make([]byte, 4)
+	b := make([]byte, codeLen)
 	for i := range b {
-		b[i] = chars[rand.Intn(len(chars))]
+		b[i] = codeAlphabet[rand.Intn(len(codeAlphabet))]
 	}
 	return string(b)
 }
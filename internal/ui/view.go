@@ -5,13 +5,31 @@ import (
 	"github.com/aminshahid573/termplay/internal/chess"
 	"github.com/aminshahid573/termplay/internal/db"
 	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/reflow/truncate"
+	"github.com/mattn/go-runewidth"
 )
 
 func (m Model) View() string {
+	if m.Unchanged && m.Frame != nil && m.Frame.frame != "" {
+		return m.Frame.frame
+	}
+	frame := m.view()
+	if m.theme() == themeHighContrast {
+		frame = highContrast(frame)
+	} else if m.Lean {
+		frame = leanColors(frame)
+	}
+	frame = m.Guard.frame(frame)
+	if m.Frame != nil {
+		m.Frame.frame = frame
+	}
+	return frame
+}
+
+func (m Model) view() string {
 	// Global Popup
 	if m.PopupActive {
 		var box string
@@ -29,9 +47,37 @@ func (m Model) View() string {
 				styles.Subtle.Render("[Esc] Cancel"),
 			)
 			box = styles.PopupBox.Render(content)
+		} else if m.PopupType == PopupReport {
+			msg := "Report your opponent to the moderators?\n(The recent chat is attached)"
+			box = styles.PopupBox.Render(
+				fmt.Sprintf("%s\n\n[Y] Yes    [N] No", msg),
+			)
+		} else if m.PopupType == PopupDuplicate {
+			box = renderDuplicate(m)
+		} else if m.PopupType == PopupOpponentLeft {
+			box = renderOpponentLeft(m)
+		} else if m.PopupType == PopupWarning {
+			box = styles.PopupBox.Render(lipgloss.JoinVertical(lipgloss.Center,
+				styles.Title.Render("WARNING"),
+				"A moderator reviewed your behavior:",
+				styles.Err.Render(m.Warning),
+				"",
+				"[Enter] I understand",
+			))
+		} else if m.PopupType == PopupKick {
+			msg := fmt.Sprintf("Remove %s from the room?\n(They won't be able to rejoin)", taggedName(m.Game.PlayerO, m.Game.PlayerOName))
+			box = styles.PopupBox.Render(
+				fmt.Sprintf("%s\n\n[Y] Yes    [N] No", msg),
+			)
 		} else {
 			// Default to Leave Popup
-			msg := "Are you sure you want to leave?\n(Room will be deleted if you are Host)"
+			msg := "Are you sure you want to leave?\n(If you are Host, your opponent takes over the room)"
+			if m.forfeitsOnLeave() {
+				msg = "Leave and forfeit this game?\n(Your opponent is credited with the win)"
+			}
+			if m.Game.KeepsSeat(m.SessionID) {
+				msg = "Step away from this correspondence game?\n(Your seat is kept: press C on the main menu to come back)"
+			}
 			box = styles.PopupBox.Render(
 				fmt.Sprintf("%s\n\n[Y] Yes    [N] No", msg),
 			)
@@ -40,7 +86,6 @@ func (m Model) View() string {
 	}
 
 	var content string
-	var helpText string
 
 	switch m.State {
 	case StateNameInput:
@@ -52,12 +97,24 @@ func (m Model) View() string {
 			m.TextInput.View(),
 			"\n",
 		)
-		helpText = "Enter: Confirm • Ctrl+C: Quit"
+		if guest := m.guestNotice(); guest != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, guest, "")
+		}
+		if motd := renderMOTD(m.MOTD); motd != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, motd)
+		}
 
 	case StateMenu:
-		opts := []string{"Create Room", "Join with Code", "Public Rooms", "Quit"}
 		var renderedOpts []string
-		for i, opt := range opts {
+		for i, opt := range menuItems() {
+			opt = m.tr(opt)
+			if i == 3 {
+				mode := m.tr("Random")
+				if m.WatchBest {
+					mode = m.tr("Top rated")
+				}
+				opt += " ‹" + mode + "›"
+			}
 			if i == m.MenuIndex {
 				renderedOpts = append(renderedOpts, styles.ItemFocused.Render(" "+opt+" "))
 			} else {
@@ -66,21 +123,33 @@ func (m Model) View() string {
 		}
 		list := lipgloss.JoinVertical(lipgloss.Left, renderedOpts...)
 		content = lipgloss.JoinVertical(lipgloss.Center,
-			styles.Title.Render("MAIN MENU"),
+			styles.Title.Render(m.tr("MAIN MENU")),
 			list,
 		)
-		helpText = "↑/↓: Navigate • Enter: Select"
+		if notice := renderRejoin(m); notice != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, "", notice)
+		}
+		if notice := renderCorrespondence(m); notice != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, "", notice)
+		}
+		if m.demoVisible() {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, "", renderDemo(m.Demo, m.Settings.ASCII))
+		}
 
 	case StateCreateConfig:
 		pubLabel := "  Public"
 		privLabel := "  Private"
+		on, off := "●", "○"
+		if m.Settings.ASCII {
+			on, off = "(*)", "( )"
+		}
 		var pubRendered, privRendered string
 		if m.IsPublicCreate {
-			pubRendered = styles.ItemFocused.Render("● " + pubLabel)
-			privRendered = styles.ItemBlurred.Render("○ " + privLabel)
+			pubRendered = styles.ItemFocused.Render(on + " " + pubLabel)
+			privRendered = styles.ItemBlurred.Render(off + " " + privLabel)
 		} else {
-			pubRendered = styles.ItemBlurred.Render("○ " + pubLabel)
-			privRendered = styles.ItemFocused.Render("● " + privLabel)
+			pubRendered = styles.ItemBlurred.Render(off + " " + pubLabel)
+			privRendered = styles.ItemFocused.Render(on + " " + privLabel)
 		}
 		content = lipgloss.JoinVertical(lipgloss.Center,
 			styles.Title.Render("ROOM SETTINGS"),
@@ -88,34 +157,51 @@ func (m Model) View() string {
 			"\n",
 			lipgloss.JoinVertical(lipgloss.Left, pubRendered, privRendered),
 			"\n",
+			"Mode:",
+			styles.ItemFocused.Render("< "+rankedLabel(m.Rules.Ranked)+" >"),
+			styles.Subtle.Render(rankedHint(m.Rules.Ranked)),
+			"\n",
+			"Pace:",
+			styles.ItemFocused.Render("< "+paceLabel(m.Rules.Correspondence)+" >"),
+			styles.Subtle.Render(paceHint(m.Rules.Correspondence)),
+			"\n",
 		)
-		if m.Err != nil {
-			content = lipgloss.JoinVertical(lipgloss.Center, content, styles.Err.Render(m.Err.Error()))
+		if m.SelectedGame != "chess" {
+			if !m.Rules.Ranked {
+				// The bot only fills in for live games
+				if !m.Rules.Correspondence {
+					content = lipgloss.JoinVertical(lipgloss.Center, content,
+						"Bot Difficulty (if no one joins):",
+						styles.ItemFocused.Render("< "+botLevelLabel(m.BotLevel)+" >"),
+						"\n",
+					)
+				}
+				content = lipgloss.JoinVertical(lipgloss.Center, content,
+					"Handicap:",
+					styles.ItemFocused.Render("< "+handicapLabel(m.Rules.Handicap)+" >"),
+					styles.Subtle.Render("The player behind in the room's score is the weaker one"),
+					"\n",
+				)
+			}
+			content = lipgloss.JoinVertical(lipgloss.Center, content,
+				"Swap Rule:",
+				styles.ItemFocused.Render("< "+onOff(m.Rules.PieRule)+" >"),
+				styles.Subtle.Render("After X's first move, O may take it over instead of replying"),
+				"\n",
+			)
 		}
-		helpText = "↑/↓: Change • Enter: Create • Esc: Back"
 
 	case StateInputCode:
-		errView := ""
-		if m.Err != nil {
-			errView = styles.Base.Foreground(lipgloss.Color("#F25D94")).Render("\n" + m.Err.Error())
-		}
 		content = lipgloss.JoinVertical(lipgloss.Center,
 			styles.Title.Render("JOIN ROOM"),
 			styles.ListContainer.Width(30).Render( // Re-use container for consistent look
 				m.TextInput.View(),
 			),
-			errView,
+			renderCodeCheck(m),
 		)
-		helpText = "Enter: Join • Esc: Back"
 
 	case StatePublicList:
 		content = renderPublicList(m)
-		// Add error display if fetch failed
-		if m.Err != nil {
-			errText := styles.Base.Foreground(lipgloss.Color("#F25D94")).Render(fmt.Sprintf("\nError: %v", m.Err))
-			content = lipgloss.JoinVertical(lipgloss.Center, content, errText)
-		}
-		helpText = "↑/↓: Navigate • Enter: Join • Type: Filter • Esc: Back"
 
 	case StateLobby:
 		code := styles.Base.Foreground(lipgloss.Color("#e3b7ff")).Bold(true).Render(m.RoomCode)
@@ -125,11 +211,53 @@ func (m Model) View() string {
 			"\nWaiting for opponent...",
 			styles.Subtle.Render("Share this code with your friend"),
 		)
-		helpText = "Esc: Leave Room"
+		if expiry := renderRoomExpiry(m); expiry != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, expiry)
+		}
+		if m.Notice != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, "", styles.Special.Render(m.Notice))
+		}
+		content = lipgloss.JoinVertical(lipgloss.Center, content, "",
+			styles.Subtle.Render("While you wait: P to browse rooms, V for a quick bot game."),
+			styles.Subtle.Render("You'll be brought back when someone joins."))
+		if m.canOfferBot() {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, "",
+				styles.Special.Render(fmt.Sprintf("No one yet? Press B to play vs bot instead (%s)", botLevelLabel(m.Game.BotLevel))),
+				styles.Subtle.Render("A friend can still join and take the bot's seat"))
+		}
+
+	case StateMaintenance:
+		content = renderMaintenance(m)
+
+	case StateServerStatus:
+		content = renderServerStatus(m)
+
+	case StateLobbyChat:
+		content = renderLobbyChat(m)
+
+	case StateRoomAlert:
+		content = renderRoomAlert(m)
 
 	case StateGameSelect:
 		content = renderGameSelect(m)
-		helpText = "↑/↓: Navigate • Enter: Select"
+
+	case StateTutorial:
+		content = renderTutorial(m)
+
+	case StatePuzzle:
+		content = renderPuzzle(m)
+
+	case StateSettings:
+		content = renderSettings(m)
+
+	case StateScreensaver:
+		return renderScreensaver(m)
+
+	case StateLeaderboard:
+		content = renderLeaderboard(m)
+
+	case StateAdmin:
+		content = renderAdmin(m)
 
 	case StateSnakeGame:
 		// Snake handles its own rendering; we just center it
@@ -143,18 +271,33 @@ func (m Model) View() string {
 
 	case StateGame:
 		content = renderGame(m)
-		if m.Game.GameType == "chess" {
-			helpText = "arrows/hjkl move • enter/space select • esc deselect • f font • q quit"
-		} else {
-			helpText = "Arrows: Move • Space: Place • R: Restart • Q: Quit"
+		if m.Notice != "" {
+			content = lipgloss.JoinVertical(lipgloss.Center, content, styles.Special.Render(m.Notice))
 		}
+		content = m.layoutGame(content)
 	}
 
-	// Combine Content + Help Footer
+	// Combine Content + Footer, the status line last
+	footer := lipgloss.JoinVertical(lipgloss.Center, renderBuildLine(m), renderStatusLine(m))
+	if slow := renderStoreLine(m); slow != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Center, slow, footer)
+	}
+	if m.inRoom() {
+		footer = lipgloss.JoinVertical(lipgloss.Center, renderStatusBar(m), footer)
+	}
+	if tabs := renderTabBar(m); tabs != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Center, tabs, footer)
+	}
+	if toast := renderToast(m); toast != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Center, toast, footer)
+	}
+	if banner := renderMaintenanceBanner(m.Maintenance); banner != "" && m.State != StateMaintenance {
+		footer = lipgloss.JoinVertical(lipgloss.Center, banner, footer)
+	}
 	finalView := lipgloss.JoinVertical(lipgloss.Center,
 		content,
 		"\n",
-		styles.Subtle.Render(helpText),
+		footer,
 	)
 
 	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, finalView)
@@ -163,20 +306,7 @@ func (m Model) View() string {
 // --- List Rendering Logic ---
 
 func renderPublicList(m Model) string {
-	// Filter logic
-	var openRooms, fullRooms []db.Room
-	filter := strings.ToUpper(m.SearchInput.Value())
-
-	for _, r := range m.PublicRooms {
-		// Show ALL by default (filter == ""), or match filter
-		if filter == "" || strings.Contains(r.Code, filter) || strings.Contains(strings.ToUpper(r.PlayerXName), filter) {
-			if r.PlayerO == "" {
-				openRooms = append(openRooms, r)
-			} else {
-				fullRooms = append(fullRooms, r)
-			}
-		}
-	}
+	featured, openRooms, fullRooms := m.publicSections()
 
 	// Container is 70 wide; border uses 2, padding(0,1) uses 2, so inner = 66
 	listWidth := 66
@@ -186,37 +316,57 @@ func renderPublicList(m Model) string {
 	// 1. Search Bar (Borderless inside the box)
 	searchView := m.SearchInput.View()
 	listContent = append(listContent, searchView)
+	listContent = append(listContent, renderFilterChips(m))
 	listContent = append(listContent, "") // Spacer
 
-	// 2. Open Rooms Section
+	// 2. Featured game, pinned by an admin
+	if len(featured) > 0 {
+		listContent = append(listContent, renderSectionHeader(" Featured ", listWidth, "★ Picked for you"))
+		for i, r := range featured {
+			hit, _ := m.matchRoom(r)
+			listContent = append(listContent, renderRoomItem(r, i == m.ListSelectedRow, listWidth, m.Settings.ASCII, hit))
+		}
+		listContent = append(listContent, "")
+	}
+	offset := len(featured)
+
+	// 3. Open Rooms Section
 	listContent = append(listContent, renderSectionHeader(" Open Rooms ", listWidth, "✓ Joinable"))
-	if len(openRooms) == 0 {
+	if len(openRooms) == 0 && m.loading() && m.Loading == loadingRooms {
+		listContent = append(listContent, "  "+renderLoading(m))
+	} else if len(openRooms) == 0 {
 		listContent = append(listContent, styles.Subtle.Render("  No open rooms found"))
 	} else {
 		for i, r := range openRooms {
-			isSelected := (i == m.ListSelectedRow)
-			listContent = append(listContent, renderRoomItem(r, isSelected, listWidth))
+			isSelected := (i+offset == m.ListSelectedRow)
+			hit, _ := m.matchRoom(r)
+			listContent = append(listContent, renderRoomItem(r, isSelected, listWidth, m.Settings.ASCII, hit))
 		}
 	}
 	listContent = append(listContent, "")
 
-	// 3. Full Rooms Section
+	// 4. Full Rooms Section
 	listContent = append(listContent, renderSectionHeader(" Full Rooms ", listWidth, "Spectate"))
 	if len(fullRooms) == 0 {
 		listContent = append(listContent, styles.Subtle.Render("  No full rooms"))
 	} else {
 		for i, r := range fullRooms {
-			isSelected := (i+len(openRooms) == m.ListSelectedRow)
-			listContent = append(listContent, renderRoomItem(r, isSelected, listWidth))
+			isSelected := (i+offset+len(openRooms) == m.ListSelectedRow)
+			hit, _ := m.matchRoom(r)
+			listContent = append(listContent, renderRoomItem(r, isSelected, listWidth, m.Settings.ASCII, hit))
 		}
 	}
 
 	// Wrap everything in the Bordered Container
 	inner := lipgloss.JoinVertical(lipgloss.Left, listContent...)
 
+	list := styles.ListContainer.Render(inner)
+	if preview := renderPreview(m); preview != "" {
+		list = lipgloss.JoinHorizontal(lipgloss.Top, list, "  ", preview)
+	}
 	return lipgloss.JoinVertical(lipgloss.Center,
 		styles.Title.Render("PUBLIC ROOMS"),
-		styles.ListContainer.Render(inner),
+		list,
 	)
 }
 
@@ -238,8 +388,30 @@ func renderSectionHeader(text string, width int, info string) string {
 	return titleRendered + " " + line + infoRendered
 }
 
-func renderRoomItem(r db.Room, focused bool, width int) string {
-	name := fmt.Sprintf("%s's Room", r.PlayerXName)
+// roomTitle is what the public list says about r: the host and the
+// room's options while it waits, the score once it is under way.
+func roomTitle(r db.Room, ascii bool) string {
+	if r.PlayerO != "" {
+		return liveStatus(r, ascii)
+	}
+	name := fmt.Sprintf("%s's Room", playerName(r, r.PlayerX, r.PlayerXName, ascii))
+	if r.Handicap != db.HandicapNone && r.GameType != "chess" {
+		name += " • handicap: " + strings.ToLower(handicapLabel(r.Handicap))
+	}
+	if r.PieRule && r.GameType != "chess" {
+		name += " • swap rule"
+	}
+	name += " • " + strings.ToLower(rankedLabel(r.Ranked))
+	if r.Correspondence {
+		name += " • correspondence"
+	}
+	return name
+}
+
+// renderRoomItem draws one row of the public list, with the characters
+// the search matched in hit picked out.
+func renderRoomItem(r db.Room, focused bool, width int, ascii bool, hit roomMatch) string {
+	name := roomTitle(r, ascii)
 	code := r.Code
 
 	style := styles.ItemBlurred
@@ -251,16 +423,54 @@ func renderRoomItem(r db.Room, focused bool, width int) string {
 	}
 
 	rightText := fmt.Sprintf(" %s ", code)
+	if !r.HasRoom(r.PlayerO != "") {
+		rightText = fmt.Sprintf(" at capacity • %s ", code)
+	} else if n := len(r.Spectators); n > 0 {
+		rightText = fmt.Sprintf(" %d watching • %s ", n, code)
+	}
 	rightRendered := infoStyle.Render(rightText)
 	rightWidth := lipgloss.Width(rightRendered)
 
 	availableWidth := width - rightWidth - 2
-	name = truncate.StringWithTail(name, uint(availableWidth), "...")
+	name = fitWidth(name, availableWidth)
 
-	nameWidth := lipgloss.Width(name)
+	nameWidth := runewidth.StringWidth(name)
 	gap := strings.Repeat(" ", max(0, width-nameWidth-rightWidth))
 
-	return style.Render(name + gap + rightRendered)
+	if len(hit.title) == 0 && len(hit.code) == 0 {
+		return style.Render(name + gap + rightRendered)
+	}
+	// Styled a piece at a time, as an inner reset would end the row's
+	// background part way along
+	base := style.UnsetPadding()
+	info := infoStyle.Inherit(base)
+	codeAt := len([]rune(rightText)) - len([]rune(code)) - 1
+	return base.Render(" ") +
+		highlightRunes(name, hit.title, base) +
+		base.Render(gap) +
+		info.Render(string([]rune(rightText)[:codeAt])) +
+		highlightRunes(code, hit.code, info) +
+		info.Render(" ") +
+		base.Render(" ")
+}
+
+// liveStatus summarises a game in progress for would-be spectators,
+// e.g. "alice 2–1 bob • move 6 • bob to move".
+func liveStatus(r db.Room, ascii bool) string {
+	s := fmt.Sprintf("%s %d–%d %s • move %d",
+		playerName(r, r.PlayerX, r.PlayerXName, ascii), r.WinsX, r.WinsO,
+		playerName(r, r.PlayerO, r.PlayerOName, ascii), len(r.Moves))
+	switch r.Status {
+	case "finished":
+		return s + " • game over"
+	case "waiting":
+		return s + " • waiting"
+	}
+	next := displayName(r.PlayerXName)
+	if r.Turn == "O" || r.Turn == "Black" {
+		next = displayName(r.PlayerOName)
+	}
+	return s + " • " + next + " to move"
 }
 
 func max(a, b int) int {
@@ -271,9 +481,10 @@ func max(a, b int) int {
 }
 
 func renderGameSelect(m Model) string {
-	opts := []string{"Tic Tac Toe", "Chess", "Snake"}
+	opts := []string{"Tic Tac Toe", "Chess", "Snake", "Puzzles", "How to Play", "Leaderboard", "Server Status", "Settings"}
 	var renderedOpts []string
 	for i, opt := range opts {
+		opt = m.tr(opt)
 		if i == m.MenuIndex {
 			renderedOpts = append(renderedOpts, styles.ItemFocused.Render(" "+opt+" "))
 		} else {
@@ -281,10 +492,14 @@ func renderGameSelect(m Model) string {
 		}
 	}
 	list := lipgloss.JoinVertical(lipgloss.Left, renderedOpts...)
-	return lipgloss.JoinVertical(lipgloss.Center,
-		styles.Title.Render("SELECT GAME"),
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		styles.Title.Render(m.tr("SELECT GAME")),
 		list,
 	)
+	if !m.TutorialDone {
+		content = lipgloss.JoinVertical(lipgloss.Center, content, "", styles.Special.Render(m.tr("New here? Try How to Play")))
+	}
+	return content
 }
 
 func renderGame(m Model) string {
@@ -292,58 +507,48 @@ func renderGame(m Model) string {
 		return renderChessGame(m)
 	}
 
+	oName := playerName(m.Game, m.Game.PlayerO, m.Game.PlayerOName, m.Settings.ASCII)
+	if m.Game.PlayerO == db.BotID {
+		oName = fmt.Sprintf("%s [%s]", oName, botLevelLabel(m.Game.BotLevel))
+	}
 	header := lipgloss.JoinHorizontal(lipgloss.Center,
-		fmt.Sprintf("%s (Wins: %d)", m.Game.PlayerXName, m.Game.WinsX),
+		playerName(m.Game, m.Game.PlayerX, m.Game.PlayerXName, m.Settings.ASCII),
 		"  VS  ",
-		fmt.Sprintf("%s (Wins: %d)", m.Game.PlayerOName, m.Game.WinsO),
+		oName,
 	)
+	if series := renderSeries(m.Game, m.Settings.ASCII); series != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center, header, series)
+	}
+	if h2h := renderHeadToHead(m); h2h != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center, header, h2h)
+	}
+	if h := renderHandicap(m.Game); h != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center, header, h)
+	}
 
-	var rows []string
-	for r := 0; r < 3; r++ {
-		var cols []string
-		for c := 0; c < 3; c++ {
-			idx := r*3 + c
-			val := m.Game.Board[idx]
-			style := styles.Cell
-			if m.Game.GameType == "tictactoe" {
-				// No change, tictactoe is default
-			}
-
-			isWinCell := false
-			for _, wIdx := range m.Game.WinningLine {
-				if idx == wIdx {
-					isWinCell = true
-				}
-			}
-			if isWinCell {
-				style = styles.CellWin
-			}
-
-			if m.Game.Status == "playing" && m.Game.Turn == m.MySide {
-				if r == m.CursorR && c == m.CursorC {
-					style = styles.CellSelected
-				}
-			}
-
-			content := " "
-			if val == "X" {
-				content = styles.XStyle.Render("X")
-			}
-			if val == "O" {
-				content = styles.OStyle.Render("O")
-			}
-			cols = append(cols, style.Render(content))
-		}
-		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	showCursor := m.Game.Status == "playing" && (m.Game.Turn == m.MySide || m.canPremove())
+	b := m.Game.Board
+	ghost := -1
+	if m.MovePending {
+		// Show the pending mark faintly until it is confirmed
+		ghost = m.PendingR*3 + m.PendingC
+		b[ghost] = tictactoe.ParseCell(m.MySide)
+	} else if m.Premoved {
+		ghost = m.PremoveIdx
+		b[ghost] = tictactoe.ParseCell(m.MySide)
 	}
-	board := lipgloss.JoinVertical(lipgloss.Center, rows...)
+	board := renderTicTacToeBoard(b, m.Game.WinningLine, m.CursorR, m.CursorC, showCursor, ghost, roomMarks(m.Game, m.Settings.ASCII), m.boardZoom())
 
 	status := ""
 	if m.Game.Status == "waiting" {
-		status = "Opponent disconnected. Waiting..."
+		status = m.waitingLine()
 	} else if m.Game.Status == "finished" {
 		res := "DRAW"
-		if m.Game.Winner != "" {
+		if m.Game.Flagged != "" {
+			res = renderFlagged(m.Game)
+		} else if m.Game.Forfeit != "" {
+			res = renderForfeit(m.Game)
+		} else if m.Game.Winner != "" {
 			res = m.Game.Winner + " WINS!"
 		}
 		status = fmt.Sprintf("%s", res)
@@ -352,9 +557,19 @@ func renderGame(m Model) string {
 		status = fmt.Sprintf("Turn: %s", turn)
 		if m.MySide == "Spectator" {
 			status = fmt.Sprintf("[SPECTATING] Turn: %s", turn)
+		} else if m.MovePending {
+			status = "Press Enter again to confirm • Esc: Cancel"
+		} else if m.canSwap() {
+			status += " • reply, or press S to take X's opening as your own"
+		} else if m.Premoved {
+			status += " • premove staged, played when your turn comes (Esc: Cancel)"
 		}
 	}
 
+	if clock := renderGameClock(m); clock != "" {
+		status = lipgloss.JoinVertical(lipgloss.Center, status, clock)
+	}
+
 	return lipgloss.JoinVertical(lipgloss.Center,
 		styles.Title.Render("TICTACTOE"),
 		header,
@@ -365,14 +580,115 @@ func renderGame(m Model) string {
 	)
 }
 
+// forfeitsOnLeave reports whether leaving the room now loses the game
+// under way, see db.LeaveRoom.
+func (m Model) forfeitsOnLeave() bool {
+	g := m.Game
+	return m.MySide != "Spectator" && g.Status == "playing" && len(g.Moves) > 0 && g.PlayerO != "" && g.PlayerO != db.BotID
+}
+
+// renderForfeit is the result line for a game lost by leaving it.
+func renderForfeit(r db.Room) string {
+	loser := r.PlayerXName
+	if r.Forfeit == "O" {
+		loser = r.PlayerOName
+	}
+	return fmt.Sprintf("%s left the game. %s WINS!", displayName(loser), r.Winner)
+}
+
+// renderSeries is the running score of the players in the room, e.g.
+// "Ranked game 4 • alice 2–1 bob". Draws count as games but not as wins.
+func renderSeries(r db.Room, ascii bool) string {
+	n := r.Games
+	if r.Status == "playing" {
+		n++
+	}
+	if n == 0 || r.PlayerO == "" {
+		return ""
+	}
+	series := styles.Subtle.Render(fmt.Sprintf("%s game %d • %s %d–%d %s", rankedLabel(r.Ranked),
+		n, displayName(r.PlayerXName), r.WinsX, r.WinsO, displayName(r.PlayerOName)))
+	if streak := renderStreak(r, ascii); streak != "" {
+		series += styles.Subtle.Render(" • ") + streak
+	}
+	return series
+}
+
+// streakShown is how many wins in a row make a streak worth showing.
+const streakShown = 2
+
+// renderStreak shows who is on a roll in the series, e.g. "🔥 Ann 3-win
+// streak".
+func renderStreak(r db.Room, ascii bool) string {
+	side, n := r.StreakOf()
+	if n < streakShown {
+		return ""
+	}
+	name := r.PlayerXName
+	if side == "O" {
+		name = r.PlayerOName
+	}
+	flame := "🔥 "
+	if ascii {
+		flame = ""
+	}
+	return styles.Special.Render(fmt.Sprintf("%s%s %d-win streak", flame, displayName(name), n))
+}
+
+// renderTicTacToeBoard draws the 3x3 grid at size z, highlighting the
+// winning line and, when showCursor is set, the cell under the cursor.
+func renderTicTacToeBoard(b tictactoe.Board, winLine []int, curR, curC int, showCursor bool, ghost int, marks markGlyphs, z zoom) string {
+	if z == zoomCompact {
+		return renderCompactBoard(b, winLine, curR, curC, showCursor, ghost, marks)
+	}
+	cell, selected, win := cellStyles(z)
+	var rows []string
+	for r := 0; r < 3; r++ {
+		var cols []string
+		for c := 0; c < 3; c++ {
+			idx := r*3 + c
+			val := b[idx]
+			style := cell
+
+			isWinCell := false
+			for _, wIdx := range winLine {
+				if idx == wIdx {
+					isWinCell = true
+				}
+			}
+			if isWinCell {
+				style = win
+			}
+
+			if showCursor && r == curR && c == curC {
+				style = selected
+			}
+
+			content := marks.render(val)
+			if idx == ghost {
+				content = styles.Muted.Render(marks.glyph(val))
+			}
+			cols = append(cols, style.Render(content))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, rows...)
+}
+
 func renderChessGame(m Model) string {
 	header := lipgloss.JoinHorizontal(lipgloss.Center,
-		fmt.Sprintf("%s (White)", m.Game.PlayerXName),
+		fmt.Sprintf("%s (White)", playerName(m.Game, m.Game.PlayerX, m.Game.PlayerXName, m.Settings.ASCII)),
 		"  VS  ",
-		fmt.Sprintf("%s (Black)", m.Game.PlayerOName),
+		fmt.Sprintf("%s (Black)", playerName(m.Game, m.Game.PlayerO, m.Game.PlayerOName, m.Settings.ASCII)),
 	)
+	if series := renderSeries(m.Game, m.Settings.ASCII); series != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center, header, series)
+	}
+	if h2h := renderHeadToHead(m); h2h != "" {
+		header = lipgloss.JoinVertical(lipgloss.Center, header, h2h)
+	}
 
-	sqW, sqH := computeChessSquareSize(m.Width, m.Height)
+	sqW, sqH := chessSquareSize(m.boardZoom(), m.boardWidth(), m.Height)
 
 	isFlipped := (m.MySide == "O")
 
@@ -434,8 +750,12 @@ func renderChessGame(m Model) string {
 			isValidMove := m.ChessValidMoves[chess.Pos{Row: br, Col: bc}]
 			isCapture := isValidMove && !m.Game.ChessState.Board[br][bc].IsEmpty()
 
+			isPending := m.MovePending && m.PendingR == br && m.PendingC == bc
+
 			if isSelected {
 				bg = styles.ChessSelected
+			} else if isPending {
+				bg = styles.ChessPending
 			} else if isCapture {
 				bg = styles.ChessCapture
 			} else if isValidMove {
@@ -453,7 +773,7 @@ func renderChessGame(m Model) string {
 				Width(sqW).
 				Height(sqH).
 				Align(lipgloss.Center, lipgloss.Center).
-				Render(chessPieceSymbol(piece, m.UseNerdFont))
+				Render(chessPieceSymbol(piece, m.UseNerdFont && m.theme() != themeHighContrast, m.Settings.ASCII))
 
 			rowCells = append(rowCells, cell)
 		}
@@ -504,11 +824,15 @@ func renderChessGame(m Model) string {
 	isBold := false
 
 	if m.Game.Status == "waiting" {
-		statusText = "Opponent disconnected. Waiting..."
+		statusText = m.waitingLine()
 	} else if m.Game.Status == "finished" {
 		isBold = true
 		statusColor = styles.ChessCapture
-		if m.Game.Winner == "Draw" {
+		if m.Game.Flagged != "" {
+			statusText = strings.ToUpper(renderFlagged(m.Game))
+		} else if m.Game.Forfeit != "" {
+			statusText = strings.ToUpper(renderForfeit(m.Game))
+		} else if m.Game.Winner == "Draw" {
 			statusText = "STALEMATE - DRAW!"
 		} else if m.Game.Winner != "" {
 			statusText = "CHECKMATE! " + strings.ToUpper(m.Game.Winner) + " WINS!"
@@ -527,12 +851,14 @@ func renderChessGame(m Model) string {
 
 		if m.MySide == "Spectator" {
 			statusText += "[SPECTATING]"
+		} else if isMyTurn && m.MovePending {
+			statusText += "Press Enter again to confirm"
 		} else if isMyTurn {
 			statusText += "Your turn"
 		} else {
-			opponentName := m.Game.PlayerOName
+			opponentName := displayName(m.Game.PlayerOName)
 			if m.MySide == "O" {
-				opponentName = m.Game.PlayerXName
+				opponentName = displayName(m.Game.PlayerXName)
 			}
 			statusText += opponentName + "'s turn"
 		}
@@ -542,6 +868,9 @@ func renderChessGame(m Model) string {
 		Foreground(statusColor).
 		Bold(isBold).
 		Render(statusText)
+	if clock := renderGameClock(m); clock != "" {
+		status = lipgloss.JoinVertical(lipgloss.Center, status, clock)
+	}
 
 	content := lipgloss.JoinVertical(lipgloss.Center,
 		styles.Title.Render("CHESS"),
@@ -630,11 +959,19 @@ const (
 	ucBlackPawn   = "♟"
 )
 
-func chessPieceSymbol(p chess.Piece, useNerd bool) string {
+func chessPieceSymbol(p chess.Piece, useNerd, ascii bool) string {
 	if p.IsEmpty() {
 		return ""
 	}
 
+	// ASCII: FEN-style letters, uppercase for White
+	if ascii {
+		if p.IsWhite {
+			return p.Type
+		}
+		return strings.ToLower(p.Type)
+	}
+
 	if useNerd {
 		switch p.Type {
 		case "K":
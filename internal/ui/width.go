@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+)
+
+// Player names are user input and may contain wide runes (CJK, emoji).
+// Anything that lines names up must measure them in terminal columns, not
+// bytes or runes, or columns drift and centered text lands off-center.
+
+// maxNameWidth is the most columns a name takes up on screen: twelve
+// double-width runes, the longest name the name input accepts.
+const maxNameWidth = 24
+
+// fitWidth truncates s to at most w columns, marking the cut with "…".
+func fitWidth(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	return truncate.StringWithTail(s, uint(w), "…")
+}
+
+// padWidth truncates or pads s with spaces to exactly w columns.
+func padWidth(s string, w int) string {
+	return runewidth.FillRight(fitWidth(s, w), w)
+}
+
+// displayName caps a player name for headers, lists and chat.
+func displayName(name string) string {
+	return fitWidth(name, maxNameWidth)
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/termplay/internal/styles"
+	"github.com/aminshahid573/termplay/internal/tictactoe"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// zoom is how big the game board is drawn. Players step through the sizes
+// with +/- and go back to zoomAuto, which fits the board to the terminal,
+// with 0.
+type zoom int
+
+const (
+	zoomAuto    zoom = iota
+	zoomCompact      // one mark per cell, no borders
+	zoomNormal       // bordered cells one row high
+	zoomLarge        // the big bordered cells
+)
+
+func (z zoom) String() string {
+	switch z {
+	case zoomCompact:
+		return "compact"
+	case zoomNormal:
+		return "normal"
+	case zoomLarge:
+		return "large"
+	}
+	return "fit to screen"
+}
+
+// boardZoom is the size the board is drawn at: the player's pick, or the
+// largest that leaves room for the rest of the game screen.
+func (m Model) boardZoom() zoom {
+	if m.Zoom != zoomAuto {
+		return m.Zoom
+	}
+	switch {
+	case m.Height >= 36 && m.boardWidth() >= 50:
+		return zoomLarge
+	case m.Height >= 22 && m.boardWidth() >= 30:
+		return zoomNormal
+	}
+	return zoomCompact
+}
+
+// updateZoom handles the zoom keys on the game screen, reporting whether
+// msg was one.
+func updateZoom(m Model, msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	z := m.boardZoom()
+	switch msg.String() {
+	case "+", "=":
+		if z < zoomLarge {
+			z++
+		}
+	case "-":
+		if z > zoomCompact {
+			z--
+		}
+	case "0":
+		z = zoomAuto
+	default:
+		return m, nil, false
+	}
+	m.Zoom = z
+	m.Notice = "Board: " + z.String()
+	return m, clearNoticeCmd(m.RoomCode, m.Notice, 2*time.Second), true
+}
+
+// chessSquareSize is the width and height of a chess square at z.
+func chessSquareSize(z zoom, termWidth, termHeight int) (sqW, sqH int) {
+	switch z {
+	case zoomCompact:
+		return 2, 1
+	case zoomNormal:
+		return 4, 2
+	case zoomLarge:
+		return 6, 3
+	}
+	return computeChessSquareSize(termWidth, termHeight)
+}
+
+// cellStyles are the plain, selected and winning tic-tac-toe cell styles
+// for the bordered sizes.
+func cellStyles(z zoom) (cell, selected, win lipgloss.Style) {
+	cell, selected, win = styles.Cell, styles.CellSelected, styles.CellWin
+	if z == zoomNormal {
+		return cell.Width(6).Height(1), selected.Width(6).Height(1), win.Width(6).Height(1)
+	}
+	return cell, selected, win
+}
+
+var (
+	compactCursor = lipgloss.NewStyle().Reverse(true)
+	compactWin    = lipgloss.NewStyle().Background(lipgloss.Color("22"))
+)
+
+// renderCompactBoard draws the 3x3 grid one mark per cell, with the
+// cursor in reverse video.
+func renderCompactBoard(b tictactoe.Board, winLine []int, curR, curC int, showCursor bool, ghost int, marks markGlyphs) string {
+	won := map[int]bool{}
+	for _, i := range winLine {
+		won[i] = true
+	}
+	var rows []string
+	for r := 0; r < 3; r++ {
+		var cells []string
+		for c := 0; c < 3; c++ {
+			idx := r*3 + c
+			content := marks.render(b[idx])
+			switch {
+			case idx == ghost:
+				content = styles.Muted.Render(marks.glyph(b[idx]))
+			case b[idx] == tictactoe.Empty:
+				content = styles.Muted.Render(lipgloss.PlaceHorizontal(markSlot, lipgloss.Center, "·"))
+			}
+			content = " " + content + " "
+			if showCursor && r == curR && c == curC {
+				content = compactCursor.Render(content)
+			} else if won[idx] {
+				content = compactWin.Render(content)
+			}
+			cells = append(cells, content)
+		}
+		rows = append(rows, strings.Join(cells, styles.Muted.Render("│")))
+	}
+	sep := styles.Muted.Render(fmt.Sprintf("%[1]s┼%[1]s┼%[1]s", strings.Repeat("─", markSlot+2)))
+	return lipgloss.JoinVertical(lipgloss.Center, rows[0], sep, rows[1], sep, rows[2])
+}